@@ -3,11 +3,14 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"rires-be/config"
 	_ "rires-be/docs" // Swagger docs
 	"rires-be/internal/routes"
 	"rires-be/pkg/database"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -37,12 +40,32 @@ func main() {
 		log.Fatal("Failed to load config:", err)
 	}
 
+	// Load JWT signing & verification keys
+	if err := config.AppConfig.ValidateJWT(); err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	if err := utils.LoadJWTKeys(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Connect to main database rires
 	if err := database.Connect(config.AppConfig.GetDSN()); err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	defer database.CloseDB()
 
+	// Create tables managed by this app (tokens, etc.)
+	if err := database.Migrate(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Link pengajuan lama ke periode pendaftaran (id_periode); aman diulang setiap start
+	if count, err := services.NewPeriodeService().Backfill(); err != nil {
+		log.Println("Failed to backfill periode pengajuan:", err)
+	} else if count > 0 {
+		log.Printf("Linked %d pengajuan to their periode", count)
+	}
+
 	// Connect to external databases (NEOMAA, NEOMAAREF, SIMPEG)
 	if err := database.ConnectExternal(
 		config.AppConfig.GetDSNNeomaa(),
@@ -52,17 +75,46 @@ func main() {
 		log.Fatal("Failed to connect to external databases:", err)
 	}
 
-	// Create Fiber app
-	app := fiber.New(fiber.Config{
-		AppName: config.AppConfig.AppName,
-	})
+	// Purge expired refresh/revoked tokens periodically
+	go func() {
+		tokenService := services.NewTokenService()
+		for range time.Tick(time.Hour) {
+			if err := tokenService.PurgeExpired(); err != nil {
+				log.Println("Failed to purge expired tokens:", err)
+			}
+		}
+	}()
+
+	// Expire unanswered team invitations periodically
+	go func() {
+		invitationService := services.NewInvitationService()
+		for range time.Tick(time.Hour) {
+			if err := invitationService.ExpireStale(); err != nil {
+				log.Println("Failed to expire invitations:", err)
+			}
+		}
+	}()
+
+	// Create Fiber app. ProxyHeader hanya dipercaya dari TRUSTED_PROXIES;
+	// tanpa itu client bisa mengirim X-Forwarded-For sendiri dan lolos lockout per IP
+	fiberConfig := fiber.Config{AppName: config.AppConfig.AppName}
+	if config.AppConfig.ProxyHeader != "" {
+		if trustedProxies := config.AppConfig.GetTrustedProxies(); len(trustedProxies) > 0 {
+			fiberConfig.ProxyHeader = config.AppConfig.ProxyHeader
+			fiberConfig.EnableTrustedProxyCheck = true
+			fiberConfig.TrustedProxies = trustedProxies
+		} else {
+			log.Println("Warning: PROXY_HEADER is ignored because TRUSTED_PROXIES is empty")
+		}
+	}
+	app := fiber.New(fiberConfig)
 
 	// Middleware
 	app.Use(recover.New()) // Recover from panics
 	app.Use(logger.New())  // Log requests
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-API-Key",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 
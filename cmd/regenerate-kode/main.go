@@ -0,0 +1,67 @@
+// Command regenerate-kode memperbaiki kode_pengajuan buatan generator lama,
+// mis. "PKM-PKM RE (Riset Eksakata)-2026-004", dan kode yang dipakai lebih dari satu pengajuan.
+// Kode yang dibuat dengan template sebelumnya (sebelum admin mengganti template) tidak diubah.
+//
+// Tanpa -apply hanya menampilkan daftar kode yang akan diganti:
+//
+//	go run ./cmd/regenerate-kode
+//	go run ./cmd/regenerate-kode -apply
+//
+// Lengkapi kode kategori (PUT /api/v1/kategori-pkm/:id, field kode) sebelum menjalankan -apply.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"rires-be/config"
+	"rires-be/pkg/database"
+	"rires-be/pkg/services"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "simpan kode baru (tanpa flag ini hanya dry-run)")
+	flag.Parse()
+
+	if err := config.LoadConfig(); err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	if err := database.Connect(config.AppConfig.GetDSN()); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.CloseDB()
+
+	// Tabel kode sequence & periode harus sudah ada, dan pengajuan lama sudah tertaut ke periodenya
+	if err := database.Migrate(); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := services.NewPeriodeService().Backfill(); err != nil {
+		log.Fatal("Failed to backfill periode pengajuan:", err)
+	}
+
+	kodeService := services.NewKodePengajuanService()
+	fmt.Printf("Template: %s\n", kodeService.Template())
+
+	changes, err := kodeService.RegenerateMalformed(*apply)
+	for _, change := range changes {
+		if change.KodeBaru != "" {
+			fmt.Printf("[%d] %s -> %s (%s)\n", change.IDPengajuan, change.KodeLama, change.KodeBaru, change.Alasan)
+		} else {
+			fmt.Printf("[%d] %s (%s)\n", change.IDPengajuan, change.KodeLama, change.Alasan)
+		}
+	}
+	if err != nil {
+		log.Fatal("Failed to regenerate kode pengajuan:", err)
+	}
+
+	switch {
+	case len(changes) == 0:
+		fmt.Println("Tidak ada kode pengajuan format lama atau duplikat.")
+	case *apply:
+		fmt.Printf("%d kode pengajuan diperbarui. File proposal lama tidak di-rename.\n", len(changes))
+	default:
+		fmt.Printf("%d kode pengajuan akan diganti. Jalankan dengan -apply untuk menyimpan.\n", len(changes))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,12 @@ type Config struct {
 	AppEnv  string
 	AppPort string
 
+	// Header berisi IP asli client jika di belakang reverse proxy (mis. X-Forwarded-For)
+	ProxyHeader string
+	// IP/CIDR reverse proxy yang boleh mengisi ProxyHeader, dipisah koma.
+	// Kosong = ProxyHeader diabaikan (client bisa memalsukan header)
+	TrustedProxies string
+
 	// Database config
 	DBHost     string
 	DBPort     string
@@ -43,13 +50,56 @@ type Config struct {
 	DBSimpegPassword string
 	DBSimpegName     string
 
-	// JWT config
-	JWTSecret       string
-	JWTExpiredHours string
+	// JWT config (asymmetric, RS256 atau EdDSA sesuai tipe key)
+	JWTPrivateKeyPath       string // PEM private key untuk sign access token
+	JWTKeyID                string // kid untuk key signing aktif
+	JWTVerifyKeys           string // public key lama yang masih diterima, format "kid:path,kid:path"
+	JWTAccessExpiredMinutes string // umur access token (menit), sengaja pendek
+	JWTRefreshExpiredHours  string // umur refresh token (jam)
+
+	// Password policy (akun lokal db_user)
+	PasswordMinLength     string
+	PasswordRequireUpper  string // "true" / "false"
+	PasswordRequireLower  string
+	PasswordRequireDigit  string
+	PasswordRequireSymbol string
+
+	// Login throttling
+	LoginMaxAttempts          string // gagal per username sebelum lockout
+	LoginMaxAttemptsIP        string // gagal per IP sebelum lockout
+	LoginLockoutBaseSeconds   string // lama lockout pertama, berlipat dua tiap gagal berikutnya
+	LoginLockoutMaxMinutes    string // batas atas lama lockout
+	LoginAttemptWindowMinutes string // counter di-reset jika tidak ada kegagalan selama ini
+
+	// Impersonation (admin "view as user")
+	ImpersonationMaxMinutes string // umur maksimum token impersonation
+
+	// Two-factor authentication (TOTP) akun admin db_user
+	TwoFactorIssuer           string // nama yang tampil di aplikasi authenticator
+	TwoFactorRequiredLevels   string // id_user_level yang wajib 2FA, dipisah koma (mis. 1,2)
+	TwoFactorChallengeMinutes string // umur challenge token setelah password benar
+
+	// Undangan anggota tim
+	InvitationExpiryHours string // undangan yang tidak dijawab selama ini kedaluwarsa dan slotnya dibebaskan
+
+	// Batas keikutsertaan mahasiswa per periode pendaftaran (db_pengajuan_periode), 0 = tanpa batas
+	MaxKetuaPerPeriode   string // maksimal pengajuan sebagai ketua
+	MaxAnggotaPerPeriode string // maksimal pengajuan sebagai anggota (non-ketua)
+
+	// Deteksi kemiripan judul (skor cosine TF-IDF 0-1)
+	SimilarityThreshold  string // skor minimal agar judul lain dicatat sebagai mirip
+	SimilarityMaxMatches string // jumlah judul mirip teratas yang disimpan per pengajuan
+
+	// Format kode pengajuan default (bisa diganti admin), placeholder {KODE}, {TAHUN}, {SEQ:n}
+	KodePengajuanTemplate string
+
+	// Authentication providers
+	AuthProviders string // urutan provider, dipisah koma (local, campus_mahasiswa, campus_pegawai, stub)
+	AuthStubFile  string // file JSON akun untuk provider stub (development)
 
 	// External API config
-	APIBaseURL   string
-	APIToken string
+	APIBaseURL string
+	APIToken   string
 }
 
 // Global variable untuk config
@@ -69,6 +119,9 @@ func LoadConfig() error {
 		AppEnv:  getEnv("APP_ENV", "development"),
 		AppPort: getEnv("APP_PORT", "8080"),
 
+		ProxyHeader:    getEnv("PROXY_HEADER", ""),
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "3306"),
 		DBUser:     getEnv("DB_USER", "root"),
@@ -96,16 +149,63 @@ func LoadConfig() error {
 		DBSimpegPassword: getEnv("DB_SIMPEG_PASSWORD", ""),
 		DBSimpegName:     getEnv("DB_SIMPEG_NAME", "newsimpeg"),
 
-		JWTSecret:       getEnv("JWT_SECRET", ""),
-		JWTExpiredHours: getEnv("JWT_EXPIRED_HOURS", "24"),
+		JWTPrivateKeyPath:       getEnv("JWT_PRIVATE_KEY_PATH", ""),
+		JWTKeyID:                getEnv("JWT_KEY_ID", ""),
+		JWTVerifyKeys:           getEnv("JWT_VERIFY_KEYS", ""),
+		JWTAccessExpiredMinutes: getEnv("JWT_ACCESS_EXPIRED_MINUTES", "15"),
+		JWTRefreshExpiredHours:  getEnv("JWT_REFRESH_EXPIRED_HOURS", "168"),
+
+		PasswordMinLength:     getEnv("PASSWORD_MIN_LENGTH", "8"),
+		PasswordRequireUpper:  getEnv("PASSWORD_REQUIRE_UPPER", "true"),
+		PasswordRequireLower:  getEnv("PASSWORD_REQUIRE_LOWER", "true"),
+		PasswordRequireDigit:  getEnv("PASSWORD_REQUIRE_DIGIT", "true"),
+		PasswordRequireSymbol: getEnv("PASSWORD_REQUIRE_SYMBOL", "false"),
+
+		LoginMaxAttempts:          getEnv("LOGIN_MAX_ATTEMPTS", "5"),
+		LoginMaxAttemptsIP:        getEnv("LOGIN_MAX_ATTEMPTS_IP", "20"),
+		LoginLockoutBaseSeconds:   getEnv("LOGIN_LOCKOUT_BASE_SECONDS", "30"),
+		LoginLockoutMaxMinutes:    getEnv("LOGIN_LOCKOUT_MAX_MINUTES", "60"),
+		LoginAttemptWindowMinutes: getEnv("LOGIN_ATTEMPT_WINDOW_MINUTES", "30"),
+
+		ImpersonationMaxMinutes: getEnv("IMPERSONATION_MAX_MINUTES", "30"),
+
+		TwoFactorIssuer:           getEnv("TWO_FACTOR_ISSUER", "RIRES UMM"),
+		TwoFactorRequiredLevels:   getEnv("TWO_FACTOR_REQUIRED_LEVELS", ""),
+		TwoFactorChallengeMinutes: getEnv("TWO_FACTOR_CHALLENGE_MINUTES", "5"),
 
-		APIBaseURL:   getEnv("API_URL", ""),
-		APIToken: getEnv("API_TOKEN", ""),
+		InvitationExpiryHours: getEnv("INVITATION_EXPIRY_HOURS", "72"),
+
+		MaxKetuaPerPeriode:   getEnv("MAX_KETUA_PER_PERIODE", "1"),
+		MaxAnggotaPerPeriode: getEnv("MAX_ANGGOTA_PER_PERIODE", "2"),
+
+		SimilarityThreshold:  getEnv("SIMILARITY_THRESHOLD", "0.5"),
+		SimilarityMaxMatches: getEnv("SIMILARITY_MAX_MATCHES", "5"),
+
+		KodePengajuanTemplate: getEnv("KODE_PENGAJUAN_TEMPLATE", "PKM-{KODE}-{TAHUN}-{SEQ:3}"),
+
+		AuthProviders: getEnv("AUTH_PROVIDERS", "local,campus_mahasiswa,campus_pegawai"),
+		AuthStubFile:  getEnv("AUTH_STUB_FILE", "./auth_stub.json"),
+
+		APIBaseURL: getEnv("API_URL", ""),
+		APIToken:   getEnv("API_TOKEN", ""),
 	}
 
 	return nil
 }
 
+// ValidateJWT memastikan signing key diisi. Hanya dipanggil server API;
+// tool maintenance (mis. cmd/regenerate-kode) tidak menerbitkan token.
+func (c *Config) ValidateJWT() error {
+	// Token tidak boleh diterbitkan tanpa signing key
+	if c.JWTPrivateKeyPath == "" {
+		return fmt.Errorf("JWT_PRIVATE_KEY_PATH is required")
+	}
+	if c.JWTKeyID == "" {
+		return fmt.Errorf("JWT_KEY_ID is required")
+	}
+	return nil
+}
+
 // getEnv membaca environment variable dengan fallback ke default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -115,6 +215,17 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// GetTrustedProxies mengembalikan daftar IP/CIDR dari TRUSTED_PROXIES
+func (c *Config) GetTrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 // GetDSN mengembalikan Data Source Name untuk MySQL connection
 func (c *Config) GetDSN() string {
 	// Format: username:password@tcp(host:port)/dbname?params
@@ -158,4 +269,4 @@ func (c *Config) GetDSNSimpeg() string {
 		c.DBSimpegPort,
 		c.DBSimpegName,
 	)
-}
\ No newline at end of file
+}
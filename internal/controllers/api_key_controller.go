@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"strconv"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyController handles API key management endpoints
+type APIKeyController struct {
+	service   *services.APIKeyService
+	validator *validator.Validate
+}
+
+// NewAPIKeyController creates a new controller instance
+func NewAPIKeyController() *APIKeyController {
+	return &APIKeyController{
+		service:   services.NewAPIKeyService(),
+		validator: validator.New(),
+	}
+}
+
+// GetAll godoc
+// @Summary Get API Keys
+// @Description Admin gets all API keys for machine-to-machine integrations
+// @Tags Admin - API Key
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} response.APIResponse{data=[]response.APIKeyResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/api-keys [get]
+func (ctrl *APIKeyController) GetAll(c *fiber.Ctx) error {
+	result, err := ctrl.service.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get API keys",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"API keys retrieved successfully",
+		result,
+	))
+}
+
+// GetByID godoc
+// @Summary Get API Key Detail
+// @Description Admin gets detail of an API key
+// @Tags Admin - API Key
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "API Key ID"
+// @Success 200 {object} response.APIResponse{data=response.APIKeyResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/api-keys/{id} [get]
+func (ctrl *APIKeyController) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid ID",
+			err.Error(),
+		))
+	}
+
+	result, err := ctrl.service.GetByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(response.ErrorResponse(
+			"API key not found",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"API key detail",
+		result,
+	))
+}
+
+// Create godoc
+// @Summary Create API Key
+// @Description Admin creates an API key with scopes (pengajuan:read, reference:read, statistics:read). The raw key is only shown once.
+// @Tags Admin - API Key
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body request.CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} response.APIResponse{data=response.APIKeyCreatedResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (ctrl *APIKeyController) Create(c *fiber.Ctx) error {
+	// 1. Parse request body
+	var req request.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+
+	// 2. Validate request
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	// 3. Call service
+	result, err := ctrl.service.Create(&req, int(utils.GetCurrentUserID(c)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to create API key",
+			err.Error(),
+		))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse(
+		"API key created. Store the key now, it will not be shown again",
+		result,
+	))
+}
+
+// Update godoc
+// @Summary Update API Key
+// @Description Admin updates name, scopes, expiry or status of an API key
+// @Tags Admin - API Key
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "API Key ID"
+// @Param body body request.UpdateAPIKeyRequest true "API key data"
+// @Success 200 {object} response.APIResponse{data=response.APIKeyResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/api-keys/{id} [put]
+func (ctrl *APIKeyController) Update(c *fiber.Ctx) error {
+	// 1. Parse ID
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Parse & validate request body
+	var req request.UpdateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	// 3. Call service
+	result, err := ctrl.service.Update(id, &req, int(utils.GetCurrentUserID(c)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to update API key",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"API key updated successfully",
+		result,
+	))
+}
+
+// Delete godoc
+// @Summary Delete API Key
+// @Description Admin revokes an API key
+// @Tags Admin - API Key
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "API Key ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/api-keys/{id} [delete]
+func (ctrl *APIKeyController) Delete(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid ID",
+			err.Error(),
+		))
+	}
+
+	if err := ctrl.service.Delete(id, int(utils.GetCurrentUserID(c))); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(response.ErrorResponse(
+			"Failed to delete API key",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"API key deleted successfully",
+		nil,
+	))
+}
@@ -1,8 +1,7 @@
 package controllers
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
+	"errors"
 	"log"
 	"rires-be/internal/dto/request"
 	"rires-be/internal/dto/response"
@@ -16,13 +15,35 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// localTwoFactorPending menandai response login yang berisi challenge 2FA (belum ada token)
+const localTwoFactorPending = "two_factor_pending"
+
 type AuthController struct {
-	apiService *services.APIService
+	authChain        *services.AuthProviderChain
+	tokenService     *services.TokenService
+	throttleService  *services.LoginThrottleService
+	reviewerIdentity *services.ReviewerIdentityService
+	roleService      *services.RoleService
+	twoFactor        *services.TwoFactorService
+	pembimbing       *services.PembimbingService
 }
 
 func NewAuthController() *AuthController {
+	// Provider autentikasi dibaca dari AUTH_PROVIDERS, salah konfigurasi menggagalkan startup
+	authChain, err := services.NewAuthProviderChain()
+	if err != nil {
+		log.Fatal("Failed to initialize auth providers:", err)
+	}
+	log.Printf("Auth providers: %s", strings.Join(authChain.Providers(), " -> "))
+
 	return &AuthController{
-		apiService: services.NewAPIService(),
+		authChain:        authChain,
+		tokenService:     services.NewTokenService(),
+		throttleService:  services.NewLoginThrottleService(),
+		reviewerIdentity: services.NewReviewerIdentityService(),
+		roleService:      services.NewRoleService(),
+		twoFactor:        services.NewTwoFactorService(),
+		pembimbing:       services.NewPembimbingService(),
 	}
 }
 
@@ -35,6 +56,7 @@ func NewAuthController() *AuthController {
 // @Param credentials body request.LoginRequest true "Login Credentials"
 // @Success 200 {object} response.LoginResponse
 // @Failure 401 {object} object{success=bool,message=string}
+// @Failure 429 {object} object{success=bool,message=string}
 // @Router /auth/login [post]
 func (ctrl *AuthController) Login(c *fiber.Ctx) error {
 	var req request.LoginRequest
@@ -49,50 +71,54 @@ func (ctrl *AuthController) Login(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Username and password are required")
 	}
 
-	// 1. Try Admin (Local DB)
-	var user models.User
-	result := database.DB.Where("username = ? AND hapus = 0", req.Username).First(&user)
-	if result.Error == nil {
-		return ctrl.processAdminLogin(c, user, req.Password)
+	// Tolak lebih awal jika username/IP sedang terkunci, sebelum memanggil API kampus
+	if err := ctrl.throttleService.Check(req.Username, c.IP()); err != nil {
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(locked.RetryAfter()))
+			return utils.ErrorResponse(c, fiber.StatusTooManyRequests, locked.Error())
+		}
+		log.Printf("[Login] Failed to check login throttle: %v", err)
 	}
 
-	// 2. Try Mahasiswa or Pegawai via external API
-	// If it's an email format, try Pegawai first
-	if strings.Contains(req.Username, "@") {
-		log.Printf("[Login] Attempting Pegawai login for email: %s", req.Username)
-		// Call external API for pegawai
-		pegawai, err := ctrl.apiService.PegawaiLogin(req.Username, req.Password)
-		if err != nil {
-			log.Printf("[Login] Pegawai login failed: %v", err)
-			return utils.UnauthorizedResponse(c, err.Error())
+	err := ctrl.attemptLogin(c, &req)
+
+	// Catat hasil percobaan berdasarkan status response.
+	// Challenge 2FA juga 200, tetapi counter baru di-reset setelah token benar-benar terbit (VerifyTwoFactor).
+	switch c.Response().StatusCode() {
+	case fiber.StatusOK:
+		if pending, _ := c.Locals(localTwoFactorPending).(bool); pending {
+			break
+		}
+		if e := ctrl.throttleService.RegisterSuccess(req.Username); e != nil {
+			log.Printf("[Login] Failed to reset login counter: %v", e)
+		}
+	case fiber.StatusUnauthorized:
+		if e := ctrl.throttleService.RegisterFailure(req.Username, c.IP()); e != nil {
+			log.Printf("[Login] Failed to register failed login: %v", e)
 		}
-		return ctrl.processPegawaiLoginSuccess(c, pegawai, req.Username)
 	}
 
-	// Try Mahasiswa login (most common case for numeric usernames)
-	log.Printf("[Login] Attempting Mahasiswa login for NIM: %s", req.Username)
-	mahasiswa, err := ctrl.apiService.MahasiswaLogin(req.Username, req.Password)
-	if err == nil {
-		log.Printf("[Login] Mahasiswa login success for NIM: %s", req.Username)
-		return ctrl.processMahasiswaLoginSuccess(c, mahasiswa)
-	}
-	log.Printf("[Login] Mahasiswa login failed for NIM %s: %v", req.Username, err)
+	return err
+}
 
-	// If Mahasiswa fails, try Pegawai (in case NIP is used instead of email)
-	log.Printf("[Login] Attempting Pegawai login (as backup) for NIP: %s", req.Username)
-	pegawai, errPegawai := ctrl.apiService.PegawaiLogin(req.Username, req.Password)
-	if errPegawai == nil {
-		log.Printf("[Login] Pegawai login success (backup) for NIP: %s", req.Username)
-		return ctrl.processPegawaiLoginSuccess(c, pegawai, req.Username)
+// attemptLogin mencoba provider autentikasi sesuai urutan AUTH_PROVIDERS
+func (ctrl *AuthController) attemptLogin(c *fiber.Ctx, req *request.LoginRequest) error {
+	identity, err := ctrl.authChain.Authenticate(req.Username, req.Password)
+	if err != nil {
+		log.Printf("[Login] Login failed for %s: %v", req.Username, err)
+		if errors.Is(err, services.ErrAccountInactive) {
+			return utils.UnauthorizedResponse(c, "User account is inactive")
+		}
+		return utils.UnauthorizedResponse(c, "Invalid username or password")
 	}
-	log.Printf("[Login] Pegawai login backup failed for NIP %s: %v", req.Username, errPegawai)
 
-	// If all fail, return error
-	return utils.UnauthorizedResponse(c, "Invalid username or password")
+	log.Printf("[Login] Login success for %s via %s provider", req.Username, identity.Provider)
+	return ctrl.completeLogin(c, identity, req.Username)
 }
 
-// LoginAdmin handles admin login
-func (ctrl *AuthController) LoginAdmin(c *fiber.Ctx) error {
+// loginWithProvider login hanya melalui satu provider tertentu
+func (ctrl *AuthController) loginWithProvider(c *fiber.Ctx, providerName string) error {
 	var req request.LoginRequest
 
 	// Parse request body
@@ -105,107 +131,224 @@ func (ctrl *AuthController) LoginAdmin(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Username and password are required")
 	}
 
-	// Find user in database
-	var user models.User
-	result := database.DB.Where("username = ? AND hapus = 0", req.Username).First(&user)
-	if result.Error != nil {
+	provider, err := services.NewAuthProvider(providerName)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Authentication provider is not available")
+	}
+
+	identity, err := provider.Authenticate(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountInactive) {
+			return utils.UnauthorizedResponse(c, "User account is inactive")
+		}
 		return utils.UnauthorizedResponse(c, "Invalid username or password")
 	}
+	identity.Provider = provider.Name()
+
+	return ctrl.completeLogin(c, identity, req.Username)
+}
 
-	return ctrl.processAdminLogin(c, user, req.Password)
+// LoginAdmin handles admin login
+func (ctrl *AuthController) LoginAdmin(c *fiber.Ctx) error {
+	return ctrl.loginWithProvider(c, "local")
+}
+
+// LoginMahasiswa handles mahasiswa login
+func (ctrl *AuthController) LoginMahasiswa(c *fiber.Ctx) error {
+	return ctrl.loginWithProvider(c, "campus_mahasiswa")
 }
 
-// Helper for Admin Login logic
-func (ctrl *AuthController) processAdminLogin(c *fiber.Ctx, user models.User, password string) error {
-	// Check if user is active
-	if user.Status != 1 {
-		return utils.UnauthorizedResponse(c, "User account is inactive")
+// LoginPegawai handles pegawai login
+func (ctrl *AuthController) LoginPegawai(c *fiber.Ctx) error {
+	return ctrl.loginWithProvider(c, "campus_pegawai")
+}
+
+// completeLogin menerbitkan token sesuai tipe identitas hasil autentikasi
+func (ctrl *AuthController) completeLogin(c *fiber.Ctx, identity *services.AuthIdentity, loginUsername string) error {
+	switch identity.UserType {
+	case services.UserTypeAdmin:
+		return ctrl.processAdminLogin(c, identity)
+	case services.UserTypeMahasiswa:
+		return ctrl.processMahasiswaLoginSuccess(c, identity)
+	case services.UserTypePegawai:
+		return ctrl.processPegawaiLoginSuccess(c, identity, loginUsername)
+	default:
+		return utils.UnauthorizedResponse(c, "Unknown user type")
 	}
+}
 
-	// Verify password
-	if len(user.Password) > 0 && user.Password[0] == '*' {
-		hashedInput := hashMySQLPassword(password)
-		if user.Password != hashedInput {
-			return utils.UnauthorizedResponse(c, "Invalid username or password")
-		}
-	} else if len(user.Password) >= 4 && (user.Password[0:4] == "$2a$" || user.Password[0:4] == "$2y$") {
-		if err := utils.VerifyPassword(user.Password, password); err != nil {
-			return utils.UnauthorizedResponse(c, "Invalid username or password")
-		}
-	} else {
-		if user.Password != password {
-			return utils.UnauthorizedResponse(c, "Invalid username or password")
+// Helper for Admin Login success
+func (ctrl *AuthController) processAdminLogin(c *fiber.Ctx, identity *services.AuthIdentity) error {
+	// Akun dengan 2FA (atau level yang wajib 2FA) menerima challenge token, bukan access token
+	needed, setupRequired, err := ctrl.twoFactor.NeedsChallenge(identity)
+	if err != nil {
+		log.Printf("[Login] Failed to check 2FA for %s: %v", identity.Username, err)
+		return utils.InternalServerErrorResponse(c, "Failed to check two-factor authentication")
+	}
+	if needed {
+		challenge, err := ctrl.twoFactor.CreateChallenge(identity, setupRequired, c.IP())
+		if err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to create two-factor challenge")
 		}
+		c.Locals(localTwoFactorPending, true)
+		return utils.SuccessResponse(c, "Two-factor authentication required", challenge)
 	}
 
+	return ctrl.issueAdminLogin(c, identity, nil)
+}
+
+// issueAdminLogin menerbitkan token admin (setelah password, dan 2FA jika ada, lolos)
+func (ctrl *AuthController) issueAdminLogin(c *fiber.Ctx, identity *services.AuthIdentity, recoveryCodes []string) error {
 	// Generate JWT token
-	token, err := utils.GenerateTokenWithClaims(
-		uint(user.ID),
-		user.Username,
-		"",
+	claims := utils.NewClaims(
+		identity.UserID,
+		identity.Username,
+		identity.Email,
 		"admin",
-		user.LevelUser,
+		identity.IDUserLevel,
 		map[string]string{
-			"nama_user":  user.NamaUser,
-			"level_user": strconv.Itoa(user.LevelUser),
+			"nama_user":  identity.Nama,
+			"level_user": strconv.Itoa(identity.IDUserLevel),
 		},
 	)
+
+	user := response.AdminLoginResponse{
+		ID:        int(identity.UserID),
+		NamaUser:  identity.Nama,
+		Username:  identity.Username,
+		LevelUser: identity.IDUserLevel,
+		Status:    1,
+	}
+	if len(recoveryCodes) == 0 {
+		return ctrl.sendLoginResponse(c, claims, user)
+	}
+
+	// Enrollment 2FA saat login: kode pemulihan hanya ditampilkan sekali di sini
+	loginResponse, err := ctrl.issueLoginResponse(c, claims, user)
 	if err != nil {
 		return utils.InternalServerErrorResponse(c, "Failed to generate token")
 	}
+	loginResponse.RecoveryCodes = recoveryCodes
 
-	expiredHours, _ := strconv.Atoi("24")
+	return utils.SuccessResponse(c, "Login successful", loginResponse)
+}
 
-	loginResponse := response.LoginResponse{
-		Token:     token,
-		UserType:  "admin",
-		ExpiresIn: expiredHours,
-		User: response.AdminLoginResponse{
-			ID:        user.ID,
-			NamaUser:  user.NamaUser,
-			Username:  user.Username,
-			LevelUser: user.LevelUser,
-			Status:    user.Status,
-		},
+// VerifyTwoFactor godoc
+// @Summary Verify Two-Factor Login
+// @Description Selesaikan login admin dengan kode TOTP (atau kode pemulihan) dan challenge token dari /auth/login
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param body body request.TwoFactorVerifyRequest true "Challenge token and code"
+// @Success 200 {object} object{success=bool,message=string,data=response.LoginResponse}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 401 {object} object{success=bool,message=string}
+// @Router /auth/2fa/verify [post]
+func (ctrl *AuthController) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req request.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request body")
 	}
 
-	return utils.SuccessResponse(c, "Login successful", loginResponse)
-}
+	// Validate required fields
+	if req.ChallengeToken == "" {
+		return utils.BadRequestResponse(c, "Challenge token is required")
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return utils.BadRequestResponse(c, "Code or recovery code is required")
+	}
 
-// LoginMahasiswa handles mahasiswa login
-func (ctrl *AuthController) LoginMahasiswa(c *fiber.Ctx) error {
-	var req request.LoginRequest
+	// Throttle memakai username pemilik challenge, sama dengan /auth/login, agar kode TOTP
+	// tidak bisa ditebak terus-menerus dengan meminta challenge baru
+	username, _ := ctrl.twoFactor.ChallengeUsername(req.ChallengeToken)
+	if err := ctrl.throttleService.Check(username, c.IP()); err != nil {
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(locked.RetryAfter()))
+			return utils.ErrorResponse(c, fiber.StatusTooManyRequests, locked.Error())
+		}
+		log.Printf("[Login] Failed to check login throttle: %v", err)
+	}
 
-	// Parse request body
+	identity, recoveryCodes, err := ctrl.twoFactor.VerifyChallenge(req.ChallengeToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorInvalidCode) {
+			if e := ctrl.throttleService.RegisterFailure(username, c.IP()); e != nil {
+				log.Printf("[Login] Failed to register failed 2FA verification: %v", e)
+			}
+		}
+		switch {
+		case errors.Is(err, services.ErrTwoFactorChallengeInvalid),
+			errors.Is(err, services.ErrTwoFactorInvalidCode),
+			errors.Is(err, services.ErrTwoFactorNotEnabled),
+			errors.Is(err, services.ErrAccountInactive):
+			return utils.UnauthorizedResponse(c, err.Error())
+		}
+		log.Printf("[Login] Failed to verify 2FA challenge: %v", err)
+		return utils.InternalServerErrorResponse(c, "Failed to verify two-factor authentication")
+	}
+
+	log.Printf("[Login] 2FA verified for %s", identity.Username)
+	err = ctrl.issueAdminLogin(c, identity, recoveryCodes)
+	if c.Response().StatusCode() == fiber.StatusOK {
+		if e := ctrl.throttleService.RegisterSuccess(username); e != nil {
+			log.Printf("[Login] Failed to reset login counter: %v", e)
+		}
+	}
+	return err
+}
+
+// EnrollTwoFactor godoc
+// @Summary Enroll Two-Factor During Login
+// @Description Untuk level yang wajib 2FA tetapi belum enrollment (setup_required = true): ambil secret TOTP dengan challenge token, lalu kirim kode pertama ke /auth/2fa/verify
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param body body request.TwoFactorEnrollRequest true "Challenge token"
+// @Success 200 {object} object{success=bool,message=string,data=response.TwoFactorSetupResponse}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 401 {object} object{success=bool,message=string}
+// @Router /auth/2fa/enroll [post]
+func (ctrl *AuthController) EnrollTwoFactor(c *fiber.Ctx) error {
+	var req request.TwoFactorEnrollRequest
 	if err := c.BodyParser(&req); err != nil {
 		return utils.BadRequestResponse(c, "Invalid request body")
 	}
 
-	// Validate required fields
-	if req.Username == "" || req.Password == "" {
-		return utils.BadRequestResponse(c, "Username and password are required")
+	if req.ChallengeToken == "" {
+		return utils.BadRequestResponse(c, "Challenge token is required")
 	}
 
-	// Call external API
-	mahasiswa, err := ctrl.apiService.MahasiswaLogin(req.Username, req.Password)
+	setup, err := ctrl.twoFactor.EnrollFromChallenge(req.ChallengeToken)
 	if err != nil {
-		return utils.UnauthorizedResponse(c, err.Error())
+		if errors.Is(err, services.ErrTwoFactorChallengeInvalid) {
+			return utils.UnauthorizedResponse(c, err.Error())
+		}
+		return utils.BadRequestResponse(c, err.Error())
 	}
 
-	return ctrl.processMahasiswaLoginSuccess(c, mahasiswa)
+	return utils.SuccessResponse(c, "Scan the provisioning URI, then verify with the first code", setup)
 }
 
 // Helper for Mahasiswa Login success
-func (ctrl *AuthController) processMahasiswaLoginSuccess(c *fiber.Ctx, mahasiswa *response.MahasiswaLoginResponse) error {
+func (ctrl *AuthController) processMahasiswaLoginSuccess(c *fiber.Ctx, identity *services.AuthIdentity) error {
 	// Find user_id from db_user by NIM (mahasiswa might have local account)
 	var user models.User
 	var userID uint = 0
-	if err := database.DB.Where("nim = ? AND hapus = ?", mahasiswa.NIM, 0).First(&user).Error; err == nil {
+	if err := database.DB.Where("nim = ? AND hapus = ?", identity.NIM, 0).First(&user).Error; err == nil {
 		userID = uint(user.ID)
 	}
 
+	mahasiswa := &response.MahasiswaLoginResponse{
+		NIM:      identity.NIM,
+		Nama:     identity.Nama,
+		Prodi:    identity.Unit,
+		Fakultas: identity.Fakultas,
+		Email:    identity.Email,
+	}
+
 	// Generate JWT token
-	token, err := utils.GenerateTokenWithClaims(
+	claims := utils.NewClaims(
 		userID,
 		mahasiswa.NIM,
 		"",
@@ -217,128 +360,291 @@ func (ctrl *AuthController) processMahasiswaLoginSuccess(c *fiber.Ctx, mahasiswa
 			"fakultas": mahasiswa.Fakultas,
 		},
 	)
+
+	return ctrl.sendLoginResponse(c, claims, mahasiswa)
+}
+
+// Helper for Pegawai Login success
+func (ctrl *AuthController) processPegawaiLoginSuccess(c *fiber.Ctx, identity *services.AuthIdentity, originalUsername string) error {
+	// Resolve reviewer lewat id_pegawai (email SSO -> SIMPEG -> db_reviewer)
+	reviewer, err := ctrl.reviewerIdentity.ResolveFromLogin(identity, originalUsername)
 	if err != nil {
-		return utils.InternalServerErrorResponse(c, "Failed to generate token")
+		if errors.Is(err, services.ErrNotReviewer) {
+			return ctrl.processPembimbingLogin(c, identity, originalUsername)
+		}
+		log.Printf("[Login] Failed to resolve reviewer for %s: %v", originalUsername, err)
+		return utils.InternalServerErrorResponse(c, "Failed to resolve reviewer")
+	}
+
+	// Generate JWT token
+	claims, user := services.NewReviewerClaims(reviewer, identity.NIP, identity.Jabatan, identity.Unit)
+
+	return ctrl.sendLoginResponse(c, claims, user)
+}
+
+// processPembimbingLogin menerbitkan token untuk dosen pembimbing yang bukan reviewer
+func (ctrl *AuthController) processPembimbingLogin(c *fiber.Ctx, identity *services.AuthIdentity, originalUsername string) error {
+	pegawai, err := ctrl.pembimbing.ResolveFromLogin(identity, originalUsername)
+	if err != nil {
+		if errors.Is(err, services.ErrNotReviewerOrPembimbing) {
+			return utils.UnauthorizedResponse(c, "Anda bukan reviewer aktif maupun dosen pembimbing. Pastikan email Anda sudah terdaftar sebagai reviewer.")
+		}
+		log.Printf("[Login] Failed to resolve pembimbing for %s: %v", originalUsername, err)
+		return utils.InternalServerErrorResponse(c, "Failed to resolve pembimbing")
 	}
 
-	expiredHours, _ := strconv.Atoi("24")
+	claims, user := services.NewPembimbingClaims(pegawai, identity.NIP, identity.Jabatan, identity.Unit)
 
-	loginResponse := response.LoginResponse{
-		Token:     token,
-		UserType:  "mahasiswa",
-		ExpiresIn: expiredHours,
-		User:      mahasiswa,
+	return ctrl.sendLoginResponse(c, claims, user)
+}
+
+// sendLoginResponse menerbitkan access token + refresh token dan mengirim response login
+func (ctrl *AuthController) sendLoginResponse(c *fiber.Ctx, claims *utils.JWTClaims, user interface{}) error {
+	loginResponse, err := ctrl.issueLoginResponse(c, claims, user)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to generate token")
 	}
 
 	return utils.SuccessResponse(c, "Login successful", loginResponse)
 }
 
-// LoginPegawai handles pegawai login
-func (ctrl *AuthController) LoginPegawai(c *fiber.Ctx) error {
-	var req request.LoginRequest
+// issueLoginResponse menerbitkan token pair dan menyusun response login beserta daftar role
+func (ctrl *AuthController) issueLoginResponse(c *fiber.Ctx, claims *utils.JWTClaims, user interface{}) (*response.LoginResponse, error) {
+	pair, err := ctrl.tokenService.IssueTokenPair(claims, c.IP(), c.Get("User-Agent"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Role lain milik identitas yang sama (gagal dibaca tidak menggagalkan login)
+	roles, err := ctrl.roleService.AvailableRoles(claims)
+	if err != nil {
+		log.Printf("[Login] Failed to load available roles for %s: %v", claims.Subject, err)
+		roles = []response.RoleOption{}
+	}
+
+	return &response.LoginResponse{
+		Token:            pair.AccessToken,
+		RefreshToken:     pair.RefreshToken,
+		UserType:         claims.UserType,
+		ExpiresIn:        int(utils.AccessTokenTTL().Seconds()),
+		RefreshExpiresIn: int(utils.RefreshTokenTTL().Seconds()),
+		User:             user,
+		ActiveRole:       claims.ActiveRole,
+		Roles:            roles,
+	}, nil
+}
+
+// SwitchRole godoc
+// @Summary Switch Active Role
+// @Description Terbitkan token baru untuk role lain milik identitas yang sama (mis. reviewer -> admin fakultas). Token role lama dicabut.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param body body request.SwitchRoleRequest true "Target role"
+// @Success 200 {object} object{success=bool,message=string,data=response.LoginResponse}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 401 {object} object{success=bool,message=string}
+// @Failure 403 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /auth/switch-role [post]
+func (ctrl *AuthController) SwitchRole(c *fiber.Ctx) error {
+	var req request.SwitchRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request body")
+	}
+
+	// Validate role
+	if req.Role != services.UserTypeAdmin && req.Role != services.UserTypePegawai && req.Role != services.UserTypeMahasiswa {
+		return utils.BadRequestResponse(c, "Role must be admin, pegawai or mahasiswa")
+	}
+
+	claims := utils.GetCurrentClaims(c)
+	if claims == nil {
+		return utils.UnauthorizedResponse(c, "Invalid or expired token")
+	}
+
+	// Susun claims untuk role tujuan
+	newClaims, user, err := ctrl.roleService.SwitchRole(claims, req.Role)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, err.Error())
+	}
+
+	// Pindah ke admin tetap melewati 2FA akun admin tersebut
+	if newClaims.UserType == services.UserTypeAdmin {
+		needed, setupRequired, err := ctrl.twoFactor.NeedsChallenge(&services.AuthIdentity{
+			UserType:    services.UserTypeAdmin,
+			UserID:      newClaims.UserID,
+			IDUserLevel: newClaims.IDUserLevel,
+		})
+		if err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to check two-factor authentication")
+		}
+		if setupRequired {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Two-factor authentication is required for this admin account. Log in as admin to set it up first")
+		}
+		if needed {
+			if req.Code == "" {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, "Two-factor code is required to switch to admin")
+			}
+			if err := ctrl.twoFactor.VerifyCode(int(newClaims.UserID), req.Code); err != nil {
+				return utils.UnauthorizedResponse(c, err.Error())
+			}
+		}
+	}
+
+	loginResponse, err := ctrl.issueLoginResponse(c, newClaims, user)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to generate token")
+	}
+
+	// Cabut token role lama
+	if err := ctrl.tokenService.Logout(claims, req.RefreshToken, false); err != nil {
+		log.Printf("[SwitchRole] Failed to revoke previous role tokens for %s: %v", claims.Subject, err)
+	}
+
+	return utils.SuccessResponse(c, "Role switched successfully", loginResponse)
+}
+
+// Refresh godoc
+// @Summary Refresh Access Token
+// @Description Tukar refresh token dengan access token baru. Refresh token lama langsung tidak berlaku (rotasi).
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param body body request.RefreshTokenRequest true "Refresh Token"
+// @Success 200 {object} object{success=bool,message=string,data=response.TokenResponse}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 401 {object} object{success=bool,message=string}
+// @Router /auth/refresh [post]
+func (ctrl *AuthController) Refresh(c *fiber.Ctx) error {
+	var req request.RefreshTokenRequest
 
 	// Parse request body
 	if err := c.BodyParser(&req); err != nil {
 		return utils.BadRequestResponse(c, "Invalid request body")
 	}
 
-	// Validate required fields
-	if req.Username == "" || req.Password == "" {
-		return utils.BadRequestResponse(c, "Username and password are required")
+	if req.RefreshToken == "" {
+		return utils.BadRequestResponse(c, "Refresh token is required")
 	}
 
-	// Call external API
-	pegawai, err := ctrl.apiService.PegawaiLogin(req.Username, req.Password)
+	pair, claims, err := ctrl.tokenService.Refresh(req.RefreshToken, c.IP(), c.Get("User-Agent"))
 	if err != nil {
 		return utils.UnauthorizedResponse(c, err.Error())
 	}
 
-	return ctrl.processPegawaiLoginSuccess(c, pegawai, req.Username)
+	return utils.SuccessResponse(c, "Token refreshed successfully", response.TokenResponse{
+		Token:            pair.AccessToken,
+		RefreshToken:     pair.RefreshToken,
+		UserType:         claims.UserType,
+		ExpiresIn:        int(utils.AccessTokenTTL().Seconds()),
+		RefreshExpiresIn: int(utils.RefreshTokenTTL().Seconds()),
+	})
 }
 
-// Helper for Pegawai Login success
-func (ctrl *AuthController) processPegawaiLoginSuccess(c *fiber.Ctx, pegawai *response.PegawaiLoginResponse, originalUsername string) error {
-	// Check if pegawai is an active reviewer in db_reviewer
-	var reviewer models.Reviewer
-	found := false
-
-	// Strategy 1: lookup by email from API response
-	if pegawai.Email != "" {
-		if err := database.DB.Where("email_umm = ? AND is_active = ? AND hapus = ?", pegawai.Email, 1, 0).First(&reviewer).Error; err == nil {
-			found = true
+// Logout godoc
+// @Summary Logout
+// @Description Cabut access token saat ini dan refresh token yang dikirim. all_devices=true mencabut semua sesi akun.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param body body request.LogoutRequest false "Logout Options"
+// @Success 200 {object} object{success=bool,message=string}
+// @Failure 401 {object} object{success=bool,message=string}
+// @Failure 500 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /auth/logout [post]
+func (ctrl *AuthController) Logout(c *fiber.Ctx) error {
+	var req request.LogoutRequest
+
+	// Body opsional
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.BadRequestResponse(c, "Invalid request body")
 		}
 	}
 
-	// Strategy 2: lookup by username (email input) if not found
-	if !found && originalUsername != "" {
-		if err := database.DB.Where("email_umm = ? AND is_active = ? AND hapus = ?", originalUsername, 1, 0).First(&reviewer).Error; err == nil {
-			found = true
-		}
+	claims := utils.GetCurrentClaims(c)
+	if claims == nil {
+		return utils.UnauthorizedResponse(c, "Invalid or expired token")
 	}
 
-	// Strategy 3: lookup by nama_reviewer if not found
-	if !found && pegawai.Nama != "" {
-		if err := database.DB.Where("nama_reviewer = ? AND is_active = ? AND hapus = ?", pegawai.Nama, 1, 0).First(&reviewer).Error; err == nil {
-			found = true
+	// Token impersonation: cukup akhiri sesi impersonation, jangan sentuh sesi milik user target
+	if claims.Impersonator != nil {
+		if err := services.NewImpersonationService().Stop(claims); err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to logout")
 		}
+		return utils.SuccessResponse(c, "Logout successful", nil)
 	}
 
-	if !found {
-		return utils.UnauthorizedResponse(c, "Anda bukan reviewer aktif. Pastikan email Anda sudah terdaftar sebagai reviewer.")
+	if err := ctrl.tokenService.Logout(claims, req.RefreshToken, req.AllDevices); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to logout")
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateTokenWithClaims(
-		uint(reviewer.ID),
-		pegawai.NIP,
-		reviewer.EmailUmm,
-		"pegawai",
-		4, // Reviewer level
-		map[string]string{
-			"nama":        reviewer.NamaReviewer,
-			"jabatan":     pegawai.Jabatan,
-			"unit":        pegawai.Unit,
-			"id_pegawai":  strconv.Itoa(reviewer.IDPegawai),
-			"id_reviewer": strconv.Itoa(reviewer.ID),
-		},
-	)
-	if err != nil {
-		return utils.InternalServerErrorResponse(c, "Failed to generate token")
+	return utils.SuccessResponse(c, "Logout successful", nil)
+}
+
+// ChangePassword godoc
+// @Summary Change Password
+// @Description Ubah password akun lokal (admin) yang sedang login. Password mahasiswa dan pegawai dikelola oleh SSO kampus.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param body body request.ChangePasswordRequest true "Old and New Password"
+// @Success 200 {object} object{success=bool,message=string}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 401 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /auth/change-password [post]
+func (ctrl *AuthController) ChangePassword(c *fiber.Ctx) error {
+	var req request.ChangePasswordRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request body")
 	}
 
-	expiredHours, _ := strconv.Atoi("24")
-
-	loginResponse := response.LoginResponse{
-		Token:     token,
-		UserType:  "pegawai",
-		ExpiresIn: expiredHours,
-		User: fiber.Map{
-			"id_reviewer": reviewer.ID,
-			"id_pegawai":  reviewer.IDPegawai,
-			"nip":         pegawai.NIP,
-			"nama":        reviewer.NamaReviewer,
-			"email":       reviewer.EmailUmm,
-			"jabatan":     pegawai.Jabatan,
-			"unit":        pegawai.Unit,
-		},
+	if req.OldPassword == "" || req.NewPassword == "" {
+		return utils.BadRequestResponse(c, "Old password and new password are required")
 	}
 
-	return utils.SuccessResponse(c, "Login successful", loginResponse)
-}
+	if !utils.IsAdmin(c) {
+		return utils.BadRequestResponse(c, "Password for this account is managed by the campus SSO")
+	}
 
-// hashMySQLPassword creates MySQL PASSWORD() compatible hash
-// MySQL PASSWORD() uses double SHA1: *UPPERCASE_HEX(SHA1(SHA1(password)))
-func hashMySQLPassword(password string) string {
-	// First SHA1
-	firstHash := sha1.Sum([]byte(password))
+	// Find current user
+	var user models.User
+	if err := database.DB.Where("id = ? AND hapus = ?", utils.GetCurrentUserID(c), 0).First(&user).Error; err != nil {
+		return utils.NotFoundResponse(c, "User not found")
+	}
+
+	// Verify old password
+	if ok, _ := utils.CheckStoredPassword(user.Password, req.OldPassword); !ok {
+		return utils.UnauthorizedResponse(c, "Old password is incorrect")
+	}
+
+	if req.OldPassword == req.NewPassword {
+		return utils.BadRequestResponse(c, "New password must be different from the old password")
+	}
+
+	// Validate password policy
+	if err := utils.ValidatePasswordPolicy(req.NewPassword); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to hash password")
+	}
 
-	// Second SHA1
-	secondHash := sha1.Sum(firstHash[:])
+	user.Password = hashedPassword
+	user.UserUpdate = strconv.Itoa(user.ID)
 
-	// Convert to uppercase hex with * prefix
-	result := "*" + strings.ToUpper(hex.EncodeToString(secondHash[:]))
+	if err := database.DB.Save(&user).Error; err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to change password")
+	}
 
-	return result
+	return utils.SuccessResponse(c, "Password changed successfully", nil)
 }
 
 // GetCurrentUser godoc
@@ -422,6 +728,9 @@ func (ctrl *AuthController) GetCurrentUser(c *fiber.Ctx) error {
 		"user_type": userType,
 		"user":      userData,
 	}
+	if claims.Impersonator != nil {
+		response["impersonator"] = claims.Impersonator
+	}
 
 	return utils.SuccessResponse(c, "User data retrieved successfully", response)
 }
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImpersonationController handles admin "view as user" endpoints
+type ImpersonationController struct {
+	service   *services.ImpersonationService
+	validator *validator.Validate
+}
+
+// NewImpersonationController creates a new controller instance
+func NewImpersonationController() *ImpersonationController {
+	return &ImpersonationController{
+		service:   services.NewImpersonationService(),
+		validator: validator.New(),
+	}
+}
+
+// Start godoc
+// @Summary Start Impersonation
+// @Description Admin gets a short-lived, read-only token to view the app as a mahasiswa or reviewer. Every session is written to the audit log.
+// @Tags Admin - Impersonation
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body request.ImpersonateRequest true "Impersonation target"
+// @Success 200 {object} response.APIResponse{data=response.ImpersonationResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/impersonate [post]
+func (ctrl *ImpersonationController) Start(c *fiber.Ctx) error {
+	// 1. Parse request body
+	var req request.ImpersonateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+
+	// 2. Validate request
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	// 3. Call service
+	result, err := ctrl.service.Start(utils.GetCurrentClaims(c), &req, c.IP(), c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to start impersonation",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Impersonation started",
+		result,
+	))
+}
+
+// Stop godoc
+// @Summary Stop Impersonation
+// @Description End the current impersonation session and revoke its token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer impersonation token"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/impersonation/stop [post]
+func (ctrl *ImpersonationController) Stop(c *fiber.Ctx) error {
+	if err := ctrl.service.Stop(utils.GetCurrentClaims(c)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to stop impersonation",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Impersonation stopped",
+		nil,
+	))
+}
+
+// GetLogs godoc
+// @Summary Get Impersonation Audit Log
+// @Description Admin gets the impersonation audit trail
+// @Tags Admin - Impersonation
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id_admin query int false "Filter by admin ID"
+// @Param target_id query string false "Filter by NIM or id_reviewer"
+// @Param active_only query bool false "Only sessions that are still running"
+// @Success 200 {object} response.APIResponse{data=[]response.ImpersonationLogResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/impersonations [get]
+func (ctrl *ImpersonationController) GetLogs(c *fiber.Ctx) error {
+	idAdmin := c.QueryInt("id_admin", 0)
+	targetID := c.Query("target_id", "")
+	activeOnly := c.QueryBool("active_only", false)
+
+	result, err := ctrl.service.GetLogs(idAdmin, targetID, activeOnly)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get impersonation log",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Impersonation log retrieved successfully",
+		result,
+	))
+}
@@ -6,6 +6,7 @@ import (
 	"rires-be/internal/dto/response"
 	"rires-be/internal/models"
 	"rires-be/pkg/database"
+	"rires-be/pkg/services"
 	"rires-be/pkg/utils"
 	"strconv"
 	"time"
@@ -13,10 +14,14 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-type KategoriPKMController struct{}
+type KategoriPKMController struct {
+	kodeService *services.KodePengajuanService
+}
 
 func NewKategoriPKMController() *KategoriPKMController {
-	return &KategoriPKMController{}
+	return &KategoriPKMController{
+		kodeService: services.NewKodePengajuanService(),
+	}
 }
 
 // GetList godoc
@@ -67,9 +72,20 @@ func (ctrl *KategoriPKMController) GetList(c *fiber.Ctx) error {
 		return utils.InternalServerErrorResponse(c, "Failed to fetch data")
 	}
 
+	// Kode singkat tersimpan (sisanya diturunkan dari nama)
+	kodeMap, err := ctrl.kodeService.KodeKategoriMap()
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch kode kategori")
+	}
+
 	// Transform to response
 	var data []response.KategoriPKMResponse
 	for _, kat := range kategoris {
+		kode, ok := kodeMap[kat.ID]
+		if !ok {
+			kode = utils.DeriveKodeKategori(kat.NamaKategori)
+		}
+
 		statusText := "Aktif"
 		if kat.Status == 2 {
 			statusText = "Tidak Aktif"
@@ -78,6 +94,7 @@ func (ctrl *KategoriPKMController) GetList(c *fiber.Ctx) error {
 		data = append(data, response.KategoriPKMResponse{
 			ID:           kat.ID,
 			NamaKategori: kat.NamaKategori,
+			Kode:         kode,
 			Status:       kat.Status,
 			StatusText:   statusText,
 			TglInsert:    kat.TglInsert,
@@ -121,6 +138,8 @@ func (ctrl *KategoriPKMController) GetByID(c *fiber.Ctx) error {
 		return utils.NotFoundResponse(c, "Kategori PKM not found")
 	}
 
+	kode, _ := ctrl.kodeService.KodeKategori(&kategori)
+
 	statusText := "Aktif"
 	if kategori.Status == 2 {
 		statusText = "Tidak Aktif"
@@ -129,6 +148,7 @@ func (ctrl *KategoriPKMController) GetByID(c *fiber.Ctx) error {
 	result := response.KategoriPKMResponse{
 		ID:           kategori.ID,
 		NamaKategori: kategori.NamaKategori,
+		Kode:         kode,
 		Status:       kategori.Status,
 		StatusText:   statusText,
 		TglInsert:    kategori.TglInsert,
@@ -172,6 +192,13 @@ func (ctrl *KategoriPKMController) Create(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Kategori PKM with this name already exists")
 	}
 
+	// Validate kode singkat (opsional)
+	if req.Kode != "" {
+		if _, err := ctrl.kodeService.ValidateKodeKategori(0, req.Kode); err != nil {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+	}
+
 	// Create
 	now := time.Now()
 	kategori := models.KategoriPKM{
@@ -186,6 +213,13 @@ func (ctrl *KategoriPKMController) Create(c *fiber.Ctx) error {
 		return utils.InternalServerErrorResponse(c, "Failed to create kategori PKM")
 	}
 
+	if req.Kode != "" {
+		if _, err := ctrl.kodeService.SetKodeKategori(kategori.ID, req.Kode, kategori.UserUpdate); err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to save kode kategori")
+		}
+	}
+	kode, _ := ctrl.kodeService.KodeKategori(&kategori)
+
 	statusText := "Aktif"
 	if kategori.Status == 2 {
 		statusText = "Tidak Aktif"
@@ -194,6 +228,7 @@ func (ctrl *KategoriPKMController) Create(c *fiber.Ctx) error {
 	result := response.KategoriPKMResponse{
 		ID:           kategori.ID,
 		NamaKategori: kategori.NamaKategori,
+		Kode:         kode,
 		Status:       kategori.Status,
 		StatusText:   statusText,
 		TglInsert:    kategori.TglInsert,
@@ -251,6 +286,13 @@ func (ctrl *KategoriPKMController) Update(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Kategori PKM with this name already exists")
 	}
 
+	// Validate kode singkat (hanya jika dikirim)
+	if req.Kode != nil && *req.Kode != "" {
+		if _, err := ctrl.kodeService.ValidateKodeKategori(id, *req.Kode); err != nil {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+	}
+
 	// Update
 	kategori.NamaKategori = req.NamaKategori
 	kategori.Status = req.Status
@@ -260,6 +302,13 @@ func (ctrl *KategoriPKMController) Update(c *fiber.Ctx) error {
 		return utils.InternalServerErrorResponse(c, "Failed to update kategori PKM")
 	}
 
+	if req.Kode != nil {
+		if _, err := ctrl.kodeService.SetKodeKategori(kategori.ID, *req.Kode, kategori.UserUpdate); err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to save kode kategori")
+		}
+	}
+	kode, _ := ctrl.kodeService.KodeKategori(&kategori)
+
 	statusText := "Aktif"
 	if kategori.Status == 2 {
 		statusText = "Tidak Aktif"
@@ -268,6 +317,7 @@ func (ctrl *KategoriPKMController) Update(c *fiber.Ctx) error {
 	result := response.KategoriPKMResponse{
 		ID:           kategori.ID,
 		NamaKategori: kategori.NamaKategori,
+		Kode:         kode,
 		Status:       kategori.Status,
 		StatusText:   statusText,
 		TglInsert:    kategori.TglInsert,
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// KodePengajuanController handles admin configuration of the kode pengajuan format
+type KodePengajuanController struct {
+	service   *services.KodePengajuanService
+	validator *validator.Validate
+}
+
+// NewKodePengajuanController creates a new controller instance
+func NewKodePengajuanController() *KodePengajuanController {
+	return &KodePengajuanController{
+		service:   services.NewKodePengajuanService(),
+		validator: validator.New(),
+	}
+}
+
+// GetTemplate godoc
+// @Summary Get Kode Pengajuan Template
+// @Description Admin gets the active kode pengajuan template, the configured default and an example code
+// @Tags Admin - Kode Pengajuan
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} response.APIResponse{data=response.KodeTemplateResponse}
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/kode-pengajuan/template [get]
+func (ctrl *KodePengajuanController) GetTemplate(c *fiber.Ctx) error {
+	return c.JSON(response.SuccessResponse(
+		"Template kode pengajuan retrieved successfully",
+		ctrl.service.GetTemplate(),
+	))
+}
+
+// UpdateTemplate godoc
+// @Summary Update Kode Pengajuan Template
+// @Description Admin changes the kode pengajuan template. Placeholders: {KODE} kode kategori, {TAHUN} tahun periode, {SEQ:n} nomor urut n digit. Empty template resets to the configured default. Existing codes are not changed.
+// @Tags Admin - Kode Pengajuan
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body request.UpdateKodeTemplateRequest true "Template kode"
+// @Success 200 {object} response.APIResponse{data=response.KodeTemplateResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/kode-pengajuan/template [put]
+func (ctrl *KodePengajuanController) UpdateTemplate(c *fiber.Ctx) error {
+	var req request.UpdateKodeTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	userUpdate := fmt.Sprintf("%d", utils.GetCurrentUserID(c))
+	result, err := ctrl.service.SetTemplate(req.Template, userUpdate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to update template kode pengajuan",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Template kode pengajuan updated successfully",
+		result,
+	))
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"strconv"
+
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LoginAttemptController handles login lockout management endpoints
+type LoginAttemptController struct {
+	service *services.LoginThrottleService
+}
+
+// NewLoginAttemptController creates a new controller instance
+func NewLoginAttemptController() *LoginAttemptController {
+	return &LoginAttemptController{
+		service: services.NewLoginThrottleService(),
+	}
+}
+
+// GetLockouts godoc
+// @Summary Get Login Lockouts
+// @Description Admin gets failed-login counters per username and IP
+// @Tags Admin - Login Lockout
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param locked_only query bool false "Only show keys that are currently locked"
+// @Param search query string false "Search by username or IP"
+// @Success 200 {object} response.APIResponse{data=[]response.LoginAttemptResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/login-lockouts [get]
+func (ctrl *LoginAttemptController) GetLockouts(c *fiber.Ctx) error {
+	lockedOnly := c.QueryBool("locked_only", false)
+	search := c.Query("search", "")
+
+	result, err := ctrl.service.GetAttempts(lockedOnly, search)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get login lockouts",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Login lockouts retrieved successfully",
+		result,
+	))
+}
+
+// ClearLockout godoc
+// @Summary Clear Login Lockout
+// @Description Admin clears the lockout and failed-login counter of a username or IP
+// @Tags Admin - Login Lockout
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Login Attempt ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/login-lockouts/{id} [delete]
+func (ctrl *LoginAttemptController) ClearLockout(c *fiber.Ctx) error {
+	// 1. Parse ID
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Get user ID
+	userID := int(utils.GetCurrentUserID(c))
+
+	// 3. Call service
+	if err := ctrl.service.ClearAttempt(id, userID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(response.ErrorResponse(
+			"Failed to clear lockout",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Lockout berhasil dihapus",
+		nil,
+	))
+}
@@ -153,8 +153,8 @@ func (ctrl *MenuController) GetMyMenuTree(c *fiber.Ctx) error {
 	// 1. Get user's level directly from JWT token
 	idUserLevel := utils.GetCurrentUserLevel(c)
 
-	// Superadmin (1) and Admin (2) get all menus
-	if idUserLevel == 1 || idUserLevel == 2 {
+	// Superadmin (1) and Admin (2) get all menus, selama role aktif masih admin
+	if utils.IsAdmin(c) && (idUserLevel == 1 || idUserLevel == 2) {
 		return ctrl.GetTree(c)
 	}
 
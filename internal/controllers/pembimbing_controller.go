@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PembimbingController handles dosen pembimbing endpoints (tim bimbingan & pengesahan judul)
+type PembimbingController struct {
+	service          *services.PembimbingService
+	pengajuanService *services.PengajuanService
+	validator        *validator.Validate
+}
+
+// NewPembimbingController creates a new controller instance
+func NewPembimbingController() *PembimbingController {
+	return &PembimbingController{
+		service:          services.NewPembimbingService(),
+		pengajuanService: services.NewPengajuanService(),
+		validator:        validator.New(),
+	}
+}
+
+// currentPegawai me-resolve id_pegawai dosen yang sedang login (reviewer maupun pembimbing saja)
+func (ctrl *PembimbingController) currentPegawai(c *fiber.Ctx) (int, error) {
+	return ctrl.service.ResolveFromClaims(utils.GetCurrentClaims(c))
+}
+
+// GetMyTeams godoc
+// @Summary Get Tim Bimbingan
+// @Description Dosen pembimbing lists all teams they supervise, with endorsement status
+// @Tags Dosen Pembimbing
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param status query string false "Filter by status pengesahan (PENDING/DISETUJUI/DITOLAK)"
+// @Success 200 {object} response.APIResponse{data=[]response.TimBimbinganResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pembimbing/teams [get]
+func (ctrl *PembimbingController) GetMyTeams(c *fiber.Ctx) error {
+	idPegawai, err := ctrl.currentPegawai(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
+			"Pembimbing not found. Please relogin.",
+			err.Error(),
+		))
+	}
+
+	result, err := ctrl.service.GetTeams(idPegawai, c.Query("status"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get tim bimbingan",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Tim bimbingan retrieved successfully",
+		result,
+	))
+}
+
+// GetPengajuanDetail godoc
+// @Summary Get Pengajuan Detail (Pembimbing)
+// @Description Dosen pembimbing gets detail of a pengajuan they supervise
+// @Tags Dosen Pembimbing
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Pengajuan ID"
+// @Success 200 {object} response.APIResponse{data=response.PengajuanResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pembimbing/pengajuan/{id} [get]
+func (ctrl *PembimbingController) GetPengajuanDetail(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid pengajuan ID",
+			err.Error(),
+		))
+	}
+
+	idPegawai, err := ctrl.currentPegawai(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
+			"Pembimbing not found. Please relogin.",
+			err.Error(),
+		))
+	}
+
+	result, err := ctrl.pengajuanService.GetBimbinganDetail(id, idPegawai)
+	if err != nil {
+		return c.Status(pembimbingErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to get pengajuan detail",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Pengajuan detail retrieved successfully",
+		result,
+	))
+}
+
+// Endorse godoc
+// @Summary Pengesahan Judul oleh Pembimbing
+// @Description Dosen pembimbing approves (DISETUJUI) or rejects (DITOLAK) the judul before reviewer plotting. Catatan is required when rejecting.
+// @Tags Dosen Pembimbing
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Pengajuan ID"
+// @Param body body request.PengesahanPembimbingRequest true "Keputusan pengesahan"
+// @Success 200 {object} response.APIResponse{data=response.PembimbingResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pembimbing/pengajuan/{id}/pengesahan [post]
+func (ctrl *PembimbingController) Endorse(c *fiber.Ctx) error {
+	// 1. Parse ID from URL
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid pengajuan ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Parse & validate request body
+	var req request.PengesahanPembimbingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	// 3. Get authenticated pembimbing
+	idPegawai, err := ctrl.currentPegawai(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
+			"Pembimbing not found. Please relogin.",
+			err.Error(),
+		))
+	}
+
+	// 4. Call service (user_update = id_pegawai)
+	result, err := ctrl.service.Endorse(id, idPegawai, &req, strconv.Itoa(idPegawai))
+	if err != nil {
+		return c.Status(pembimbingErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to save pengesahan",
+			err.Error(),
+		))
+	}
+
+	message := "Judul disetujui pembimbing"
+	if req.Keputusan == services.PengesahanDitolak {
+		message = "Judul tidak disetujui pembimbing"
+	}
+	return c.JSON(response.SuccessResponse(message, result))
+}
+
+// pembimbingErrorStatus memetakan error pembimbing ke HTTP status
+func pembimbingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotPembimbing):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrPengajuanNotFound):
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
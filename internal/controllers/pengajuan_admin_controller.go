@@ -1,11 +1,17 @@
 package controllers
 
 import (
+	"bufio"
+	"fmt"
+	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"rires-be/internal/dto/request"
 	"rires-be/internal/dto/response"
 	"rires-be/pkg/services"
+	"rires-be/pkg/spreadsheet"
 	"rires-be/pkg/utils"
 
 	"github.com/go-playground/validator/v10"
@@ -15,6 +21,9 @@ import (
 // PengajuanAdminController handles admin PKM submission management
 type PengajuanAdminController struct {
 	service   *services.PengajuanService
+	kemiripan *services.KemiripanService
+	importer  *services.ImportPengajuanService
+	exporter  *services.ExportPengajuanService
 	validator *validator.Validate
 }
 
@@ -22,6 +31,9 @@ type PengajuanAdminController struct {
 func NewPengajuanAdminController() *PengajuanAdminController {
 	return &PengajuanAdminController{
 		service:   services.NewPengajuanService(),
+		kemiripan: services.NewKemiripanService(),
+		importer:  services.NewImportPengajuanService(),
+		exporter:  services.NewExportPengajuanService(),
 		validator: validator.New(),
 	}
 }
@@ -40,6 +52,8 @@ func NewPengajuanAdminController() *PengajuanAdminController {
 // @Param status_final query string false "Filter by status final"
 // @Param id_kategori query int false "Filter by kategori"
 // @Param tahun query int false "Filter by tahun"
+// @Param id_periode query int false "Filter by periode pendaftaran (tgl_setting ID)"
+// @Param include_draft query bool false "Include drafts that have not been submitted" default(false)
 // @Success 200 {object} response.APIResponse{data=response.PaginatedResponse}
 // @Failure 401 {object} response.APIResponse
 // @Failure 500 {object} response.APIResponse
@@ -54,6 +68,8 @@ func (ctrl *PengajuanAdminController) GetAllPengajuan(c *fiber.Ctx) error {
 	statusFinal := c.Query("status_final", "")
 	idKategori, _ := strconv.Atoi(c.Query("id_kategori", "0"))
 	tahun, _ := strconv.Atoi(c.Query("tahun", "0"))
+	idPeriode, _ := strconv.Atoi(c.Query("id_periode", "0"))
+	includeDraft := c.QueryBool("include_draft", false)
 
 	// 2. Build filters
 	filters := map[string]interface{}{
@@ -64,6 +80,8 @@ func (ctrl *PengajuanAdminController) GetAllPengajuan(c *fiber.Ctx) error {
 		"status_final":    statusFinal,
 		"id_kategori":     idKategori,
 		"tahun":           tahun,
+		"id_periode":      idPeriode,
+		"include_draft":   includeDraft,
 	}
 
 	// 3. Call service
@@ -85,6 +103,71 @@ func (ctrl *PengajuanAdminController) GetAllPengajuan(c *fiber.Ctx) error {
 	))
 }
 
+// ExportPengajuan godoc
+// @Summary Export Pengajuan to CSV/XLSX (Admin)
+// @Description Admin downloads every pengajuan matching the list filters (no pagination) as .csv or .xlsx. Optional columns via include (comma separated): anggota, reviewer, catatan_review, parameter. Parameter columns use the import header format param:<nama_parameter>.
+// @Tags Admin - Pengajuan PKM
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Authorization header string true "Bearer token"
+// @Param format query string false "csv or xlsx" default(csv)
+// @Param include query string false "Optional columns, e.g. anggota,reviewer,catatan_review,parameter"
+// @Param status_judul query string false "Filter by status judul"
+// @Param status_proposal query string false "Filter by status proposal"
+// @Param status_final query string false "Filter by status final"
+// @Param id_kategori query int false "Filter by kategori"
+// @Param tahun query int false "Filter by tahun"
+// @Param id_periode query int false "Filter by periode pendaftaran (tgl_setting ID)"
+// @Param include_draft query bool false "Include drafts that have not been submitted" default(false)
+// @Success 200 {file} file
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/pengajuan/export [get]
+func (ctrl *PengajuanAdminController) ExportPengajuan(c *fiber.Ctx) error {
+	// 1. Parse format & optional columns
+	format := strings.ToLower(c.Query("format", spreadsheet.FormatCSV))
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid format",
+			spreadsheet.ErrUnsupportedFormat.Error(),
+		))
+	}
+	options, err := services.ParseExportInclude(c.Query("include", ""))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid include",
+			err.Error(),
+		))
+	}
+
+	// 2. Build filters (sama dengan GetAllPengajuan; string di-clone karena dipakai setelah handler selesai)
+	idKategori, _ := strconv.Atoi(c.Query("id_kategori", "0"))
+	tahun, _ := strconv.Atoi(c.Query("tahun", "0"))
+	idPeriode, _ := strconv.Atoi(c.Query("id_periode", "0"))
+	filters := map[string]interface{}{
+		"status_judul":    strings.Clone(c.Query("status_judul", "")),
+		"status_proposal": strings.Clone(c.Query("status_proposal", "")),
+		"status_final":    strings.Clone(c.Query("status_final", "")),
+		"id_kategori":     idKategori,
+		"tahun":           tahun,
+		"id_periode":      idPeriode,
+		"include_draft":   c.QueryBool("include_draft", false),
+	}
+
+	// 3. Stream file; error di tengah stream hanya bisa dicatat di log
+	filename := fmt.Sprintf("pengajuan-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentType, spreadsheet.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := ctrl.exporter.Export(w, format, filters, options); err != nil {
+			log.Printf("Failed to export pengajuan: %v", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
 // GetPengajuanDetail godoc
 // @Summary Get Pengajuan Detail (Admin)
 // @Description Admin gets full detail of any pengajuan
@@ -125,6 +208,100 @@ func (ctrl *PengajuanAdminController) GetPengajuanDetail(c *fiber.Ctx) error {
 	))
 }
 
+// GetKemiripanReport godoc
+// @Summary Get Similar Judul Clusters (Admin)
+// @Description Admin gets groups of judul in one period that are similar to each other (TF-IDF over all years)
+// @Tags Admin - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id_periode query int false "Periode pendaftaran (tgl_setting ID); jika diisi, tahun diabaikan"
+// @Param tahun query int false "Tahun pengajuan jika id_periode kosong, default tahun ini"
+// @Param min_skor query number false "Minimum similarity score 0-1, default SIMILARITY_THRESHOLD"
+// @Success 200 {object} response.APIResponse{data=[]response.KemiripanClusterResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/pengajuan/kemiripan [get]
+func (ctrl *PengajuanAdminController) GetKemiripanReport(c *fiber.Ctx) error {
+	// 1. Parse query params
+	idPeriode := c.QueryInt("id_periode", 0)
+	tahun := c.QueryInt("tahun", time.Now().Year())
+	minSkor := c.QueryFloat("min_skor", 0)
+	if minSkor < 0 || minSkor > 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid min_skor",
+			"min_skor harus antara 0 dan 1",
+		))
+	}
+
+	// 2. Call service
+	result, err := ctrl.kemiripan.ClusterReport(idPeriode, tahun, minSkor)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get kemiripan report",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Kemiripan report retrieved successfully",
+		result,
+	))
+}
+
+// ImportPengajuan godoc
+// @Summary Import Pengajuan from CSV/XLSX (Admin)
+// @Description Admin imports pengajuan from a .csv or .xlsx file. Columns: judul, kategori (id, nama or kode), nim_ketua, nim_anggota (separated by ; , or space), id_dosen_pembimbing, optional email_ketua, no_hp_ketua, program_studi, fakultas, and param:<nama_parameter> for parameter answers. Rows get the same validation as creating a pengajuan plus NIM checks in NEOMAA. mode=dry-run (default) only returns per-row errors; mode=commit saves all valid rows in one transaction with generated kode.
+// @Tags Admin - Pengajuan PKM
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param file formData file true "File .csv / .xlsx"
+// @Param mode query string false "dry-run or commit" default(dry-run)
+// @Success 200 {object} response.APIResponse{data=response.ImportPengajuanResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/pengajuan/import [post]
+func (ctrl *PengajuanAdminController) ImportPengajuan(c *fiber.Ctx) error {
+	// 1. Get uploaded file
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"File is required",
+			err.Error(),
+		))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to read file",
+			err.Error(),
+		))
+	}
+	defer src.Close()
+
+	// 2. Call service (dry-run unless mode=commit)
+	mode := c.Query("mode", services.ImportModeDryRun)
+	result, err := ctrl.importer.Import(file.Filename, src, mode)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to import pengajuan",
+			err.Error(),
+		))
+	}
+
+	message := "Import pengajuan validated"
+	if mode == services.ImportModeCommit {
+		message = "Import pengajuan completed"
+	}
+	return c.JSON(response.SuccessResponse(message, result))
+}
+
 // AssignReviewerJudul godoc
 // @Summary Assign Reviewer for Judul
 // @Description Admin assigns reviewer (pegawai) to review PKM title
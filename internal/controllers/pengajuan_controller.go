@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"rires-be/internal/dto/request"
 	"rires-be/internal/dto/response"
 	"rires-be/pkg/services"
 	"rires-be/pkg/utils"
+	"rires-be/pkg/workflow"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -14,15 +17,23 @@ import (
 
 // PengajuanController handles mahasiswa PKM submission endpoints
 type PengajuanController struct {
-	service   *services.PengajuanService
-	validator *validator.Validate
+	service         *services.PengajuanService
+	eventService    *services.PengajuanEventService
+	pembimbing      *services.PembimbingService
+	invitations     *services.InvitationService
+	transfers       *services.TransferKetuaService
+	validator       *validator.Validate
 }
 
 // NewPengajuanController creates a new controller instance
 func NewPengajuanController() *PengajuanController {
 	return &PengajuanController{
-		service:   services.NewPengajuanService(),
-		validator: validator.New(),
+		service:         services.NewPengajuanService(),
+		eventService:    services.NewPengajuanEventService(),
+		pembimbing:      services.NewPembimbingService(),
+		invitations:     services.NewInvitationService(),
+		transfers:       services.NewTransferKetuaService(),
+		validator:       validator.New(),
 	}
 }
 
@@ -33,7 +44,7 @@ func NewPengajuanController() *PengajuanController {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
-// @Param body body request.CreatePengajuanRequest true "Pengajuan data"
+// @Param body body request.CreatePengajuanRequest true "Pengajuan data (is_draft=true saves without full validation)"
 // @Success 201 {object} response.APIResponse{data=response.PengajuanResponse}
 // @Failure 400 {object} response.APIResponse
 // @Failure 401 {object} response.APIResponse
@@ -50,8 +61,14 @@ func (ctrl *PengajuanController) CreateJudulPKM(c *fiber.Ctx) error {
 		))
 	}
 
-	// 2. Validate request
-	if err := ctrl.validator.Struct(req); err != nil {
+	// 2. Validate request (draft only needs kategori, the rest is validated on submit)
+	validate := ctrl.validator.Struct
+	if req.IsDraft {
+		validate = func(s interface{}) error {
+			return ctrl.validator.StructPartial(s, "IDKategori", "EmailKetua", "Anggota")
+		}
+	}
+	if err := validate(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
 			"Validation failed",
 			ctrl.formatValidationErrors(err),
@@ -67,7 +84,7 @@ func (ctrl *PengajuanController) CreateJudulPKM(c *fiber.Ctx) error {
 	}
 
 	// 4. Get NIM ketua based on user type
-	userType := c.Locals("user_type")
+	userType := utils.GetActiveRole(c)
 	var nimKetua string
 	var isAdmin bool
 
@@ -112,20 +129,25 @@ func (ctrl *PengajuanController) CreateJudulPKM(c *fiber.Ctx) error {
 	}
 
 	// 6. Return success response
+	message := "Pengajuan berhasil dibuat"
+	if req.IsDraft {
+		message = "Draft pengajuan berhasil disimpan"
+	}
 	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse(
-		"Pengajuan berhasil dibuat",
+		message,
 		result,
 	))
 }
 
 // GetMySubmissions godoc
 // @Summary Get My Submissions
-// @Description Get all submissions created by authenticated mahasiswa
+// @Description Get all submissions where authenticated mahasiswa is ketua or anggota. Each item has peran (ketua/anggota)
 // @Tags Mahasiswa - Pengajuan PKM
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
-// @Param status query string false "Filter by status (all/pending/acc/revisi/tolak)"
+// @Param status query string false "Filter by status (all/draft/pending/acc/revisi/tolak)"
+// @Param peran query string false "Filter by peran (ketua/anggota), empty = both"
 // @Success 200 {object} response.APIResponse{data=[]response.PengajuanListResponse}
 // @Failure 401 {object} response.APIResponse
 // @Failure 500 {object} response.APIResponse
@@ -133,13 +155,14 @@ func (ctrl *PengajuanController) CreateJudulPKM(c *fiber.Ctx) error {
 // @Router /pengajuan/my-submissions [get]
 func (ctrl *PengajuanController) GetMySubmissions(c *fiber.Ctx) error {
 	// 1. Get authenticated user NIM
-	nimKetua := utils.GetCurrentUsername(c)
+	nim := utils.GetCurrentUsername(c)
 
 	// 2. Get filter from query params
 	statusFilter := c.Query("status", "all")
+	peranFilter := c.Query("peran")
 
 	// 3. Call service
-	result, err := ctrl.service.GetMySubmissions(nimKetua, statusFilter)
+	result, err := ctrl.service.GetMySubmissions(nim, statusFilter, peranFilter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
 			"Failed to get submissions",
@@ -156,7 +179,7 @@ func (ctrl *PengajuanController) GetMySubmissions(c *fiber.Ctx) error {
 
 // GetPengajuanDetail godoc
 // @Summary Get Pengajuan Detail
-// @Description Get detailed information of a pengajuan (only if user is part of the team)
+// @Description Get detailed information of a pengajuan (only if user is ketua or anggota of the team; anggota get read-only access)
 // @Tags Mahasiswa - Pengajuan PKM
 // @Accept json
 // @Produce json
@@ -180,23 +203,23 @@ func (ctrl *PengajuanController) GetPengajuanDetail(c *fiber.Ctx) error {
 		))
 	}
 
-	// 2. Get detail from service
-	result, err := ctrl.service.GetPengajuanDetail(id)
+	// 2. Get detail from service - only team members (or admin) can view
+	result, err := ctrl.service.GetTeamPengajuanDetail(id, utils.GetCurrentUsername(c), utils.IsAdmin(c))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(response.ErrorResponse(
-			"Pengajuan not found",
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrNotTeamMember):
+			status = fiber.StatusForbidden
+		case errors.Is(err, services.ErrPengajuanNotFound):
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(response.ErrorResponse(
+			"Failed to get pengajuan detail",
 			err.Error(),
 		))
 	}
 
-	// 3. Check authorization - only team members can view
-	// TODO: Verify user is part of the team
-	// nimUser := utils.GetCurrentUsername(c)
-	// if !isTeamMember(result, nimUser) {
-	//     return c.Status(403).JSON(...)
-	// }
-
-	// 4. Return success
+	// 3. Return success
 	return c.JSON(response.SuccessResponse(
 		"Pengajuan detail",
 		result,
@@ -267,6 +290,109 @@ func (ctrl *PengajuanController) UpdateJudul(c *fiber.Ctx) error {
 	))
 }
 
+// SubmitPengajuan godoc
+// @Summary Submit Draft Pengajuan
+// @Description Ketua submits a draft. Runs full validation (registration period, team, NIM) then moves status_judul DRAFT -> PENDING
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Pengajuan ID"
+// @Success 200 {object} response.APIResponse{data=response.PengajuanResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/{id}/submit [post]
+func (ctrl *PengajuanController) SubmitPengajuan(c *fiber.Ctx) error {
+	// 1. Parse ID from URL
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid pengajuan ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Get authenticated user
+	nimKetua := utils.GetCurrentUsername(c)
+	isAdmin := utils.IsAdmin(c)
+
+	// 3. Call service
+	result, err := ctrl.service.SubmitPengajuan(id, nimKetua, isAdmin)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to submit pengajuan",
+			err.Error(),
+		))
+	}
+
+	// 4. Return success
+	return c.JSON(response.SuccessResponse(
+		"Pengajuan berhasil diajukan",
+		result,
+	))
+}
+
+// WithdrawPengajuan godoc
+// @Summary Withdraw Pengajuan
+// @Description Ketua withdraws a pengajuan before judul ACC. Requires a reason; admin can restore it from the recycle bin
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Pengajuan ID"
+// @Param body body request.WithdrawPengajuanRequest true "Alasan penarikan"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/{id}/withdraw [post]
+func (ctrl *PengajuanController) WithdrawPengajuan(c *fiber.Ctx) error {
+	// 1. Parse ID from URL
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid pengajuan ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Parse & validate reason
+	var req request.WithdrawPengajuanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			ctrl.formatValidationErrors(err),
+		))
+	}
+
+	// 3. Call service (ketua only)
+	if err := ctrl.service.WithdrawPengajuan(id, utils.GetCurrentUsername(c), req.Alasan); err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, services.ErrPengajuanNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(response.ErrorResponse(
+			"Failed to withdraw pengajuan",
+			err.Error(),
+		))
+	}
+
+	// 4. Return success
+	return c.JSON(response.SuccessResponse(
+		"Pengajuan berhasil ditarik",
+		nil,
+	))
+}
+
 // UploadProposal godoc
 // @Summary Upload Proposal File
 // @Description Ketua uploads proposal PDF/DOC/DOCX (only allowed when status_judul = ACC)
@@ -388,6 +514,7 @@ func (ctrl *PengajuanController) ReviseProposal(c *fiber.Ctx) error {
 // @Param per_page query int false "Items per page" default(10)
 // @Param id_kategori query int false "Filter by kategori"
 // @Param tahun query int false "Filter by tahun"
+// @Param id_periode query int false "Filter by periode pendaftaran (tgl_setting ID)"
 // @Success 200 {object} response.APIResponse{data=response.PaginatedResponse}
 // @Failure 401 {object} response.APIResponse
 // @Failure 500 {object} response.APIResponse
@@ -399,6 +526,7 @@ func (ctrl *PengajuanController) GetAnnouncements(c *fiber.Ctx) error {
 	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
 	idKategori, _ := strconv.Atoi(c.Query("id_kategori", "0"))
 	tahun, _ := strconv.Atoi(c.Query("tahun", "0"))
+	idPeriode, _ := strconv.Atoi(c.Query("id_periode", "0"))
 	statusProposal := c.Query("status_proposal", "")
 
 	// 2. Build filters
@@ -407,6 +535,7 @@ func (ctrl *PengajuanController) GetAnnouncements(c *fiber.Ctx) error {
 		"per_page":        perPage,
 		"id_kategori":     idKategori,
 		"tahun":           tahun,
+		"id_periode":      idPeriode,
 		"status_proposal": statusProposal,
 	}
 
@@ -429,10 +558,382 @@ func (ctrl *PengajuanController) GetAnnouncements(c *fiber.Ctx) error {
 	))
 }
 
+// GetTimeline godoc
+// @Summary Get Pengajuan Timeline
+// @Description Get status history of a pengajuan (who changed what and when). Accessible by the team, dosen pembimbing, assigned reviewers and admin.
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Pengajuan ID"
+// @Success 200 {object} response.APIResponse{data=[]response.PengajuanEventResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/{id}/timeline [get]
+func (ctrl *PengajuanController) GetTimeline(c *fiber.Ctx) error {
+	// 1. Parse ID from URL
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid pengajuan ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Resolve viewer
+	viewer := workflow.Actor{IsAdmin: utils.IsAdmin(c)}
+	switch {
+	case viewer.IsAdmin:
+	case utils.IsReviewer(c):
+		// Reviewer atau dosen pembimbing
+		idPegawai, err := ctrl.pembimbing.ResolveFromClaims(utils.GetCurrentClaims(c))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
+				"Reviewer not found. Please relogin.",
+				err.Error(),
+			))
+		}
+		viewer.IDPegawai = idPegawai
+	case utils.IsMahasiswa(c):
+		viewer.NIM = utils.GetCurrentUsername(c)
+	}
+
+	// 3. Call service
+	result, err := ctrl.eventService.GetTimeline(id, viewer)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrTimelineForbidden):
+			status = fiber.StatusForbidden
+		case errors.Is(err, services.ErrPengajuanNotFound):
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(response.ErrorResponse(
+			"Failed to get timeline",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Timeline retrieved successfully",
+		result,
+	))
+}
+
+// GetMyInvitations godoc
+// @Summary Get My Team Invitations
+// @Description Mahasiswa sees invitations to join PKM teams. Filter by status (PENDING, ACCEPTED, DECLINED, EXPIRED, CANCELLED), empty = all
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param status query string false "Filter by status_undangan"
+// @Success 200 {object} response.APIResponse{data=[]response.InvitationResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/invitations [get]
+func (ctrl *PengajuanController) GetMyInvitations(c *fiber.Ctx) error {
+	nim := utils.GetCurrentUsername(c)
+
+	result, err := ctrl.invitations.GetMyInvitations(nim, strings.ToUpper(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get invitations",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Invitations retrieved successfully",
+		result,
+	))
+}
+
+// AcceptInvitation godoc
+// @Summary Accept Team Invitation
+// @Description Anggota accepts invitation to join a PKM team
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} response.APIResponse{data=response.InvitationResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/invitations/{id}/accept [post]
+func (ctrl *PengajuanController) AcceptInvitation(c *fiber.Ctx) error {
+	return ctrl.respondInvitation(c, true)
+}
+
+// DeclineInvitation godoc
+// @Summary Decline Team Invitation
+// @Description Anggota declines invitation; the member slot is freed so ketua can invite someone else
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} response.APIResponse{data=response.InvitationResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/invitations/{id}/decline [post]
+func (ctrl *PengajuanController) DeclineInvitation(c *fiber.Ctx) error {
+	return ctrl.respondInvitation(c, false)
+}
+
+// NominateKetua godoc
+// @Summary Nominate New Ketua
+// @Description Current ketua (or admin) nominates another confirmed team member as ketua. Takes effect after the member accepts
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Pengajuan ID"
+// @Param body body request.NominateKetuaRequest true "NIM anggota tujuan"
+// @Success 201 {object} response.APIResponse{data=response.TransferKetuaResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/{id}/transfer-ketua [post]
+func (ctrl *PengajuanController) NominateKetua(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid pengajuan ID",
+			err.Error(),
+		))
+	}
+
+	var req request.NominateKetuaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			ctrl.formatValidationErrors(err),
+		))
+	}
+
+	result, err := ctrl.transfers.Nominate(id, &req, ctrl.currentActor(c))
+	if err != nil {
+		return c.Status(transferErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to nominate ketua",
+			err.Error(),
+		))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse(
+		"Pengalihan ketua diajukan, menunggu persetujuan anggota",
+		result,
+	))
+}
+
+// GetMyTransfers godoc
+// @Summary Get My Ketua Transfers
+// @Description Ketua transfer nominations sent or received by the authenticated mahasiswa
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} response.APIResponse{data=[]response.TransferKetuaResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/transfer-ketua [get]
+func (ctrl *PengajuanController) GetMyTransfers(c *fiber.Ctx) error {
+	result, err := ctrl.transfers.GetMyTransfers(utils.GetCurrentUsername(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get ketua transfers",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Ketua transfers retrieved successfully",
+		result,
+	))
+}
+
+// AcceptKetua godoc
+// @Summary Accept Ketua Nomination
+// @Description Nominated member becomes ketua. nim_ketua, nama_ketua, biodata ketua, is_ketua and urutan are updated atomically
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Transfer ID"
+// @Param body body request.AcceptKetuaRequest false "Biodata ketua baru"
+// @Success 200 {object} response.APIResponse{data=response.TransferKetuaResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/transfer-ketua/{id}/accept [post]
+func (ctrl *PengajuanController) AcceptKetua(c *fiber.Ctx) error {
+	var req request.AcceptKetuaRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+				"Invalid request body",
+				err.Error(),
+			))
+		}
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			ctrl.formatValidationErrors(err),
+		))
+	}
+
+	return ctrl.respondTransfer(c, true, &req)
+}
+
+// DeclineKetua godoc
+// @Summary Decline Ketua Nomination
+// @Description Nominated member declines; ketua stays unchanged
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} response.APIResponse{data=response.TransferKetuaResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/transfer-ketua/{id}/decline [post]
+func (ctrl *PengajuanController) DeclineKetua(c *fiber.Ctx) error {
+	return ctrl.respondTransfer(c, false, nil)
+}
+
+// CancelTransferKetua godoc
+// @Summary Cancel Ketua Nomination
+// @Description Ketua who nominated (or admin) cancels a nomination that has not been answered
+// @Tags Mahasiswa - Pengajuan PKM
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /pengajuan/transfer-ketua/{id} [delete]
+func (ctrl *PengajuanController) CancelTransferKetua(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid transfer ID",
+			err.Error(),
+		))
+	}
+
+	if err := ctrl.transfers.Cancel(id, ctrl.currentActor(c)); err != nil {
+		return c.Status(transferErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to cancel ketua transfer",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Pengalihan ketua dibatalkan",
+		nil,
+	))
+}
+
 // ========================================
 // HELPER FUNCTIONS
 // ========================================
 
+// currentActor membuat aktor workflow dari user yang login (mahasiswa atau admin)
+func (ctrl *PengajuanController) currentActor(c *fiber.Ctx) workflow.Actor {
+	username := utils.GetCurrentUsername(c)
+	return workflow.Actor{NIM: username, IsAdmin: utils.IsAdmin(c), UserUpdate: username}
+}
+
+// respondTransfer menjalankan accept/decline pengalihan ketua
+func (ctrl *PengajuanController) respondTransfer(c *fiber.Ctx, accept bool, req *request.AcceptKetuaRequest) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid transfer ID",
+			err.Error(),
+		))
+	}
+
+	result, err := ctrl.transfers.Respond(id, utils.GetCurrentUsername(c), accept, req)
+	if err != nil {
+		return c.Status(transferErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to respond ketua transfer",
+			err.Error(),
+		))
+	}
+
+	message := "Anda sekarang menjadi ketua pengajuan"
+	if !accept {
+		message = "Pengalihan ketua ditolak"
+	}
+	return c.JSON(response.SuccessResponse(message, result))
+}
+
+// transferErrorStatus memetakan error pengalihan ketua ke HTTP status
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTransferForbidden):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrTransferNotFound), errors.Is(err, services.ErrPengajuanNotFound):
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
+
+// respondInvitation menjalankan accept/decline undangan untuk mahasiswa yang login
+func (ctrl *PengajuanController) respondInvitation(c *fiber.Ctx, accept bool) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid invitation ID",
+			err.Error(),
+		))
+	}
+
+	result, err := ctrl.invitations.Respond(id, utils.GetCurrentUsername(c), accept)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, services.ErrInvitationNotFound) || errors.Is(err, services.ErrPengajuanNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(response.ErrorResponse(
+			"Failed to respond invitation",
+			err.Error(),
+		))
+	}
+
+	message := "Undangan berhasil diterima"
+	if !accept {
+		message = "Undangan berhasil ditolak"
+	}
+	return c.JSON(response.SuccessResponse(message, result))
+}
+
 // formatValidationErrors formats validator errors to readable format
 func (ctrl *PengajuanController) formatValidationErrors(err error) []response.ValidationErrorResponse {
 	var errors []response.ValidationErrorResponse
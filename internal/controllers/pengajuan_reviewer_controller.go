@@ -14,18 +14,31 @@ import (
 
 // PengajuanReviewerController handles reviewer PKM review endpoints
 type PengajuanReviewerController struct {
-	service   *services.PengajuanService
-	validator *validator.Validate
+	service         *services.PengajuanService
+	identityService *services.ReviewerIdentityService
+	kemiripan       *services.KemiripanService
+	validator       *validator.Validate
 }
 
 // NewPengajuanReviewerController creates a new controller instance
 func NewPengajuanReviewerController() *PengajuanReviewerController {
 	return &PengajuanReviewerController{
-		service:   services.NewPengajuanService(),
-		validator: validator.New(),
+		service:         services.NewPengajuanService(),
+		identityService: services.NewReviewerIdentityService(),
+		kemiripan:       services.NewKemiripanService(),
+		validator:       validator.New(),
 	}
 }
 
+// currentReviewer me-resolve reviewer yang sedang login dari token.
+// Admin tidak memiliki identitas reviewer (nil) dan tetap boleh bertindak atas nama reviewer.
+func (ctrl *PengajuanReviewerController) currentReviewer(c *fiber.Ctx) (*services.ReviewerIdentity, error) {
+	if utils.IsAdmin(c) {
+		return nil, nil
+	}
+	return ctrl.identityService.ResolveFromClaims(utils.GetCurrentClaims(c))
+}
+
 // GetMyAssignments godoc
 // @Summary Get My Assignments (Reviewer)
 // @Description Reviewer gets all pengajuan assigned to them
@@ -40,14 +53,12 @@ func NewPengajuanReviewerController() *PengajuanReviewerController {
 // @Security BearerAuth
 // @Router /reviewer/my-assignments [get]
 func (ctrl *PengajuanReviewerController) GetMyAssignments(c *fiber.Ctx) error {
-	// 1. Get authenticated reviewer (id_pegawai from JWT user_data)
-	userData := utils.GetCurrentUserData(c)
-	idPegawai, _ := strconv.Atoi(userData["id_pegawai"])
-
-	if idPegawai == 0 {
+	// 1. Get authenticated reviewer
+	reviewer, err := ctrl.identityService.ResolveFromClaims(utils.GetCurrentClaims(c))
+	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
-			"Reviewer ID not found in token. Please relogin.",
-			"",
+			"Reviewer not found. Please relogin.",
+			err.Error(),
 		))
 	}
 
@@ -55,7 +66,7 @@ func (ctrl *PengajuanReviewerController) GetMyAssignments(c *fiber.Ctx) error {
 	tipeFilter := c.Query("tipe", "all") // JUDUL, PROPOSAL, or all
 
 	// 3. Call service
-	result, err := ctrl.service.GetMyAssignments(idPegawai, tipeFilter)
+	result, err := ctrl.service.GetMyAssignments(reviewer, tipeFilter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
 			"Failed to get assignments",
@@ -115,19 +126,18 @@ func (ctrl *PengajuanReviewerController) ReviewJudul(c *fiber.Ctx) error {
 	}
 
 	// 4. Get authenticated reviewer and check if admin
-	userData := utils.GetCurrentUserData(c)
-	idPegawai, _ := strconv.Atoi(userData["id_pegawai"])
-	isAdmin := utils.IsAdmin(c)
-
-	if idPegawai == 0 && !isAdmin {
+	reviewer, err := ctrl.currentReviewer(c)
+	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
-			"Reviewer ID not found in token. Please relogin.",
-			"",
+			"Reviewer not found. Please relogin.",
+			err.Error(),
 		))
 	}
+	isAdmin := utils.IsAdmin(c)
+	userID := int(utils.GetCurrentUserID(c))
 
 	// 5. Call service
-	result, err := ctrl.service.ReviewJudul(id, &req, idPegawai, isAdmin)
+	result, err := ctrl.service.ReviewJudul(id, &req, reviewer, userID, isAdmin)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
 			"Failed to submit review",
@@ -187,19 +197,18 @@ func (ctrl *PengajuanReviewerController) ReviewProposal(c *fiber.Ctx) error {
 	}
 
 	// 4. Get authenticated reviewer and check if admin
-	userData := utils.GetCurrentUserData(c)
-	idPegawai, _ := strconv.Atoi(userData["id_pegawai"])
-	isAdmin := utils.IsAdmin(c)
-
-	if idPegawai == 0 && !isAdmin {
+	reviewer, err := ctrl.currentReviewer(c)
+	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
-			"Reviewer ID not found in token. Please relogin.",
-			"",
+			"Reviewer not found. Please relogin.",
+			err.Error(),
 		))
 	}
+	isAdmin := utils.IsAdmin(c)
+	userID := int(utils.GetCurrentUserID(c))
 
 	// 5. Call service
-	result, err := ctrl.service.ReviewProposal(id, &req, idPegawai, isAdmin)
+	result, err := ctrl.service.ReviewProposal(id, &req, reviewer, userID, isAdmin)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
 			"Failed to submit review",
@@ -216,7 +225,7 @@ func (ctrl *PengajuanReviewerController) ReviewProposal(c *fiber.Ctx) error {
 
 // GetPengajuanDetail godoc
 // @Summary Get Pengajuan Detail (Reviewer)
-// @Description Reviewer gets detail of pengajuan assigned to them
+// @Description Reviewer gets detail of pengajuan assigned to them, including similar judul flagged by the similarity check
 // @Tags Reviewer - Pengajuan PKM
 // @Accept json
 // @Produce json
@@ -249,17 +258,21 @@ func (ctrl *PengajuanReviewerController) GetPengajuanDetail(c *fiber.Ctx) error
 	}
 
 	// 3. Verify reviewer has access (assigned to them)
-	userData := utils.GetCurrentUserData(c)
-	idPegawai, _ := strconv.Atoi(userData["id_pegawai"])
-	isAdmin := utils.IsAdmin(c)
+	reviewer, err := ctrl.currentReviewer(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
+			"Reviewer not found. Please relogin.",
+			err.Error(),
+		))
+	}
 
-	// Check if reviewer is assigned to this pengajuan
-	if !isAdmin {
+	// Check if reviewer is assigned to this pengajuan (by id_pegawai)
+	if reviewer != nil {
 		isAssigned := false
-		if result.ReviewerJudul != nil && result.ReviewerJudul.ID == idPegawai {
+		if result.ReviewerJudul != nil && result.ReviewerJudul.ID == reviewer.IDPegawai {
 			isAssigned = true
 		}
-		if result.ReviewerProposal != nil && result.ReviewerProposal.ID == idPegawai {
+		if result.ReviewerProposal != nil && result.ReviewerProposal.ID == reviewer.IDPegawai {
 			isAssigned = true
 		}
 
@@ -271,7 +284,17 @@ func (ctrl *PengajuanReviewerController) GetPengajuanDetail(c *fiber.Ctx) error
 		}
 	}
 
-	// 4. Return success
+	// 4. Judul lain yang terdeteksi mirip
+	kemiripan, err := ctrl.kemiripan.GetMatches(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get kemiripan judul",
+			err.Error(),
+		))
+	}
+	result.KemiripanJudul = kemiripan
+
+	// 5. Return success
 	return c.JSON(response.SuccessResponse(
 		"Pengajuan detail",
 		result,
@@ -304,19 +327,18 @@ func (ctrl *PengajuanReviewerController) CancelReviewJudul(c *fiber.Ctx) error {
 	}
 
 	// 2. Get authenticated user and check if admin
-	userData := utils.GetCurrentUserData(c)
-	idPegawai, _ := strconv.Atoi(userData["id_pegawai"])
-	isAdmin := utils.IsAdmin(c)
-
-	if idPegawai == 0 && !isAdmin {
+	reviewer, err := ctrl.currentReviewer(c)
+	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
-			"Reviewer ID not found in token. Please relogin.",
-			"",
+			"Reviewer not found. Please relogin.",
+			err.Error(),
 		))
 	}
+	isAdmin := utils.IsAdmin(c)
+	userID := int(utils.GetCurrentUserID(c))
 
 	// 3. Call service
-	result, err := ctrl.service.CancelReviewJudul(id, idPegawai, isAdmin)
+	result, err := ctrl.service.CancelReviewJudul(id, reviewer, userID, isAdmin)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
 			"Failed to cancel review",
@@ -357,19 +379,18 @@ func (ctrl *PengajuanReviewerController) CancelReviewProposal(c *fiber.Ctx) erro
 	}
 
 	// 2. Get authenticated user and check if admin
-	userData := utils.GetCurrentUserData(c)
-	idPegawai, _ := strconv.Atoi(userData["id_pegawai"])
-	isAdmin := utils.IsAdmin(c)
-
-	if idPegawai == 0 && !isAdmin {
+	reviewer, err := ctrl.currentReviewer(c)
+	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
-			"Reviewer ID not found in token. Please relogin.",
-			"",
+			"Reviewer not found. Please relogin.",
+			err.Error(),
 		))
 	}
+	isAdmin := utils.IsAdmin(c)
+	userID := int(utils.GetCurrentUserID(c))
 
 	// 3. Call service
-	result, err := ctrl.service.CancelReviewProposal(id, idPegawai, isAdmin)
+	result, err := ctrl.service.CancelReviewProposal(id, reviewer, userID, isAdmin)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
 			"Failed to cancel review",
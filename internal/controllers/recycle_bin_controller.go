@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RecycleBinController handles admin recycle bin endpoints
+type RecycleBinController struct {
+	service *services.RecycleBinService
+}
+
+// NewRecycleBinController creates a new controller instance
+func NewRecycleBinController() *RecycleBinController {
+	return &RecycleBinController{
+		service: services.NewRecycleBinService(),
+	}
+}
+
+// GetAll godoc
+// @Summary Get Recycle Bin
+// @Description Admin lists soft-deleted pengajuan, reviewer, kategori and menu
+// @Tags Admin - Recycle Bin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param tipe query string false "Filter by tipe (pengajuan/reviewer/kategori/menu), empty = all"
+// @Success 200 {object} response.APIResponse{data=[]response.RecycleBinItemResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/recycle-bin [get]
+func (ctrl *RecycleBinController) GetAll(c *fiber.Ctx) error {
+	result, err := ctrl.service.List(c.Query("tipe"))
+	if err != nil {
+		return c.Status(recycleErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to get recycle bin",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Recycle bin retrieved successfully",
+		result,
+	))
+}
+
+// Restore godoc
+// @Summary Restore Deleted Data
+// @Description Admin restores a soft-deleted item (hapus = 0)
+// @Tags Admin - Recycle Bin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param tipe path string true "pengajuan/reviewer/kategori/menu"
+// @Param id path int true "ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/recycle-bin/{tipe}/{id}/restore [post]
+func (ctrl *RecycleBinController) Restore(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid ID",
+			err.Error(),
+		))
+	}
+
+	userUpdate := fmt.Sprintf("%d", utils.GetCurrentUserID(c))
+	if err := ctrl.service.Restore(c.Params("tipe"), id, userUpdate); err != nil {
+		return c.Status(recycleErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to restore data",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Data berhasil dipulihkan",
+		nil,
+	))
+}
+
+// Purge godoc
+// @Summary Permanently Delete Data
+// @Description Admin permanently deletes an item from the recycle bin (pengajuan includes team, reviews, timeline and proposal file)
+// @Tags Admin - Recycle Bin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param tipe path string true "pengajuan/reviewer/kategori/menu"
+// @Param id path int true "ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/recycle-bin/{tipe}/{id} [delete]
+func (ctrl *RecycleBinController) Purge(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid ID",
+			err.Error(),
+		))
+	}
+
+	if err := ctrl.service.Purge(c.Params("tipe"), id); err != nil {
+		return c.Status(recycleErrorStatus(err)).JSON(response.ErrorResponse(
+			"Failed to purge data",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Data berhasil dihapus permanen",
+		nil,
+	))
+}
+
+// recycleErrorStatus memetakan error recycle bin ke HTTP status
+func recycleErrorStatus(err error) int {
+	if errors.Is(err, services.ErrRecycleItemNotFound) {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
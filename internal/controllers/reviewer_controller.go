@@ -234,4 +234,45 @@ func (ctrl *ReviewerController) DeleteReviewer(c *fiber.Ctx) error {
 		"Reviewer berhasil dihapus",
 		nil,
 	))
-}
\ No newline at end of file
+}
+// RevokeTokens godoc
+// @Summary Revoke Reviewer Tokens
+// @Description Admin revokes all access and refresh tokens of a reviewer (e.g. compromised session)
+// @Tags Admin - Reviewer Management
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Reviewer ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/reviewers/{id}/revoke-tokens [post]
+func (ctrl *ReviewerController) RevokeTokens(c *fiber.Ctx) error {
+	// 1. Parse ID from URL
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid reviewer ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Get user ID
+	userID := int(utils.GetCurrentUserID(c))
+
+	// 3. Call service
+	if err := ctrl.service.RevokeTokens(id, userID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to revoke reviewer tokens",
+			err.Error(),
+		))
+	}
+
+	// 4. Return success
+	return c.JSON(response.SuccessResponse(
+		"Semua sesi reviewer berhasil dicabut",
+		nil,
+	))
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionController handles login session (device) management endpoints
+type SessionController struct {
+	service *services.SessionService
+}
+
+// NewSessionController creates a new controller instance
+func NewSessionController() *SessionController {
+	return &SessionController{
+		service: services.NewSessionService(),
+	}
+}
+
+// GetMySessions godoc
+// @Summary Get My Sessions
+// @Description List devices where the current account is logged in
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} response.APIResponse{data=[]response.SessionResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/sessions [get]
+func (ctrl *SessionController) GetMySessions(c *fiber.Ctx) error {
+	claims := utils.GetCurrentClaims(c)
+	if claims == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
+			"Invalid or expired token",
+			nil,
+		))
+	}
+
+	result, err := ctrl.service.GetMySessions(claims)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get sessions",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Sessions retrieved successfully",
+		result,
+	))
+}
+
+// RevokeMySession godoc
+// @Summary Revoke My Session
+// @Description Log out one of the current account's devices
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Session ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/sessions/{id} [delete]
+func (ctrl *SessionController) RevokeMySession(c *fiber.Ctx) error {
+	// 1. Parse ID
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid session ID",
+			err.Error(),
+		))
+	}
+
+	claims := utils.GetCurrentClaims(c)
+	if claims == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(response.ErrorResponse(
+			"Invalid or expired token",
+			nil,
+		))
+	}
+
+	// 2. Call service
+	if err := ctrl.service.RevokeMySession(claims, id); err != nil {
+		return c.Status(statusForSessionError(err)).JSON(response.ErrorResponse(
+			"Failed to revoke session",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Session revoked successfully",
+		nil,
+	))
+}
+
+// GetSessions godoc
+// @Summary Get Sessions (Admin)
+// @Description Admin lists login sessions of any db_user, reviewer or mahasiswa
+// @Tags Admin - Session
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_type query string false "Filter by user type (admin, pegawai, mahasiswa)"
+// @Param id_user query int false "Filter by db_user.id (admin) or db_reviewer.id (pegawai)"
+// @Param identifier query string false "Filter by username, NIM or NIP"
+// @Param active_only query bool false "Only active sessions" default(true)
+// @Success 200 {object} response.APIResponse{data=[]response.SessionResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/sessions [get]
+func (ctrl *SessionController) GetSessions(c *fiber.Ctx) error {
+	filters := map[string]interface{}{
+		"user_type":   c.Query("user_type", ""),
+		"id_user":     c.QueryInt("id_user", 0),
+		"identifier":  c.Query("identifier", ""),
+		"active_only": c.QueryBool("active_only", true),
+	}
+
+	var currentSessionID string
+	if claims := utils.GetCurrentClaims(c); claims != nil {
+		currentSessionID = claims.SessionID
+	}
+
+	result, err := ctrl.service.GetSessions(filters, currentSessionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get sessions",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Sessions retrieved successfully",
+		result,
+	))
+}
+
+// RevokeSession godoc
+// @Summary Revoke Session (Admin)
+// @Description Admin kills any login session. Its access and refresh tokens stop working immediately.
+// @Tags Admin - Session
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Session ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/sessions/{id} [delete]
+func (ctrl *SessionController) RevokeSession(c *fiber.Ctx) error {
+	// 1. Parse ID
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid session ID",
+			err.Error(),
+		))
+	}
+
+	// 2. Call service
+	userUpdate := strconv.Itoa(int(utils.GetCurrentUserID(c)))
+	if err := ctrl.service.RevokeSession(id, userUpdate); err != nil {
+		return c.Status(statusForSessionError(err)).JSON(response.ErrorResponse(
+			"Failed to revoke session",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Session revoked successfully",
+		nil,
+	))
+}
+
+// statusForSessionError memetakan error sesi ke HTTP status
+func statusForSessionError(err error) int {
+	if errors.Is(err, services.ErrSessionNotFound) {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
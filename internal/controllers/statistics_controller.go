@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StatisticsController handles dashboard statistics endpoints
+type StatisticsController struct {
+	service *services.StatisticsService
+}
+
+// NewStatisticsController creates a new controller instance
+func NewStatisticsController() *StatisticsController {
+	return &StatisticsController{
+		service: services.NewStatisticsService(),
+	}
+}
+
+// GetPengajuanStatistics godoc
+// @Summary Get Pengajuan Statistics
+// @Description Count of pengajuan per status, kategori and fakultas. Accessible by admin or an API key with statistics:read scope.
+// @Tags Admin - Statistics
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Bearer token"
+// @Param X-API-Key header string false "API key"
+// @Param tahun query int false "Filter by tahun"
+// @Success 200 {object} response.APIResponse{data=response.PengajuanStatisticsResponse}
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /admin/statistics/pengajuan [get]
+func (ctrl *StatisticsController) GetPengajuanStatistics(c *fiber.Ctx) error {
+	tahun := c.QueryInt("tahun", 0)
+
+	result, err := ctrl.service.GetPengajuanStatistics(tahun)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get statistics",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Statistics retrieved successfully",
+		result,
+	))
+}
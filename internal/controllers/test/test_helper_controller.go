@@ -5,6 +5,7 @@ import (
 	"rires-be/pkg/database"
 	"rires-be/pkg/services"
 	"rires-be/pkg/utils"
+	"rires-be/pkg/workflow"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -93,17 +94,17 @@ func (ctrl *TestHelperController) TestExternalData(c *fiber.Ctx) error {
 
 // TestCodeGenerator godoc
 // @Summary Test Code Generator
-// @Description Test generating kode pengajuan
+// @Description Preview the next kode pengajuan (does not consume the sequence)
 // @Tags Test Helpers
 // @Accept json
 // @Produce json
 // @Param id_kategori query int false "ID Kategori PKM" default(1)
-// @Param tahun query int false "Tahun" default(2026)
+// @Param id_periode query int false "ID periode (tgl_setting), default periode aktif"
 // @Success 200 {object} object{success=bool,message=string,data=object}
 // @Router /test/code-generator [get]
 func (ctrl *TestHelperController) TestCodeGenerator(c *fiber.Ctx) error {
 	idKategori := c.QueryInt("id_kategori", 1)
-	tahun := c.QueryInt("tahun", 2026)
+	idPeriode := c.QueryInt("id_periode", 0)
 
 	// Get kategori
 	var kategori models.KategoriPKM
@@ -114,8 +115,24 @@ func (ctrl *TestHelperController) TestCodeGenerator(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate code
-	code, err := utils.GenerateKodePengajuan(&kategori, tahun)
+	// Get periode (default: periode aktif)
+	var periode models.TglSetting
+	periodeQuery := database.DB.Where("hapus = ?", 0)
+	if idPeriode > 0 {
+		periodeQuery = periodeQuery.Where("id = ?", idPeriode)
+	} else {
+		periodeQuery = periodeQuery.Where("is_active = ? AND status = ?", 1, 1)
+	}
+	if err := periodeQuery.First(&periode).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Periode not found",
+		})
+	}
+
+	// Preview code (nomor urut tidak dipakai)
+	kodeService := services.NewKodePengajuanService()
+	code, err := kodeService.Preview(&kategori, &periode)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
@@ -131,7 +148,9 @@ func (ctrl *TestHelperController) TestCodeGenerator(c *fiber.Ctx) error {
 		"message": "Code Generator Test",
 		"data": fiber.Map{
 			"kategori":       kategori.NamaKategori,
-			"tahun":          tahun,
+			"id_periode":     periode.ID,
+			"tahun":          periode.Tahun(),
+			"template":       kodeService.Template(),
 			"generated_code": code,
 			"is_unique":      isUnique,
 		},
@@ -192,8 +211,8 @@ func (ctrl *TestHelperController) TestStatusValidator(c *fiber.Ctx) error {
 
 	// Test with dummy pengajuan
 	dummyPengajuan := &models.Pengajuan{
-		StatusJudul:    "ACC",
-		StatusProposal: "REVISI",
+		StatusJudul:    workflow.StatusACC,
+		StatusProposal: workflow.StatusRevisi,
 		FileProposal:   "proposal_test.pdf",
 		NIMKetua:       "202110370311503",
 	}
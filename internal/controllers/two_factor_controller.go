@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"errors"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorController handles TOTP enrollment for logged-in admin accounts
+type TwoFactorController struct {
+	service   *services.TwoFactorService
+	validator *validator.Validate
+}
+
+// NewTwoFactorController creates a new controller instance
+func NewTwoFactorController() *TwoFactorController {
+	return &TwoFactorController{
+		service:   services.NewTwoFactorService(),
+		validator: validator.New(),
+	}
+}
+
+// GetStatus godoc
+// @Summary Get Two-Factor Status
+// @Description Admin gets 2FA status of their own account
+// @Tags Authentication - 2FA
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} response.APIResponse{data=response.TwoFactorStatusResponse}
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/2fa [get]
+func (ctrl *TwoFactorController) GetStatus(c *fiber.Ctx) error {
+	result, err := ctrl.service.GetStatus(int(utils.GetCurrentUserID(c)), utils.GetCurrentUserLevel(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse(
+			"Failed to get 2FA status",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"2FA status retrieved successfully",
+		result,
+	))
+}
+
+// Setup godoc
+// @Summary Setup Two-Factor
+// @Description Generate a new TOTP secret and provisioning URI (show as QR code). 2FA is not active until confirmed via /auth/2fa/enable.
+// @Tags Authentication - 2FA
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} response.APIResponse{data=response.TwoFactorSetupResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/2fa/setup [post]
+func (ctrl *TwoFactorController) Setup(c *fiber.Ctx) error {
+	result, err := ctrl.service.Setup(int(utils.GetCurrentUserID(c)), utils.GetCurrentUsername(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Failed to setup 2FA",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Scan the provisioning URI, then confirm with the first code",
+		result,
+	))
+}
+
+// Enable godoc
+// @Summary Enable Two-Factor
+// @Description Confirm the TOTP secret with the first code. Returns recovery codes that are only shown once.
+// @Tags Authentication - 2FA
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body request.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} response.APIResponse{data=response.TwoFactorRecoveryCodesResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/2fa/enable [post]
+func (ctrl *TwoFactorController) Enable(c *fiber.Ctx) error {
+	// 1. Parse & validate request body
+	var req request.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	// 2. Call service
+	codes, err := ctrl.service.Enable(int(utils.GetCurrentUserID(c)), req.Code)
+	if err != nil {
+		return c.Status(statusForTwoFactorError(err)).JSON(response.ErrorResponse(
+			"Failed to enable 2FA",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"2FA enabled. Store the recovery codes now, they will not be shown again",
+		response.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes},
+	))
+}
+
+// Disable godoc
+// @Summary Disable Two-Factor
+// @Description Disable 2FA with password and current TOTP code. Not allowed for levels that require 2FA.
+// @Tags Authentication - 2FA
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body request.TwoFactorDisableRequest true "Password and TOTP code"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/2fa/disable [post]
+func (ctrl *TwoFactorController) Disable(c *fiber.Ctx) error {
+	// 1. Parse & validate request body
+	var req request.TwoFactorDisableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	// 2. Call service
+	if err := ctrl.service.Disable(int(utils.GetCurrentUserID(c)), utils.GetCurrentUserLevel(c), req.Password, req.Code); err != nil {
+		return c.Status(statusForTwoFactorError(err)).JSON(response.ErrorResponse(
+			"Failed to disable 2FA",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"2FA disabled",
+		nil,
+	))
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate Recovery Codes
+// @Description Replace all recovery codes. Old codes stop working immediately.
+// @Tags Authentication - 2FA
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body request.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} response.APIResponse{data=response.TwoFactorRecoveryCodesResponse}
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Security BearerAuth
+// @Router /auth/2fa/recovery-codes [post]
+func (ctrl *TwoFactorController) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	// 1. Parse & validate request body
+	var req request.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Invalid request body",
+			err.Error(),
+		))
+	}
+	if err := ctrl.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response.ErrorResponse(
+			"Validation failed",
+			err.Error(),
+		))
+	}
+
+	// 2. Call service
+	codes, err := ctrl.service.RegenerateRecoveryCodes(int(utils.GetCurrentUserID(c)), req.Code)
+	if err != nil {
+		return c.Status(statusForTwoFactorError(err)).JSON(response.ErrorResponse(
+			"Failed to regenerate recovery codes",
+			err.Error(),
+		))
+	}
+
+	return c.JSON(response.SuccessResponse(
+		"Recovery codes regenerated. Store them now, they will not be shown again",
+		response.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes},
+	))
+}
+
+// statusForTwoFactorError memetakan error 2FA ke HTTP status
+func statusForTwoFactorError(err error) int {
+	if errors.Is(err, services.ErrTwoFactorInvalidCode) {
+		return fiber.StatusUnauthorized
+	}
+	return fiber.StatusBadRequest
+}
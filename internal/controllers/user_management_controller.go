@@ -6,6 +6,7 @@ import (
 	"rires-be/internal/dto/response"
 	"rires-be/internal/models"
 	"rires-be/pkg/database"
+	"rires-be/pkg/services"
 	"rires-be/pkg/utils"
 	"strconv"
 	"time"
@@ -114,6 +115,68 @@ func (ctrl *UserManagementController) GetList(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, "Data retrieved successfully", result)
 }
 
+// GetWeakPasswords godoc
+// @Summary Weak Password Report
+// @Description List accounts whose password is still stored as plain text or MySQL PASSWORD() hash. Hashes are upgraded to bcrypt on the next successful login.
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Success 200 {object} object{success=bool,message=string,data=response.WeakPasswordReportResponse}
+// @Failure 500 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /users/weak-passwords [get]
+func (ctrl *UserManagementController) GetWeakPasswords(c *fiber.Ctx) error {
+	// Semua yang bukan bcrypt
+	var users []models.User
+	if err := database.DB.Preload("Level").
+		Where("hapus = ?", 0).
+		Where("password NOT LIKE ? AND password NOT LIKE ? AND password NOT LIKE ?", "$2a$%", "$2b$%", "$2y$%").
+		Order("id ASC").
+		Find(&users).Error; err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch data")
+	}
+
+	result := response.WeakPasswordReportResponse{
+		Data: []response.WeakPasswordUserResponse{},
+	}
+	for _, user := range users {
+		hashType := utils.DetectPasswordHash(user.Password)
+		if hashType == utils.PasswordHashBcrypt {
+			continue
+		}
+
+		statusText := "Aktif"
+		if user.Status == 2 {
+			statusText = "Tidak Aktif"
+		}
+
+		namaLevel := ""
+		if user.Level != nil {
+			namaLevel = user.Level.NamaLevel
+		}
+
+		result.Data = append(result.Data, response.WeakPasswordUserResponse{
+			ID:         user.ID,
+			NamaUser:   user.NamaUser,
+			Username:   user.Username,
+			LevelUser:  user.LevelUser,
+			NamaLevel:  namaLevel,
+			Status:     user.Status,
+			StatusText: statusText,
+			HashType:   hashType,
+		})
+
+		if hashType == utils.PasswordHashPlain {
+			result.TotalPlain++
+		} else {
+			result.TotalMySQL++
+		}
+	}
+	result.Total = len(result.Data)
+
+	return utils.SuccessResponse(c, "Data retrieved successfully", result)
+}
+
 // GetByID godoc
 // @Summary Get User by ID
 // @Description Get user detail by ID
@@ -201,8 +264,16 @@ func (ctrl *UserManagementController) Create(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Username already exists")
 	}
 
-	// Hash password using MySQL PASSWORD() function
-	hashedPassword := hashMySQLPassword(req.Password)
+	// Validate password policy
+	if err := utils.ValidatePasswordPolicy(req.Password); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	// Hash password using bcrypt
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to hash password")
+	}
 
 	// Create
 	now := time.Now()
@@ -351,8 +422,8 @@ func (ctrl *UserManagementController) ResetPassword(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Invalid request body")
 	}
 
-	if req.NewPassword == "" || len(req.NewPassword) < 6 {
-		return utils.BadRequestResponse(c, "Password must be at least 6 characters")
+	if err := utils.ValidatePasswordPolicy(req.NewPassword); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
 	}
 
 	// Find existing
@@ -362,7 +433,10 @@ func (ctrl *UserManagementController) ResetPassword(c *fiber.Ctx) error {
 	}
 
 	// Hash new password
-	hashedPassword := hashMySQLPassword(req.NewPassword)
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to hash password")
+	}
 
 	// Update password
 	user.Password = hashedPassword
@@ -375,6 +449,178 @@ func (ctrl *UserManagementController) ResetPassword(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, "Password reset successfully", nil)
 }
 
+// RevokeTokens godoc
+// @Summary Revoke User Tokens
+// @Description Revoke all access and refresh tokens of a user, forcing re-login (admin only)
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} object{success=bool,message=string}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 404 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /users/{id}/revoke-tokens [post]
+func (ctrl *UserManagementController) RevokeTokens(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid ID")
+	}
+
+	// Find existing
+	var user models.User
+	if err := database.DB.Where("id = ?", id).First(&user).Error; err != nil {
+		return utils.NotFoundResponse(c, "User not found")
+	}
+
+	subject := utils.TokenSubject("admin", uint(user.ID), user.Username)
+	userUpdate := strconv.Itoa(int(utils.GetCurrentUserID(c)))
+	if err := services.NewTokenService().RevokeSubject(subject, "dicabut oleh admin", userUpdate); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to revoke tokens")
+	}
+
+	return utils.SuccessResponse(c, "All sessions of this user have been revoked", nil)
+}
+
+// ResetTwoFactor godoc
+// @Summary Reset User 2FA
+// @Description Remove 2FA of a user who lost their authenticator and recovery codes (admin only). The user must enroll again on next login if their level requires 2FA.
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} object{success=bool,message=string}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 404 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /users/{id}/reset-2fa [post]
+func (ctrl *UserManagementController) ResetTwoFactor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid ID")
+	}
+
+	// Tidak boleh mereset 2FA sendiri (gunakan /auth/2fa/disable)
+	if uint(id) == utils.GetCurrentUserID(c) {
+		return utils.BadRequestResponse(c, "Use /auth/2fa/disable to change your own 2FA")
+	}
+
+	// Find existing
+	var user models.User
+	if err := database.DB.Where("id = ? AND hapus = ?", id, 0).First(&user).Error; err != nil {
+		return utils.NotFoundResponse(c, "User not found")
+	}
+
+	if err := services.NewTwoFactorService().Reset(user.ID); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to reset 2FA")
+	}
+
+	// Sesi lama ikut dicabut agar login berikutnya melewati enrollment ulang
+	subject := utils.TokenSubject("admin", uint(user.ID), user.Username)
+	userUpdate := strconv.Itoa(int(utils.GetCurrentUserID(c)))
+	if err := services.NewTokenService().RevokeSubject(subject, "2FA direset oleh admin", userUpdate); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to revoke tokens")
+	}
+
+	return utils.SuccessResponse(c, "2FA of this user has been reset", nil)
+}
+
+// GetRoleLinks godoc
+// @Summary List User Role Links
+// @Description Get SSO identities (id_pegawai / NIM) linked to a local account, used for role switching
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} object{success=bool,message=string,data=[]response.UserRoleLinkResponse}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 500 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /users/{id}/role-links [get]
+func (ctrl *UserManagementController) GetRoleLinks(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid ID")
+	}
+
+	links, err := services.NewRoleService().GetLinks(id)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch role links")
+	}
+
+	return utils.SuccessResponse(c, "Role links retrieved successfully", links)
+}
+
+// CreateRoleLink godoc
+// @Summary Link User Role
+// @Description Link a local account to an id_pegawai or NIM so the same person can switch roles without logging in again
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param body body request.CreateUserRoleLinkRequest true "Identity to link"
+// @Success 201 {object} object{success=bool,message=string,data=response.UserRoleLinkResponse}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /users/{id}/role-links [post]
+func (ctrl *UserManagementController) CreateRoleLink(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid ID")
+	}
+
+	var req request.CreateUserRoleLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request body")
+	}
+
+	// Validate required fields
+	if req.Tipe != services.RoleLinkPegawai && req.Tipe != services.RoleLinkMahasiswa {
+		return utils.BadRequestResponse(c, "Tipe must be pegawai or mahasiswa")
+	}
+	if req.Nilai == "" {
+		return utils.BadRequestResponse(c, "Nilai is required")
+	}
+
+	link, err := services.NewRoleService().CreateLink(id, &req, int(utils.GetCurrentUserID(c)))
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	return utils.CreatedResponse(c, "Role link created successfully", link)
+}
+
+// DeleteRoleLink godoc
+// @Summary Unlink User Role
+// @Description Remove a linked SSO identity from a local account
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param link_id path int true "Role link ID"
+// @Success 200 {object} object{success=bool,message=string}
+// @Failure 400 {object} object{success=bool,message=string}
+// @Failure 404 {object} object{success=bool,message=string}
+// @Security BearerAuth
+// @Router /users/{id}/role-links/{link_id} [delete]
+func (ctrl *UserManagementController) DeleteRoleLink(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid ID")
+	}
+
+	linkID, err := strconv.Atoi(c.Params("link_id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid link ID")
+	}
+
+	if err := services.NewRoleService().DeleteLink(id, linkID, int(utils.GetCurrentUserID(c))); err != nil {
+		return utils.NotFoundResponse(c, err.Error())
+	}
+
+	return utils.SuccessResponse(c, "Role link deleted successfully", nil)
+}
+
 // Delete godoc
 // @Summary Delete User
 // @Description Soft delete user
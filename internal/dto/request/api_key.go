@@ -0,0 +1,18 @@
+package request
+
+import "time"
+
+// CreateAPIKeyRequest untuk membuat API key integrasi
+type CreateAPIKeyRequest struct {
+	Nama      string     `json:"nama" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"` // pengajuan:read, reference:read, statistics:read
+	ExpiresAt *time.Time `json:"expires_at"`                       // opsional, kosong = tidak kedaluwarsa
+}
+
+// UpdateAPIKeyRequest untuk mengubah nama / scopes / masa berlaku / status API key
+type UpdateAPIKeyRequest struct {
+	Nama      *string    `json:"nama" validate:"omitempty,max=100"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Status    *int       `json:"status" validate:"omitempty,oneof=0 1"`
+}
@@ -4,10 +4,12 @@ package request
 type CreateKategoriPKMRequest struct {
 	NamaKategori string `json:"nama_kategori" validate:"required,min=3,max=100"`
 	Status       int    `json:"status" validate:"required,oneof=1 2"` // 1=active, 2=inactive
+	Kode         string `json:"kode" validate:"omitempty,max=10"`     // kode singkat untuk kode pengajuan (mis. RE), kosong = dari nama
 }
 
 // UpdateKategoriPKMRequest untuk update kategori PKM
 type UpdateKategoriPKMRequest struct {
-	NamaKategori string `json:"nama_kategori" validate:"required,min=3,max=100"`
-	Status       int    `json:"status" validate:"required,oneof=1 2"`
+	NamaKategori string  `json:"nama_kategori" validate:"required,min=3,max=100"`
+	Status       int     `json:"status" validate:"required,oneof=1 2"`
+	Kode         *string `json:"kode,omitempty" validate:"omitempty,max=10"` // tidak dikirim = tetap, "" = kembali dari nama
 }
\ No newline at end of file
@@ -0,0 +1,8 @@
+package request
+
+// UpdateKodeTemplateRequest untuk mengganti template kode pengajuan.
+// Placeholder: {KODE} kode kategori, {TAHUN} tahun periode, {SEQ:n} nomor urut n digit.
+// Kosong = kembali ke template default konfigurasi.
+type UpdateKodeTemplateRequest struct {
+	Template string `json:"template" validate:"max=100"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// PengesahanPembimbingRequest berisi keputusan dosen pembimbing atas judul tim bimbingannya
+type PengesahanPembimbingRequest struct {
+	Keputusan string `json:"keputusan" validate:"required,oneof=DISETUJUI DITOLAK"`
+	Catatan   string `json:"catatan" validate:"omitempty,max=1000"` // wajib jika DITOLAK
+}
@@ -4,31 +4,39 @@ import "errors"
 
 // CreatePengajuanRequest represents request body for creating PKM title submission
 type CreatePengajuanRequest struct {
-	IDKategori      int                    `json:"id_kategori" validate:"required"`
-	Judul           string                 `json:"judul" validate:"required,min=10,max=500"`
-	NIMKetua        string                 `json:"nim_ketua" validate:"omitempty"` // Optional: for admin to specify ketua NIM
-	NamaKetua       string                 `json:"nama_ketua" validate:"omitempty"`
-	EmailKetua      string                 `json:"email_ketua" validate:"omitempty,email"`
-	NoHPKetua       string                 `json:"no_hp_ketua" validate:"omitempty"`
-	ProgramStudi    string                 `json:"program_studi" validate:"omitempty"`
-	Fakultas        string                 `json:"fakultas" validate:"omitempty"`
-	DosenPembimbing string                 `json:"dosen_pembimbing" validate:"omitempty"`
-	Anggota         []AnggotaRequest       `json:"anggota" validate:"omitempty,max=5,dive"` // Optional, ketua auto-added
-	ParameterData   map[string]interface{} `json:"parameter_data" validate:"omitempty"`     // JSON object for form parameters
+	IDKategori        int                    `json:"id_kategori" validate:"required"`
+	Judul             string                 `json:"judul" validate:"required,min=10,max=500"`
+	NIMKetua          string                 `json:"nim_ketua" validate:"omitempty"` // Optional: for admin to specify ketua NIM
+	NamaKetua         string                 `json:"nama_ketua" validate:"omitempty"`
+	EmailKetua        string                 `json:"email_ketua" validate:"omitempty,email"`
+	NoHPKetua         string                 `json:"no_hp_ketua" validate:"omitempty"`
+	ProgramStudi      string                 `json:"program_studi" validate:"omitempty"`
+	Fakultas          string                 `json:"fakultas" validate:"omitempty"`
+	DosenPembimbing   string                 `json:"dosen_pembimbing" validate:"omitempty"`          // Teks bebas (data lama), diganti nama SIMPEG jika id_dosen_pembimbing diisi
+	IDDosenPembimbing int                    `json:"id_dosen_pembimbing" validate:"omitempty,min=1"` // pegawai.id di SIMPEG, wajib saat submit
+	Anggota           []AnggotaRequest       `json:"anggota" validate:"omitempty,max=5,dive"`        // Optional, ketua auto-added
+	ParameterData     map[string]interface{} `json:"parameter_data" validate:"omitempty"`            // JSON object for form parameters
+	IsDraft           bool                   `json:"is_draft"`                                       // Save as draft: partial data allowed, submit later via /pengajuan/:id/submit
 }
 
 // UpdateJudulRequest represents request body for revising PKM title
 type UpdateJudulRequest struct {
-	IDKategori      int                    `json:"id_kategori" validate:"omitempty"`
-	Judul           string                 `json:"judul" validate:"required,min=10,max=500"`
-	NamaKetua       string                 `json:"nama_ketua" validate:"omitempty"`
-	EmailKetua      string                 `json:"email_ketua" validate:"omitempty,email"`
-	NoHPKetua       string                 `json:"no_hp_ketua" validate:"omitempty"`
-	ProgramStudi    string                 `json:"program_studi" validate:"omitempty"`
-	Fakultas        string                 `json:"fakultas" validate:"omitempty"`
-	DosenPembimbing string                 `json:"dosen_pembimbing" validate:"omitempty"`
-	Anggota         []AnggotaRequest       `json:"anggota" validate:"omitempty,max=5,dive"`
-	ParameterData   map[string]interface{} `json:"parameter_data" validate:"omitempty"`
+	IDKategori        int                    `json:"id_kategori" validate:"omitempty"`
+	Judul             string                 `json:"judul" validate:"required,min=10,max=500"`
+	NamaKetua         string                 `json:"nama_ketua" validate:"omitempty"`
+	EmailKetua        string                 `json:"email_ketua" validate:"omitempty,email"`
+	NoHPKetua         string                 `json:"no_hp_ketua" validate:"omitempty"`
+	ProgramStudi      string                 `json:"program_studi" validate:"omitempty"`
+	Fakultas          string                 `json:"fakultas" validate:"omitempty"`
+	DosenPembimbing   string                 `json:"dosen_pembimbing" validate:"omitempty"`          // Teks bebas (data lama), diganti nama SIMPEG jika id_dosen_pembimbing diisi
+	IDDosenPembimbing int                    `json:"id_dosen_pembimbing" validate:"omitempty,min=1"` // pegawai.id di SIMPEG, wajib saat submit
+	Anggota           []AnggotaRequest       `json:"anggota" validate:"omitempty,max=5,dive"`
+	ParameterData     map[string]interface{} `json:"parameter_data" validate:"omitempty"`
+}
+
+// WithdrawPengajuanRequest represents request body for withdrawing a pengajuan
+type WithdrawPengajuanRequest struct {
+	Alasan string `json:"alasan" validate:"required,min=5,max=500"`
 }
 
 // Validate validates CreatePengajuanRequest
@@ -0,0 +1,15 @@
+package request
+
+// NominateKetuaRequest untuk mengajukan anggota sebagai ketua baru
+type NominateKetuaRequest struct {
+	NIMBaru string `json:"nim_baru" validate:"required"`
+	Alasan  string `json:"alasan" validate:"omitempty,max=500"`
+}
+
+// AcceptKetuaRequest berisi biodata ketua baru; menggantikan biodata ketua lama di pengajuan
+type AcceptKetuaRequest struct {
+	EmailKetua   string `json:"email_ketua" validate:"omitempty,email"`
+	NoHPKetua    string `json:"no_hp_ketua" validate:"omitempty"`
+	ProgramStudi string `json:"program_studi" validate:"omitempty"`
+	Fakultas     string `json:"fakultas" validate:"omitempty"`
+}
@@ -6,6 +6,39 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// RefreshTokenRequest untuk menukar refresh token dengan access token baru
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest untuk logout, refresh_token opsional agar ikut dicabut
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	AllDevices   bool   `json:"all_devices"` // true = cabut semua sesi akun ini
+}
+
+// SwitchRoleRequest untuk berpindah role aktif
+type SwitchRoleRequest struct {
+	Role         string `json:"role" validate:"required,oneof=admin pegawai mahasiswa"`
+	RefreshToken string `json:"refresh_token"` // opsional, refresh token role lama ikut dicabut
+	Code         string `json:"code"`          // kode TOTP, wajib jika pindah ke admin yang memakai 2FA
+}
+
+// CreateUserRoleLinkRequest untuk menghubungkan akun lokal dengan identitas SSO
+type CreateUserRoleLinkRequest struct {
+	Tipe  string `json:"tipe" validate:"required,oneof=pegawai mahasiswa"`
+	Nilai string `json:"nilai" validate:"required,max=50"` // id_pegawai atau NIM
+}
+
+// ImpersonateRequest untuk admin melihat aplikasi sebagai mahasiswa / reviewer
+type ImpersonateRequest struct {
+	UserType    string `json:"user_type" validate:"required,oneof=mahasiswa pegawai"`
+	NIM         string `json:"nim"`         // wajib jika user_type = mahasiswa
+	IDReviewer  int    `json:"id_reviewer"` // wajib jika user_type = pegawai
+	Alasan      string `json:"alasan" validate:"required,max=255"`
+	DurasiMenit int    `json:"durasi_menit"` // opsional, dibatasi IMPERSONATION_MAX_MINUTES
+}
+
 // CreateUserRequest untuk create user
 type CreateUserRequest struct {
 	NamaUser  string `json:"nama_user" validate:"required,min=3,max:100"`
@@ -26,10 +59,33 @@ type UpdateUserRequest struct {
 // ChangePasswordRequest untuk ubah password user
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
-	NewPassword string `json:"new_password" validate:"required,min=6"`
+	NewPassword string `json:"new_password" validate:"required"` // Divalidasi dengan password policy
 }
 
 // ResetPasswordRequest untuk reset password (admin only)
 type ResetPasswordRequest struct {
-	NewPassword string `json:"new_password" validate:"required,min=6"`
-}
\ No newline at end of file
+	NewPassword string `json:"new_password" validate:"required"` // Divalidasi dengan password policy
+}
+
+// TwoFactorVerifyRequest untuk menyelesaikan login admin dengan faktor kedua
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`          // kode TOTP 6 digit
+	RecoveryCode   string `json:"recovery_code"` // alternatif jika authenticator hilang
+}
+
+// TwoFactorEnrollRequest untuk enrollment 2FA saat login (level yang wajib 2FA)
+type TwoFactorEnrollRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+// TwoFactorCodeRequest untuk aksi yang dikonfirmasi dengan kode TOTP
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+// TwoFactorDisableRequest untuk menonaktifkan 2FA akun sendiri
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6"`
+}
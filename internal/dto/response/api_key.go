@@ -0,0 +1,24 @@
+package response
+
+import "time"
+
+// APIKeyResponse untuk daftar / detail API key (tanpa key mentah)
+type APIKeyResponse struct {
+	ID         int        `json:"id"`
+	Nama       string     `json:"nama"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	Status     int        `json:"status"`
+	IsUsable   bool       `json:"is_usable"`
+	TglInsert  *time.Time `json:"tgl_insert"`
+	UserUpdate string     `json:"user_update"`
+}
+
+// APIKeyCreatedResponse dikembalikan sekali saat key dibuat, key mentah tidak bisa dilihat lagi
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
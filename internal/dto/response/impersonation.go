@@ -0,0 +1,31 @@
+package response
+
+import "time"
+
+// ImpersonationResponse token impersonation (read-only, tanpa refresh token)
+type ImpersonationResponse struct {
+	Token     string      `json:"token"`
+	UserType  string      `json:"user_type"` // mahasiswa, pegawai
+	User      interface{} `json:"user"`
+	ExpiresIn int         `json:"expires_in"` // dalam detik
+	ExpiresAt time.Time   `json:"expires_at"`
+	IDLog     int         `json:"id_log"`
+}
+
+// ImpersonationLogResponse untuk audit trail impersonation
+type ImpersonationLogResponse struct {
+	ID             int        `json:"id"`
+	IDAdmin        int        `json:"id_admin"`
+	UsernameAdmin  string     `json:"username_admin"`
+	TargetType     string     `json:"target_type"`
+	TargetID       string     `json:"target_id"`
+	TargetNama     string     `json:"target_nama"`
+	Alasan         string     `json:"alasan"`
+	IPAddress      string     `json:"ip_address"`
+	UserAgent      string     `json:"user_agent"`
+	MulaiAt        time.Time  `json:"mulai_at"`
+	BerakhirAt     time.Time  `json:"berakhir_at"`
+	SelesaiAt      *time.Time `json:"selesai_at"`
+	JumlahDiblokir int        `json:"jumlah_diblokir"`
+	IsActive       bool       `json:"is_active"`
+}
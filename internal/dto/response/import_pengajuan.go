@@ -0,0 +1,22 @@
+package response
+
+// ImportPengajuanResponse adalah hasil import pengajuan dari file CSV/XLSX
+type ImportPengajuanResponse struct {
+	Mode       string                       `json:"mode"`        // dry-run | commit
+	TotalBaris int                          `json:"total_baris"` // baris data, tanpa header & baris kosong
+	Valid      int                          `json:"valid"`
+	Invalid    int                          `json:"invalid"`
+	Imported   int                          `json:"imported"` // 0 pada dry-run
+	Rows       []ImportPengajuanRowResponse `json:"rows"`
+}
+
+// ImportPengajuanRowResponse adalah hasil validasi satu baris file
+type ImportPengajuanRowResponse struct {
+	Baris         int      `json:"baris"` // nomor baris di file, header = baris 1
+	Judul         string   `json:"judul"`
+	NIMKetua      string   `json:"nim_ketua"`
+	Valid         bool     `json:"valid"`
+	Errors        []string `json:"errors,omitempty"`
+	IDPengajuan   int      `json:"id_pengajuan,omitempty"`   // terisi setelah commit
+	KodePengajuan string   `json:"kode_pengajuan,omitempty"` // terisi setelah commit
+}
@@ -6,6 +6,7 @@ import "time"
 type KategoriPKMResponse struct {
 	ID           int        `json:"id"`
 	NamaKategori string     `json:"nama_kategori"`
+	Kode         string     `json:"kode"` // kode singkat di kode pengajuan (isian admin atau turunan nama)
 	Status       int        `json:"status"`
 	StatusText   string     `json:"status_text"` // "Aktif" atau "Tidak Aktif"
 	TglInsert    *time.Time `json:"tgl_insert"`
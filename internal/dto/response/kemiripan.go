@@ -0,0 +1,27 @@
+package response
+
+// KemiripanResponse adalah judul lain yang mirip dengan judul pengajuan
+type KemiripanResponse struct {
+	IDPengajuan   int     `json:"id_pengajuan"`
+	KodePengajuan string  `json:"kode_pengajuan"`
+	Judul         string  `json:"judul"`
+	Tahun         int     `json:"tahun"`
+	Skor          float64 `json:"skor"` // 0-1, semakin tinggi semakin mirip
+}
+
+// KemiripanClusterResponse adalah sekelompok judul satu periode yang saling mirip
+type KemiripanClusterResponse struct {
+	SkorMaks  float64                `json:"skor_maks"`
+	Pengajuan []KemiripanClusterItem `json:"pengajuan"`
+}
+
+// KemiripanClusterItem adalah satu pengajuan dalam kelompok judul mirip
+type KemiripanClusterItem struct {
+	IDPengajuan   int    `json:"id_pengajuan"`
+	KodePengajuan string `json:"kode_pengajuan"`
+	Judul         string `json:"judul"`
+	NamaKetua     string `json:"nama_ketua"`
+	NIMKetua      string `json:"nim_ketua"`
+	NamaKategori  string `json:"nama_kategori"`
+	StatusJudul   string `json:"status_judul"`
+}
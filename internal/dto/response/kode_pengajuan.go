@@ -0,0 +1,8 @@
+package response
+
+// KodeTemplateResponse adalah template kode pengajuan yang berlaku
+type KodeTemplateResponse struct {
+	Template        string `json:"template"`         // template aktif (pengaturan admin atau default)
+	TemplateDefault string `json:"template_default"` // KODE_PENGAJUAN_TEMPLATE dari konfigurasi
+	Contoh          string `json:"contoh"`           // contoh hasil: kategori RE, tahun 2026, nomor urut 1
+}
@@ -0,0 +1,15 @@
+package response
+
+import "time"
+
+// LoginAttemptResponse untuk daftar counter login gagal / lockout
+type LoginAttemptResponse struct {
+	ID            int        `json:"id"`
+	Tipe          string     `json:"tipe"`  // username, ip
+	Nilai         string     `json:"nilai"` // username atau alamat IP
+	JumlahGagal   int        `json:"jumlah_gagal"`
+	TerakhirGagal *time.Time `json:"terakhir_gagal"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	IsLocked      bool       `json:"is_locked"`
+	SisaDetik     int        `json:"sisa_detik,omitempty"` // sisa waktu lockout
+}
@@ -0,0 +1,31 @@
+package response
+
+import "time"
+
+// PembimbingResponse berisi dosen pembimbing pengajuan beserta status pengesahannya
+type PembimbingResponse struct {
+	IDPegawai         int        `json:"id_pegawai"`
+	NamaPegawai       string     `json:"nama_pegawai"`
+	StatusPengesahan  string     `json:"status_pengesahan"` // PENDING, DISETUJUI, DITOLAK
+	CatatanPengesahan string     `json:"catatan_pengesahan,omitempty"`
+	TglPengesahan     *time.Time `json:"tgl_pengesahan,omitempty"`
+}
+
+// TimBimbinganResponse untuk daftar tim yang dibimbing seorang dosen
+type TimBimbinganResponse struct {
+	IDPengajuan       int               `json:"id_pengajuan"`
+	KodePengajuan     string            `json:"kode_pengajuan"`
+	Judul             string            `json:"judul"`
+	Tahun             int               `json:"tahun"`
+	NamaKategori      string            `json:"nama_kategori"`
+	NamaKetua         string            `json:"nama_ketua"`
+	NIMKetua          string            `json:"nim_ketua"`
+	Anggota           []AnggotaResponse `json:"anggota"`
+	StatusJudul       string            `json:"status_judul"`
+	StatusProposal    string            `json:"status_proposal"`
+	StatusFinal       string            `json:"status_final"`
+	StatusPengesahan  string            `json:"status_pengesahan"`
+	CatatanPengesahan string            `json:"catatan_pengesahan,omitempty"`
+	TglPengesahan     *time.Time        `json:"tgl_pengesahan,omitempty"`
+	TglPengajuan      *time.Time        `json:"tgl_pengajuan"`
+}
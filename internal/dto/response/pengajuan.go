@@ -8,6 +8,7 @@ type PengajuanResponse struct {
 	KodePengajuan string `json:"kode_pengajuan"`
 	Judul         string `json:"judul"`
 	Tahun         int    `json:"tahun"`
+	IDPeriode     int    `json:"id_periode,omitempty"` // db_tgl_setting.id saat pengajuan dibuat
 
 	// Biodata Ketua
 	NamaKetua       string     `json:"nama_ketua"`
@@ -19,11 +20,20 @@ type PengajuanResponse struct {
 	DosenPembimbing string     `json:"dosen_pembimbing"`
 	TglPengajuan    *time.Time `json:"tgl_pengajuan"`
 
+	// Dosen pembimbing dari SIMPEG (kosong untuk data lama yang hanya berisi teks)
+	Pembimbing *PembimbingResponse `json:"pembimbing,omitempty"`
+
+	// Judul lain yang mirip (peringatan saat simpan judul & detail reviewer)
+	KemiripanJudul []KemiripanResponse `json:"kemiripan_judul,omitempty"`
+
 	// Status
 	StatusJudul    string `json:"status_judul"`
 	StatusProposal string `json:"status_proposal"`
 	StatusFinal    string `json:"status_final"`
 
+	// Peran mahasiswa yang login (ketua/anggota), kosong untuk admin
+	Peran string `json:"peran,omitempty"`
+
 	// Kategori
 	IDKategori   int               `json:"id_kategori"` // Flat field for easy access
 	Kategori     *KategoriResponse `json:"kategori,omitempty"`
@@ -75,6 +85,7 @@ type PengajuanListResponse struct {
 	Ketua          *MahasiswaResponse `json:"ketua,omitempty"`
 	JumlahAnggota  int                `json:"jumlah_anggota"`
 	TglInsert      *time.Time         `json:"tgl_insert"`
+	Peran          string             `json:"peran,omitempty"` // ketua/anggota (my-submissions)
 
 	// Flat fields for admin list view
 	NIMKetua        string     `json:"nim_ketua,omitempty"`
@@ -113,4 +124,6 @@ type AnggotaResponse struct {
 	NamaAnggota string `json:"nama_anggota"`
 	IsKetua     int    `json:"is_ketua"`
 	Urutan      int    `json:"urutan"`
+
+	StatusUndangan string `json:"status_undangan,omitempty"` // PENDING / ACCEPTED (kosong untuk ketua & data lama)
 }
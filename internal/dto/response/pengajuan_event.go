@@ -0,0 +1,18 @@
+package response
+
+import "time"
+
+// PengajuanEventResponse untuk satu baris timeline pengajuan
+type PengajuanEventResponse struct {
+	ID         int                    `json:"id"`
+	Aksi       string                 `json:"aksi"`
+	Tahap      string                 `json:"tahap,omitempty"`
+	StatusLama string                 `json:"status_lama,omitempty"`
+	StatusBaru string                 `json:"status_baru,omitempty"`
+	DataLama   map[string]interface{} `json:"data_lama,omitempty"`
+	DataBaru   map[string]interface{} `json:"data_baru,omitempty"`
+	Catatan    string                 `json:"catatan,omitempty"`
+	ActorType  string                 `json:"actor_type"` // admin, mahasiswa, pegawai
+	ActorID    string                 `json:"actor_id"`
+	TglInsert  time.Time              `json:"tgl_insert"`
+}
@@ -0,0 +1,13 @@
+package response
+
+import "time"
+
+// RecycleBinItemResponse untuk satu data yang di-soft delete
+type RecycleBinItemResponse struct {
+	Tipe       string    `json:"tipe"` // pengajuan, reviewer, kategori, menu
+	ID         int       `json:"id"`
+	Nama       string    `json:"nama"`
+	Keterangan string    `json:"keterangan,omitempty"`
+	TglHapus   time.Time `json:"tgl_hapus"` // tgl_update terakhir (saat dihapus)
+	UserUpdate string    `json:"user_update"`
+}
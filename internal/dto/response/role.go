@@ -0,0 +1,22 @@
+package response
+
+import "time"
+
+// RoleOption adalah satu role yang bisa dipakai oleh identitas yang sedang login
+type RoleOption struct {
+	Role        string `json:"role"` // admin, pegawai, mahasiswa
+	IDUserLevel int    `json:"id_user_level"`
+	Label       string `json:"label"`     // nama level (mis. Admin Fakultas, Reviewer)
+	IsActive    bool   `json:"is_active"` // role yang dipakai token saat ini
+}
+
+// UserRoleLinkResponse untuk daftar identitas SSO yang terhubung ke akun lokal
+type UserRoleLinkResponse struct {
+	ID         int        `json:"id"`
+	IDUser     int        `json:"id_user"`
+	Tipe       string     `json:"tipe"`  // pegawai, mahasiswa
+	Nilai      string     `json:"nilai"` // id_pegawai atau NIM
+	Nama       string     `json:"nama,omitempty"`
+	TglInsert  *time.Time `json:"tgl_insert"`
+	UserUpdate string     `json:"user_update"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "time"
+
+// SessionResponse untuk daftar sesi login (perangkat) sebuah akun
+type SessionResponse struct {
+	ID         int        `json:"id"`
+	UserType   string     `json:"user_type"`
+	IDUser     uint       `json:"id_user"`
+	Identifier string     `json:"identifier"` // username, NIM atau NIP
+	Nama       string     `json:"nama"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	IssuedAt   time.Time  `json:"issued_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Alasan     string     `json:"alasan,omitempty"`
+	IsActive   bool       `json:"is_active"`
+	IsCurrent  bool       `json:"is_current"` // sesi token yang sedang dipakai
+}
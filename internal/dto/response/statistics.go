@@ -0,0 +1,31 @@
+package response
+
+// StatusCount jumlah pengajuan per status
+type StatusCount struct {
+	Status string `json:"status"`
+	Jumlah int64  `json:"jumlah"`
+}
+
+// KategoriCount jumlah pengajuan per kategori PKM
+type KategoriCount struct {
+	IDKategori   int    `json:"id_kategori"`
+	NamaKategori string `json:"nama_kategori"`
+	Jumlah       int64  `json:"jumlah"`
+}
+
+// FakultasCount jumlah pengajuan per fakultas ketua
+type FakultasCount struct {
+	Fakultas string `json:"fakultas"`
+	Jumlah   int64  `json:"jumlah"`
+}
+
+// PengajuanStatisticsResponse rekap pengajuan untuk dashboard
+type PengajuanStatisticsResponse struct {
+	Tahun             int             `json:"tahun,omitempty"` // 0 = semua tahun
+	Total             int64           `json:"total"`
+	PerStatusJudul    []StatusCount   `json:"per_status_judul"`
+	PerStatusProposal []StatusCount   `json:"per_status_proposal"`
+	PerStatusFinal    []StatusCount   `json:"per_status_final"`
+	PerKategori       []KategoriCount `json:"per_kategori"`
+	PerFakultas       []FakultasCount `json:"per_fakultas"`
+}
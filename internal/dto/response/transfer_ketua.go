@@ -0,0 +1,18 @@
+package response
+
+import "time"
+
+// TransferKetuaResponse untuk pengajuan pengalihan ketua
+type TransferKetuaResponse struct {
+	ID             int        `json:"id"`
+	IDPengajuan    int        `json:"id_pengajuan"`
+	KodePengajuan  string     `json:"kode_pengajuan"`
+	Judul          string     `json:"judul"`
+	NIMLama        string     `json:"nim_lama"`
+	NIMBaru        string     `json:"nim_baru"`
+	Alasan         string     `json:"alasan,omitempty"`
+	StatusTransfer string     `json:"status_transfer"` // PENDING, ACCEPTED, DECLINED, CANCELLED
+	DiajukanOleh   string     `json:"diajukan_oleh"`
+	RespondedAt    *time.Time `json:"responded_at"`
+	TglInsert      *time.Time `json:"tgl_insert"`
+}
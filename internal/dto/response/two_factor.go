@@ -0,0 +1,32 @@
+package response
+
+import "time"
+
+// TwoFactorChallengeResponse dikembalikan login admin yang masih butuh faktor kedua
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`     // dalam detik
+	SetupRequired     bool   `json:"setup_required"` // true jika level wajib 2FA tetapi belum enrollment
+}
+
+// TwoFactorSetupResponse secret TOTP untuk di-scan aplikasi authenticator
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://, tampilkan sebagai QR code
+	Issuer          string `json:"issuer"`
+	Account         string `json:"account"`
+}
+
+// TwoFactorStatusResponse status 2FA akun yang sedang login
+type TwoFactorStatusResponse struct {
+	Enabled           bool       `json:"enabled"`
+	Required          bool       `json:"required"` // diwajibkan oleh level user
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// TwoFactorRecoveryCodesResponse kode pemulihan, hanya ditampilkan sekali
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,17 @@
+package response
+
+import "time"
+
+// InvitationResponse untuk undangan anggota tim yang diterima mahasiswa
+type InvitationResponse struct {
+	ID             int        `json:"id"`
+	IDPengajuan    int        `json:"id_pengajuan"`
+	KodePengajuan  string     `json:"kode_pengajuan"`
+	Judul          string     `json:"judul"`
+	NamaKetua      string     `json:"nama_ketua"`
+	NIMKetua       string     `json:"nim_ketua"`
+	StatusUndangan string     `json:"status_undangan"` // PENDING, ACCEPTED, DECLINED, EXPIRED, CANCELLED
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RespondedAt    *time.Time `json:"responded_at"`
+	TglInsert      *time.Time `json:"tgl_insert"`
+}
@@ -2,10 +2,24 @@ package response
 
 // LoginResponse adalah struktur untuk response login
 type LoginResponse struct {
-	Token     string      `json:"token"`
-	UserType  string      `json:"user_type"` // admin, mahasiswa, pegawai
-	User      interface{} `json:"user"`
-	ExpiresIn int         `json:"expires_in"` // dalam jam
+	Token            string       `json:"token"`
+	RefreshToken     string       `json:"refresh_token"`
+	UserType         string       `json:"user_type"` // admin, mahasiswa, pegawai
+	User             interface{}  `json:"user"`
+	ExpiresIn        int          `json:"expires_in"`         // umur access token, dalam detik
+	RefreshExpiresIn int          `json:"refresh_expires_in"` // umur refresh token, dalam detik
+	ActiveRole       string       `json:"active_role"`
+	Roles            []RoleOption `json:"roles"`                    // role lain yang bisa dipilih lewat /auth/switch-role
+	RecoveryCodes    []string     `json:"recovery_codes,omitempty"` // hanya saat 2FA diaktifkan lewat login
+}
+
+// TokenResponse adalah struktur untuk response refresh token
+type TokenResponse struct {
+	Token            string `json:"token"`
+	RefreshToken     string `json:"refresh_token"`
+	UserType         string `json:"user_type"`
+	ExpiresIn        int    `json:"expires_in"`         // dalam detik
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // dalam detik
 }
 
 // AdminLoginResponse data admin setelah login
@@ -24,4 +24,23 @@ type UserListResponse struct {
 	Page       int            `json:"page"`
 	PerPage    int            `json:"per_page"`
 	TotalPages int            `json:"total_pages"`
-}
\ No newline at end of file
+}
+// WeakPasswordUserResponse untuk laporan akun yang password-nya belum bcrypt
+type WeakPasswordUserResponse struct {
+	ID         int    `json:"id"`
+	NamaUser   string `json:"nama_user"`
+	Username   string `json:"username"`
+	LevelUser  int    `json:"level_user"`
+	NamaLevel  string `json:"nama_level,omitempty"`
+	Status     int    `json:"status"`
+	StatusText string `json:"status_text"`
+	HashType   string `json:"hash_type"` // mysql_sha1 atau plain
+}
+
+// WeakPasswordReportResponse untuk ringkasan laporan password lemah
+type WeakPasswordReportResponse struct {
+	Data       []WeakPasswordUserResponse `json:"data"`
+	Total      int                        `json:"total"`
+	TotalPlain int                        `json:"total_plain"`
+	TotalMySQL int                        `json:"total_mysql_sha1"`
+}
@@ -1,15 +1,29 @@
 package middleware
 
 import (
+	"errors"
+	"log"
 	"strings"
 
+	"rires-be/pkg/services"
 	"rires-be/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// APIKeyHeader adalah header untuk API key integrasi
+const APIKeyHeader = "X-API-Key"
+
+// UserTypeAPIKey adalah user_type di context untuk request dengan API key
+const UserTypeAPIKey = "api_key"
+
 // JWTAuth adalah middleware untuk validasi JWT token
 func JWTAuth() fiber.Handler {
+	tokenService := services.NewTokenService()
+	impersonationService := services.NewImpersonationService()
+	apiKeyService := services.NewAPIKeyService()
+	sessionService := services.NewSessionService()
+
 	return func(c *fiber.Ctx) error {
 		path := c.Path()
 
@@ -17,6 +31,9 @@ func JWTAuth() fiber.Handler {
 		// BYPASS PUBLIC ROUTES
 		// ==================================
 		if strings.HasPrefix(path, "/api/v1/auth/login") ||
+			strings.HasPrefix(path, "/api/v1/auth/refresh") ||
+			path == "/api/v1/auth/2fa/verify" ||
+			path == "/api/v1/auth/2fa/enroll" ||
 			strings.HasPrefix(path, "/swagger") ||
 			path == "/" ||
 			path == "/health" {
@@ -25,6 +42,12 @@ func JWTAuth() fiber.Handler {
 
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
+
+		// Integrasi machine-to-machine memakai X-API-Key sebagai ganti Bearer token
+		if rawKey := c.Get(APIKeyHeader); rawKey != "" && authHeader == "" {
+			return apiKeyAuth(c, apiKeyService, rawKey)
+		}
+
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"success": false,
@@ -51,6 +74,54 @@ func JWTAuth() fiber.Handler {
 			})
 		}
 
+		// Check revocation (logout, revoke by admin)
+		revoked, err := tokenService.IsRevoked(claims)
+		if err != nil {
+			log.Printf("[JWTAuth] Failed to check token revocation: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"success": false,
+				"message": "Unable to verify token",
+			})
+		}
+		if revoked {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Token has been revoked",
+			})
+		}
+
+		// Check sesi login (perangkat yang di-logout dari daftar sesi)
+		if claims.SessionID != "" {
+			active, err := sessionService.IsActive(claims.SessionID)
+			if err != nil {
+				log.Printf("[JWTAuth] Failed to check session: %v", err)
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"success": false,
+					"message": "Unable to verify session",
+				})
+			}
+			if !active {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"success": false,
+					"message": "Session has been revoked",
+				})
+			}
+		}
+
+		// Token impersonation hanya boleh membaca (kecuali menghentikan sesi / logout)
+		if claims.Impersonator != nil && !isReadOnlyRequest(c) &&
+			path != "/api/v1/auth/impersonation/stop" &&
+			path != "/api/v1/auth/logout" {
+			log.Printf("[JWTAuth] Blocked %s %s while admin %s impersonates %s", c.Method(), path, claims.Impersonator.Username, claims.Subject)
+			if err := impersonationService.RecordBlocked(claims.Impersonator.IDLog); err != nil {
+				log.Printf("[JWTAuth] Failed to record blocked impersonation action: %v", err)
+			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"message": "Write actions are disabled while impersonating",
+			})
+		}
+
 		// Set user info to context
 		c.Locals("id_user", claims.UserID)
 		c.Locals("username", claims.Username)
@@ -59,17 +130,60 @@ func JWTAuth() fiber.Handler {
 		c.Locals("id_user_level", claims.IDUserLevel)
 		c.Locals("user_data", claims.UserData)
 		c.Locals("claims", claims)
+		if claims.Impersonator != nil {
+			c.Locals("impersonator", claims.Impersonator)
+		}
 
 		// Continue to next handler
 		return c.Next()
 	}
 }
 
-// RequireAdmin adalah middleware untuk memastikan user adalah admin
+// apiKeyAuth memvalidasi X-API-Key. API key hanya boleh membaca dan hanya
+// diterima oleh route yang memasang RequireScope, guard role lain menolaknya.
+func apiKeyAuth(c *fiber.Ctx, apiKeyService *services.APIKeyService, rawKey string) error {
+	key, err := apiKeyService.Authenticate(rawKey, c.IP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Invalid or expired API key",
+			})
+		}
+		log.Printf("[JWTAuth] Failed to verify API key: %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"success": false,
+			"message": "Unable to verify API key",
+		})
+	}
+
+	if !isReadOnlyRequest(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"message": "API keys are read-only",
+		})
+	}
+
+	c.Locals("username", key.Nama)
+	c.Locals("user_type", UserTypeAPIKey)
+	c.Locals("api_key", key)
+
+	return c.Next()
+}
+
+// isReadOnlyRequest memeriksa apakah request tidak mengubah data
+func isReadOnlyRequest(c *fiber.Ctx) bool {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// RequireAdmin adalah middleware untuk memastikan role aktif user adalah admin
 func RequireAdmin() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userType := c.Locals("user_type")
-		if userType != "admin" {
+		if utils.GetActiveRole(c) != "admin" {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success": false,
 				"message": "Access denied. Admin only.",
@@ -82,7 +196,7 @@ func RequireAdmin() fiber.Handler {
 // RequireMahasiswa adalah middleware untuk memastikan user adalah mahasiswa atau admin
 func RequireMahasiswa() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userType := c.Locals("user_type")
+		userType := utils.GetActiveRole(c)
 		// Admin dapat mengakses semua endpoint
 		if userType == "admin" {
 			return c.Next()
@@ -100,12 +214,13 @@ func RequireMahasiswa() fiber.Handler {
 // RequireReviewer adalah middleware untuk memastikan user adalah reviewer (pegawai) atau admin
 func RequireReviewer() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userType := c.Locals("user_type")
+		userType := utils.GetActiveRole(c)
 		// Admin dapat mengakses semua endpoint
 		if userType == "admin" {
 			return c.Next()
 		}
-		if userType != "pegawai" {
+		// Login dosen pembimbing (bukan reviewer) juga ber-role pegawai, hanya boleh lewat RequirePembimbing
+		if userType != "pegawai" || utils.IsPembimbingOnly(c) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success": false,
 				"message": "Access denied. Reviewer only.",
@@ -115,11 +230,25 @@ func RequireReviewer() fiber.Handler {
 	}
 }
 
+// RequirePembimbing adalah middleware untuk dosen pembimbing (role pegawai, reviewer atau bukan).
+// Satu-satunya guard pegawai yang menerima token pembimbing saja (IsPembimbingOnly).
+func RequirePembimbing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if utils.GetActiveRole(c) != "pegawai" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"message": "Access denied. Dosen pembimbing only.",
+			})
+		}
+		return c.Next()
+	}
+}
+
 // RequireAdminOrReviewer untuk route yang bisa diakses admin atau reviewer
 func RequireAdminOrReviewer() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userType := c.Locals("user_type")
-		if userType != "admin" && userType != "pegawai" {
+		userType := utils.GetActiveRole(c)
+		if (userType != "admin" && userType != "pegawai") || utils.IsPembimbingOnly(c) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success": false,
 				"message": "Access denied. Admin or Reviewer only.",
@@ -128,3 +257,37 @@ func RequireAdminOrReviewer() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// RequireScope membuka route untuk API key yang memiliki scope tertentu.
+// Request dengan JWT diteruskan ke userGuard (mis. RequireAdmin()) jika diberikan,
+// tanpa userGuard semua user login boleh mengakses.
+func RequireScope(scope string, userGuard ...fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key := utils.GetAPIKey(c); key != nil {
+			if !key.HasScope(scope) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"success": false,
+					"message": "Access denied. API key requires scope " + scope + ".",
+				})
+			}
+			return c.Next()
+		}
+		if len(userGuard) > 0 {
+			return userGuard[0](c)
+		}
+		return c.Next()
+	}
+}
+
+// RequireUser menolak API key di route yang khusus untuk user login
+func RequireUser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if utils.IsAPIKey(c) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"message": "Access denied. This endpoint requires a user login.",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey represents db_api_key table
+// Key untuk integrasi antar sistem (dashboard fakultas, data warehouse).
+// Key mentah hanya ditampilkan sekali saat dibuat, yang disimpan hanya hash-nya.
+type APIKey struct {
+	ID         int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Nama       string     `gorm:"column:nama;type:varchar(100)" json:"nama"`
+	Prefix     string     `gorm:"column:prefix;type:varchar(20);uniqueIndex" json:"prefix"` // bagian awal key untuk identifikasi
+	KeyHash    string     `gorm:"column:key_hash;type:varchar(64)" json:"-"`                // SHA-256 dari key mentah
+	Scopes     string     `gorm:"column:scopes;type:text" json:"scopes"`                    // dipisah koma, mis. pengajuan:read,reference:read
+	ExpiresAt  *time.Time `gorm:"column:expires_at;type:datetime" json:"expires_at"`        // nil = tidak kedaluwarsa
+	LastUsedAt *time.Time `gorm:"column:last_used_at;type:datetime" json:"last_used_at"`
+	LastUsedIP string     `gorm:"column:last_used_ip;type:varchar(45)" json:"last_used_ip"`
+	Status     int        `gorm:"column:status;type:int(1);default:1" json:"status"`
+	Hapus      int        `gorm:"column:hapus;type:int(1);default:0" json:"-"`
+	TglInsert  *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate  time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for APIKey model
+func (APIKey) TableName() string {
+	return "db_api_key"
+}
+
+// ScopeList mengembalikan scopes sebagai slice
+func (k *APIKey) ScopeList() []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(k.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// HasScope checks if key has the given scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable checks if key is active and not expired
+func (k *APIKey) IsUsable() bool {
+	if k.Status != 1 || k.Hapus != 0 {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AppSetting represents db_app_setting table
+// Pengaturan aplikasi yang bisa diubah admin tanpa deploy ulang (key-value).
+// Jika kunci belum ada, nilai default dari konfigurasi (.env) yang dipakai.
+type AppSetting struct {
+	Kunci      string    `gorm:"column:kunci;type:varchar(100);primaryKey" json:"kunci"`
+	Nilai      string    `gorm:"column:nilai;type:text" json:"nilai"`
+	TglUpdate  time.Time `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate string    `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for AppSetting model
+func (AppSetting) TableName() string {
+	return "db_app_setting"
+}
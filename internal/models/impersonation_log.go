@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ImpersonationLog represents db_impersonation_log table
+// Satu baris per sesi "view as user" oleh admin.
+type ImpersonationLog struct {
+	ID             int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDAdmin        int        `gorm:"column:id_admin;index" json:"id_admin"`
+	UsernameAdmin  string     `gorm:"column:username_admin;type:varchar(100)" json:"username_admin"`
+	TargetType     string     `gorm:"column:target_type;type:varchar(20)" json:"target_type"`   // mahasiswa, pegawai
+	TargetID       string     `gorm:"column:target_id;type:varchar(50);index" json:"target_id"` // NIM atau id_reviewer
+	TargetNama     string     `gorm:"column:target_nama;type:varchar(255)" json:"target_nama"`
+	Alasan         string     `gorm:"column:alasan;type:varchar(255)" json:"alasan"`
+	JTI            string     `gorm:"column:jti;type:varchar(64);index" json:"jti"`
+	IPAddress      string     `gorm:"column:ip_address;type:varchar(64)" json:"ip_address"`
+	UserAgent      string     `gorm:"column:user_agent;type:varchar(255)" json:"user_agent"`
+	MulaiAt        time.Time  `gorm:"column:mulai_at;type:datetime" json:"mulai_at"`
+	BerakhirAt     time.Time  `gorm:"column:berakhir_at;type:datetime" json:"berakhir_at"`     // token kadaluarsa
+	SelesaiAt      *time.Time `gorm:"column:selesai_at;type:datetime" json:"selesai_at"`       // dihentikan manual
+	JumlahDiblokir int        `gorm:"column:jumlah_diblokir;default:0" json:"jumlah_diblokir"` // percobaan aksi tulis yang diblokir
+	TglInsert      *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+}
+
+// TableName specifies the table name for ImpersonationLog model
+func (ImpersonationLog) TableName() string {
+	return "db_impersonation_log"
+}
+
+// IsActive checks if impersonation session is still running
+func (l *ImpersonationLog) IsActive() bool {
+	return l.SelesaiAt == nil && time.Now().Before(l.BerakhirAt)
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// KategoriKode represents db_kategori_kode table
+// Kode singkat kategori PKM (mis. RE, KC) untuk kode pengajuan. Disimpan terpisah karena
+// db_kategori_pkm dikelola di luar aplikasi.
+type KategoriKode struct {
+	ID         int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDKategori int        `gorm:"column:id_kategori;type:int;uniqueIndex" json:"id_kategori"`
+	Kode       string     `gorm:"column:kode;type:varchar(10);uniqueIndex" json:"kode"`
+	TglInsert  *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate  time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for KategoriKode model
+func (KategoriKode) TableName() string {
+	return "db_kategori_kode"
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// KemiripanJudul represents db_kemiripan_judul table
+// Judul lain (semua tahun) yang mirip dengan judul pengajuan, dihitung ulang setiap judul dibuat/diubah.
+type KemiripanJudul struct {
+	ID                 int       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDPengajuan        int       `gorm:"column:id_pengajuan;type:int;index" json:"id_pengajuan"`
+	IDPengajuanMirip   int       `gorm:"column:id_pengajuan_mirip;type:int;index" json:"id_pengajuan_mirip"`
+	KodePengajuanMirip string    `gorm:"column:kode_pengajuan_mirip;type:varchar(50)" json:"kode_pengajuan_mirip"` // snapshot
+	JudulMirip         string    `gorm:"column:judul_mirip;type:text" json:"judul_mirip"`                          // snapshot
+	TahunMirip         int       `gorm:"column:tahun_mirip;type:int" json:"tahun_mirip"`
+	Skor               float64   `gorm:"column:skor;type:decimal(5,4)" json:"skor"` // cosine TF-IDF 0-1
+	TglInsert          time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+}
+
+// TableName specifies the table name for KemiripanJudul model
+func (KemiripanJudul) TableName() string {
+	return "db_kemiripan_judul"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// KodeSequence represents db_kode_sequence table
+// Nomor urut terakhir kode pengajuan per kategori dan periode. Dinaikkan secara atomik
+// (INSERT ... ON DUPLICATE KEY UPDATE) di dalam transaksi pembuatan pengajuan.
+type KodeSequence struct {
+	ID         int       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDKategori int       `gorm:"column:id_kategori;type:int;uniqueIndex:idx_kode_sequence_kategori_periode" json:"id_kategori"`
+	IDPeriode  int       `gorm:"column:id_periode;type:int;uniqueIndex:idx_kode_sequence_kategori_periode" json:"id_periode"`
+	LastSeq    int       `gorm:"column:last_seq;type:int;not null;default:0" json:"last_seq"`
+	TglUpdate  time.Time `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+}
+
+// TableName specifies the table name for KodeSequence model
+func (KodeSequence) TableName() string {
+	return "db_kode_sequence"
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// LoginAttempt represents db_login_attempt table
+// Menyimpan counter login gagal per username dan per IP agar lockout tetap
+// berlaku walaupun aplikasi di-restart.
+type LoginAttempt struct {
+	ID            int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Kunci         string     `gorm:"column:kunci;type:varchar(191);uniqueIndex" json:"kunci"` // <tipe>:<nilai>
+	Tipe          string     `gorm:"column:tipe;type:varchar(20)" json:"tipe"`                // username, ip
+	Nilai         string     `gorm:"column:nilai;type:varchar(150)" json:"nilai"`
+	JumlahGagal   int        `gorm:"column:jumlah_gagal;type:int(11);default:0" json:"jumlah_gagal"`
+	TerakhirGagal *time.Time `gorm:"column:terakhir_gagal;type:datetime" json:"terakhir_gagal"`
+	LockedUntil   *time.Time `gorm:"column:locked_until;type:datetime;index" json:"locked_until"`
+	TglInsert     *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate     time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate    string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for LoginAttempt model
+func (LoginAttempt) TableName() string {
+	return "db_login_attempt"
+}
+
+// IsLocked checks if the key is currently locked out
+func (l *LoginAttempt) IsLocked() bool {
+	return l.LockedUntil != nil && time.Now().Before(*l.LockedUntil)
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PartisipasiLock represents db_partisipasi_lock table
+// Satu baris per NIM dan periode, dikunci (SELECT ... FOR UPDATE) selama transaksi yang menambah
+// keikutsertaan mahasiswa agar dua request bersamaan tidak melewati batas ketua/anggota per periode.
+type PartisipasiLock struct {
+	NIM       string     `gorm:"column:nim;type:varchar(20);primaryKey" json:"nim"`
+	IDPeriode int        `gorm:"column:id_periode;primaryKey;autoIncrement:false" json:"id_periode"`
+	TglInsert *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+}
+
+// TableName specifies the table name for PartisipasiLock model
+func (PartisipasiLock) TableName() string {
+	return "db_partisipasi_lock"
+}
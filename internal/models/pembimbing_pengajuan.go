@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// PembimbingPengajuan represents db_pembimbing_pengajuan table
+// Dosen pembimbing terstruktur (pegawai SIMPEG) untuk setiap pengajuan. Judul baru bisa di-plot
+// ke reviewer setelah pembimbing memberi pengesahan.
+type PembimbingPengajuan struct {
+	ID                int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDPengajuan       int        `gorm:"column:id_pengajuan;type:int;uniqueIndex" json:"id_pengajuan"`
+	IDPegawai         int        `gorm:"column:id_pegawai;type:int;index" json:"id_pegawai"`                                       // pegawai.id di SIMPEG
+	NamaPegawai       string     `gorm:"column:nama_pegawai;type:varchar(100)" json:"nama_pegawai"`                                // snapshot nama + gelar saat dipilih
+	StatusPengesahan  string     `gorm:"column:status_pengesahan;type:varchar(20);default:PENDING;index" json:"status_pengesahan"` // PENDING, DISETUJUI, DITOLAK
+	CatatanPengesahan string     `gorm:"column:catatan_pengesahan;type:text" json:"catatan_pengesahan"`
+	TglPengesahan     *time.Time `gorm:"column:tgl_pengesahan;type:datetime" json:"tgl_pengesahan"`
+	TglInsert         *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate         time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate        string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for PembimbingPengajuan model
+func (PembimbingPengajuan) TableName() string {
+	return "db_pembimbing_pengajuan"
+}
+
+// IsEndorsed checks if the pembimbing has approved the judul
+func (p *PembimbingPengajuan) IsEndorsed() bool {
+	return p.StatusPengesahan == "DISETUJUI"
+}
@@ -54,31 +54,6 @@ func (Pengajuan) TableName() string {
 	return "db_pengajuan_pkm"
 }
 
-// CanUploadProposal checks if mahasiswa can upload proposal (status_judul must be ACC)
-func (p *Pengajuan) CanUploadProposal() bool {
-	return p.StatusJudul == "ACC"
-}
-
-// CanReviseJudul checks if mahasiswa can revise/update judul data (broadened for conditional editing)
-func (p *Pengajuan) CanReviseJudul() bool {
-	return p.StatusJudul == "REVISI" || p.StatusJudul == "PENDING"
-}
-
-// CanEditMembers checks if team members can be edited (only in PENDING status)
-func (p *Pengajuan) CanEditMembers() bool {
-	return p.StatusJudul == "PENDING"
-}
-
-// CanEditJudulData checks if title and parameters can be edited (only in REVISI status)
-func (p *Pengajuan) CanEditJudulData() bool {
-	return p.StatusJudul == "REVISI"
-}
-
-// CanReviseProposal checks if mahasiswa can revise proposal (status_proposal must be REVISI)
-func (p *Pengajuan) CanReviseProposal() bool {
-	return p.StatusProposal == "REVISI"
-}
-
 // IsOwner checks if given NIM is the owner (ketua) of this pengajuan
 func (p *Pengajuan) IsOwner(nim string) bool {
 	return p.NIMKetua == nim
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PengajuanEvent represents db_pengajuan_event table
+// Riwayat append-only setiap perubahan pengajuan: siapa, aksi apa, nilai lama -> baru, kapan.
+type PengajuanEvent struct {
+	ID          int       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDPengajuan int       `gorm:"column:id_pengajuan;type:int;index" json:"id_pengajuan"`
+	Aksi        string    `gorm:"column:aksi;type:varchar(50);index" json:"aksi"` // lihat workflow.Action
+	Tahap       string    `gorm:"column:tahap;type:varchar(20)" json:"tahap"`     // JUDUL, PROPOSAL, FINAL (kosong jika bukan perubahan status)
+	StatusLama  string    `gorm:"column:status_lama;type:varchar(20)" json:"status_lama"`
+	StatusBaru  string    `gorm:"column:status_baru;type:varchar(20)" json:"status_baru"`
+	DataLama    string    `gorm:"column:data_lama;type:text" json:"data_lama"` // JSON kolom yang berubah (nilai sebelum)
+	DataBaru    string    `gorm:"column:data_baru;type:text" json:"data_baru"` // JSON kolom yang berubah (nilai sesudah)
+	Catatan     string    `gorm:"column:catatan;type:text" json:"catatan"`
+	ActorType   string    `gorm:"column:actor_type;type:varchar(20)" json:"actor_type"` // admin, mahasiswa, pegawai
+	ActorID     string    `gorm:"column:actor_id;type:varchar(100)" json:"actor_id"`    // NIM, id_user admin atau id_user reviewer
+	TglInsert   time.Time `gorm:"column:tgl_insert;type:datetime;index" json:"tgl_insert"`
+}
+
+// TableName specifies the table name for PengajuanEvent model
+func (PengajuanEvent) TableName() string {
+	return "db_pengajuan_event"
+}
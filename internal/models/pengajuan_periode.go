@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PengajuanPeriode represents db_pengajuan_periode table
+// Menautkan pengajuan ke periode pendaftaran (db_tgl_setting) tempat ia diajukan.
+// Tabel db_pengajuan_pkm dikelola di luar aplikasi, sehingga id_periode disimpan di sini.
+type PengajuanPeriode struct {
+	ID          int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDPengajuan int        `gorm:"column:id_pengajuan;type:int;uniqueIndex" json:"id_pengajuan"`
+	IDPeriode   int        `gorm:"column:id_periode;type:int;index" json:"id_periode"` // db_tgl_setting.id
+	TglInsert   *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	UserUpdate  string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for PengajuanPeriode model
+func (PengajuanPeriode) TableName() string {
+	return "db_pengajuan_periode"
+}
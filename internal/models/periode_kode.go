@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PeriodeKode represents db_periode_kode table
+// Bagian {TAHUN} kode pengajuan per periode (mis. "2026" atau "2026-2"). Disimpan saat pertama
+// kali dipakai agar tidak berubah ketika periode lain di tahun yang sama ditambah atau dimundurkan.
+type PeriodeKode struct {
+	IDPeriode int        `gorm:"column:id_periode;primaryKey;autoIncrement:false" json:"id_periode"`
+	TahunKode string     `gorm:"column:tahun_kode;type:varchar(10);uniqueIndex" json:"tahun_kode"`
+	TglInsert *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+}
+
+// TableName specifies the table name for PeriodeKode model
+func (PeriodeKode) TableName() string {
+	return "db_periode_kode"
+}
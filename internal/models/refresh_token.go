@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// RefreshToken represents db_refresh_token table
+// Setiap refresh token hanya bisa dipakai sekali (rotating). Token yang sudah
+// dirotasi lalu dipakai lagi dianggap bocor dan seluruh family-nya dicabut.
+type RefreshToken struct {
+	ID         int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TokenHash  string     `gorm:"column:token_hash;type:varchar(64);uniqueIndex" json:"-"`  // SHA-256 dari token mentah
+	FamilyID   string     `gorm:"column:family_id;type:varchar(64);index" json:"family_id"` // sama untuk satu rantai rotasi
+	Subject    string     `gorm:"column:subject;type:varchar(150);index" json:"subject"`    // lihat utils.TokenSubject
+	Claims     string     `gorm:"column:claims;type:text" json:"-"`                         // snapshot JSON claims untuk access token baru
+	ExpiresAt  time.Time  `gorm:"column:expires_at;type:datetime;index" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at;type:datetime" json:"revoked_at"`
+	ReplacedBy *int       `gorm:"column:replaced_by;type:int(11)" json:"replaced_by"` // id token pengganti setelah rotasi
+	IPAddress  string     `gorm:"column:ip_address;type:varchar(45)" json:"ip_address"`
+	UserAgent  string     `gorm:"column:user_agent;type:varchar(255)" json:"user_agent"`
+	TglInsert  *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+}
+
+// TableName specifies the table name for RefreshToken model
+func (RefreshToken) TableName() string {
+	return "db_refresh_token"
+}
+
+// IsUsable checks if refresh token can still be exchanged
+func (r *RefreshToken) IsUsable() bool {
+	return r.RevokedAt == nil && time.Now().Before(r.ExpiresAt)
+}
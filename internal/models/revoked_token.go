@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RevokedToken represents db_revoked_token table
+// Satu baris mencabut satu access token (JTI terisi) atau semua token milik
+// subject yang diterbitkan sebelum RevokedBefore (JTI kosong).
+type RevokedToken struct {
+	ID            int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	JTI           string     `gorm:"column:jti;type:varchar(64);index" json:"jti"`
+	Subject       string     `gorm:"column:subject;type:varchar(150);index" json:"subject"`
+	RevokedBefore *time.Time `gorm:"column:revoked_before;type:datetime(6)" json:"revoked_before"` // presisi mikrodetik, MySQL membulatkan pecahan detik pada datetime
+	ExpiresAt     time.Time  `gorm:"column:expires_at;type:datetime;index" json:"expires_at"`      // baris boleh dibersihkan setelah waktu ini
+	Alasan        string     `gorm:"column:alasan;type:varchar(255)" json:"alasan"`
+	TglInsert     *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	UserUpdate    string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for RevokedToken model
+func (RevokedToken) TableName() string {
+	return "db_revoked_token"
+}
@@ -51,4 +51,9 @@ func (t *TglSetting) IsAfterAnnouncement() bool {
 		   t.Status == 1 &&
 		   t.Hapus == 0 &&
 		   now.After(t.TglPengumuman)
-}
\ No newline at end of file
+}
+
+// Tahun returns the year of the registration period (tahun pengajuan yang dibuat di periode ini)
+func (t *TglSetting) Tahun() int {
+	return t.TglDaftarAwal.Year()
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TransferKetua represents db_transfer_ketua table
+// Pengalihan ketua ke anggota lain: diajukan ketua/admin, berlaku setelah anggota tujuan menerima.
+type TransferKetua struct {
+	ID             int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDPengajuan    int        `gorm:"column:id_pengajuan;type:int;index" json:"id_pengajuan"`
+	NIMLama        string     `gorm:"column:nim_lama;type:varchar(20)" json:"nim_lama"`
+	NIMBaru        string     `gorm:"column:nim_baru;type:varchar(20);index" json:"nim_baru"`
+	Alasan         string     `gorm:"column:alasan;type:text" json:"alasan"`
+	StatusTransfer string     `gorm:"column:status_transfer;type:varchar(20);default:PENDING;index" json:"status_transfer"` // PENDING, ACCEPTED, DECLINED, CANCELLED
+	DiajukanOleh   string     `gorm:"column:diajukan_oleh;type:varchar(100)" json:"diajukan_oleh"`                          // NIM ketua atau id_user admin
+	RespondedAt    *time.Time `gorm:"column:responded_at;type:datetime" json:"responded_at"`
+	TglInsert      *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate      time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate     string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for TransferKetua model
+func (TransferKetua) TableName() string {
+	return "db_transfer_ketua"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TwoFactorChallenge represents db_2fa_challenge table
+// Dibuat saat password admin benar tetapi faktor kedua belum diverifikasi.
+// Token mentah hanya dikirim ke client, yang disimpan hanya hash-nya.
+type TwoFactorChallenge struct {
+	ID        int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);uniqueIndex" json:"-"`
+	IDUser    int        `gorm:"column:id_user;type:int(11);index" json:"id_user"`
+	Username  string     `gorm:"column:username;type:varchar(100)" json:"username"`
+	Percobaan int        `gorm:"column:percobaan;type:int;default:0" json:"percobaan"` // kode salah yang sudah dicoba
+	ExpiresAt time.Time  `gorm:"column:expires_at;type:datetime;index" json:"expires_at"`
+	DipakaiAt *time.Time `gorm:"column:dipakai_at;type:datetime" json:"dipakai_at"`
+	IPAddress string     `gorm:"column:ip_address;type:varchar(45)" json:"ip_address"`
+	TglInsert *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+}
+
+// TableName specifies the table name for TwoFactorChallenge model
+func (TwoFactorChallenge) TableName() string {
+	return "db_2fa_challenge"
+}
+
+// IsUsable checks if challenge can still be verified
+func (c *TwoFactorChallenge) IsUsable(maxAttempts int) bool {
+	return c.DipakaiAt == nil && c.Percobaan < maxAttempts && time.Now().Before(c.ExpiresAt)
+}
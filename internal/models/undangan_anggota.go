@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// UndanganAnggota represents db_undangan_anggota table
+// Undangan untuk setiap anggota non-ketua. Pengajuan baru bisa lanjut dari DRAFT/PENDING
+// setelah semua undangan aktif diterima.
+type UndanganAnggota struct {
+	ID             int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDPengajuan    int        `gorm:"column:id_pengajuan;type:int;index" json:"id_pengajuan"`
+	NIMAnggota     string     `gorm:"column:nim_anggota;type:varchar(20);index" json:"nim_anggota"`
+	NamaAnggota    string     `gorm:"column:nama_anggota;type:varchar(100)" json:"nama_anggota"`
+	NIMKetua       string     `gorm:"column:nim_ketua;type:varchar(20)" json:"nim_ketua"`                                   // pengundang
+	StatusUndangan string     `gorm:"column:status_undangan;type:varchar(20);default:PENDING;index" json:"status_undangan"` // PENDING, ACCEPTED, DECLINED, EXPIRED, CANCELLED
+	ExpiresAt      time.Time  `gorm:"column:expires_at;type:datetime;index" json:"expires_at"`
+	RespondedAt    *time.Time `gorm:"column:responded_at;type:datetime" json:"responded_at"`
+	TglInsert      *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate      time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate     string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for UndanganAnggota model
+func (UndanganAnggota) TableName() string {
+	return "db_undangan_anggota"
+}
+
+// IsActive checks if invitation still waits for an answer
+func (u *UndanganAnggota) IsActive() bool {
+	return u.StatusUndangan == "PENDING" && time.Now().Before(u.ExpiresAt)
+}
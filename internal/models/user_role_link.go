@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// UserRoleLink represents db_user_role_link table
+// Menghubungkan akun lokal db_user dengan identitas SSO (pegawai / mahasiswa)
+// agar satu orang bisa berpindah role tanpa login ulang.
+type UserRoleLink struct {
+	ID         int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDUser     int        `gorm:"column:id_user;index" json:"id_user"`
+	Tipe       string     `gorm:"column:tipe;type:varchar(20);index:idx_role_link_identitas" json:"tipe"`   // pegawai, mahasiswa
+	Nilai      string     `gorm:"column:nilai;type:varchar(50);index:idx_role_link_identitas" json:"nilai"` // id_pegawai atau NIM
+	Hapus      int        `gorm:"column:hapus;type:int(1);default:0" json:"-"`
+	TglInsert  *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate  time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for UserRoleLink model
+func (UserRoleLink) TableName() string {
+	return "db_user_role_link"
+}
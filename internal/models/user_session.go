@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// UserSession represents db_user_session table
+// Satu baris per login (perangkat). SessionID sama dengan family_id refresh token
+// dan ikut dibawa access token sebagai claim sid.
+type UserSession struct {
+	ID         int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	SessionID  string     `gorm:"column:session_id;type:varchar(64);uniqueIndex" json:"-"`
+	Subject    string     `gorm:"column:subject;type:varchar(150);index" json:"subject"` // lihat utils.TokenSubject
+	UserType   string     `gorm:"column:user_type;type:varchar(20)" json:"user_type"`    // admin, mahasiswa, pegawai
+	IDUser     uint       `gorm:"column:id_user;type:int(11)" json:"id_user"`            // id db_user / db_reviewer (0 untuk mahasiswa tanpa akun lokal)
+	Identifier string     `gorm:"column:identifier;type:varchar(100)" json:"identifier"` // username, NIM atau NIP
+	Nama       string     `gorm:"column:nama;type:varchar(150)" json:"nama"`
+	IPAddress  string     `gorm:"column:ip_address;type:varchar(45)" json:"ip_address"`
+	UserAgent  string     `gorm:"column:user_agent;type:varchar(255)" json:"user_agent"`
+	IssuedAt   time.Time  `gorm:"column:issued_at;type:datetime" json:"issued_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at;type:datetime" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;type:datetime;index" json:"expires_at"` // mengikuti refresh token terakhir
+	RevokedAt  *time.Time `gorm:"column:revoked_at;type:datetime" json:"revoked_at"`
+	Alasan     string     `gorm:"column:alasan;type:varchar(255)" json:"alasan"` // alasan sesi diakhiri
+	UserUpdate string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for UserSession model
+func (UserSession) TableName() string {
+	return "db_user_session"
+}
+
+// IsActive checks if session has not been revoked or expired
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
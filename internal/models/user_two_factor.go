@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// UserTwoFactor represents db_user_2fa table
+// TOTP untuk akun admin db_user. Baris dengan is_enabled = 0 adalah enrollment
+// yang belum dikonfirmasi dengan kode pertama.
+type UserTwoFactor struct {
+	ID            int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IDUser        int        `gorm:"column:id_user;type:int(11);uniqueIndex" json:"id_user"`
+	Secret        string     `gorm:"column:secret;type:varchar(64)" json:"-"` // base32
+	IsEnabled     int        `gorm:"column:is_enabled;type:int(1);default:0" json:"is_enabled"`
+	EnabledAt     *time.Time `gorm:"column:enabled_at;type:datetime" json:"enabled_at"`
+	LastUsedStep  int64      `gorm:"column:last_used_step;type:bigint;default:0" json:"-"` // step TOTP terakhir, cegah replay
+	RecoveryCodes string     `gorm:"column:recovery_codes;type:text" json:"-"`             // SHA-256 kode pemulihan, dipisah koma
+	TglInsert     *time.Time `gorm:"column:tgl_insert;type:datetime" json:"tgl_insert"`
+	TglUpdate     time.Time  `gorm:"column:tgl_update;type:timestamp;autoUpdateTime" json:"tgl_update"`
+	UserUpdate    string     `gorm:"column:user_update;type:text" json:"user_update"`
+}
+
+// TableName specifies the table name for UserTwoFactor model
+func (UserTwoFactor) TableName() string {
+	return "db_user_2fa"
+}
+
+// RecoveryCodeHashes mengembalikan hash kode pemulihan yang belum dipakai
+func (t *UserTwoFactor) RecoveryCodeHashes() []string {
+	hashes := []string{}
+	for _, hash := range strings.Split(t.RecoveryCodes, ",") {
+		if hash = strings.TrimSpace(hash); hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
@@ -4,6 +4,8 @@ import (
 	"rires-be/internal/controllers"
 	"rires-be/internal/middleware"
 	"rires-be/pkg/database"
+	"rires-be/pkg/services"
+	"rires-be/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
@@ -22,6 +24,12 @@ func Setup(app *fiber.App) {
 		})
 	})
 
+	// Public keys untuk verifikasi access token oleh service lain
+	app.Get("/.well-known/jwks.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(utils.JWKS())
+	})
+
 	// Health check endpoint
 	app.Get("/health", func(c *fiber.Ctx) error {
 		// Check database connection
@@ -128,22 +136,46 @@ func Setup(app *fiber.App) {
 	auth := api.Group("/auth")
 	{
 		auth.Post("/login", authController.Login)
+		auth.Post("/refresh", authController.Refresh)
+		auth.Post("/2fa/verify", authController.VerifyTwoFactor)
+		auth.Post("/2fa/enroll", authController.EnrollTwoFactor)
 	}
 
 	// ============================================
 	// PROTECTED ROUTES (JWT required)
 	// ============================================
+	// API key (X-API-Key) hanya diterima oleh group yang memasang
+	// middleware.RequireScope, guard role lain menolaknya.
 	protected := api.Group("/", middleware.JWTAuth())
 
 	// Auth - Get current user (protected)
-	authProtected := protected.Group("/auth")
+	impersonationController := controllers.NewImpersonationController()
+	sessionController := controllers.NewSessionController()
+	authProtected := protected.Group("/auth", middleware.RequireUser())
 	{
 		authProtected.Get("/me", authController.GetCurrentUser)
+		authProtected.Post("/logout", authController.Logout)
+		authProtected.Post("/change-password", authController.ChangePassword)
+		authProtected.Post("/impersonation/stop", impersonationController.Stop)
+		authProtected.Post("/switch-role", authController.SwitchRole)
+		authProtected.Get("/sessions", sessionController.GetMySessions)
+		authProtected.Delete("/sessions/:id", sessionController.RevokeMySession)
+	}
+
+	// Auth - 2FA management akun admin (protected)
+	twoFactorController := controllers.NewTwoFactorController()
+	twoFactor := protected.Group("/auth/2fa", middleware.RequireAdmin())
+	{
+		twoFactor.Get("/", twoFactorController.GetStatus)
+		twoFactor.Post("/setup", twoFactorController.Setup)
+		twoFactor.Post("/enable", twoFactorController.Enable)
+		twoFactor.Post("/disable", twoFactorController.Disable)
+		twoFactor.Post("/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
 	}
 
 	// Reference Data routes (Fakultas & Prodi from NEOMAAREF)
 	referenceController := controllers.NewReferenceController()
-	reference := protected.Group("/reference")
+	reference := protected.Group("/reference", middleware.RequireScope(services.ScopeReferenceRead))
 	{
 		reference.Get("/fakultas", referenceController.GetAllFakultas)
 		reference.Get("/prodi", referenceController.GetAllProdi)
@@ -163,7 +195,7 @@ func Setup(app *fiber.App) {
 
 	// Menu routes (Admin only for CUD, All for Read)
 	menuController := controllers.NewMenuController()
-	menusPublic := protected.Group("/menus")
+	menusPublic := protected.Group("/menus", middleware.RequireUser())
 	{
 		menusPublic.Get("/", menuController.GetList)              // All users can read
 		menusPublic.Get("/tree", menuController.GetTree)          // All users can read (all menus)
@@ -179,7 +211,7 @@ func Setup(app *fiber.App) {
 
 	// Kategori PKM routes (Admin only for CUD, All for Read)
 	kategoriPKMController := controllers.NewKategoriPKMController()
-	kategoriPublic := protected.Group("/kategori-pkm")
+	kategoriPublic := protected.Group("/kategori-pkm", middleware.RequireScope(services.ScopeReferenceRead))
 	{
 		kategoriPublic.Get("/", kategoriPKMController.GetList)    // All users can read
 		kategoriPublic.Get("/:id", kategoriPKMController.GetByID) // All users can read
@@ -193,7 +225,7 @@ func Setup(app *fiber.App) {
 
 	// Status Review routes (Admin only for CUD, All for Read)
 	statusReviewController := controllers.NewStatusReviewController()
-	statusPublic := protected.Group("/status-review")
+	statusPublic := protected.Group("/status-review", middleware.RequireScope(services.ScopeReferenceRead))
 	{
 		statusPublic.Get("/", statusReviewController.GetList)    // All users can read
 		statusPublic.Get("/:id", statusReviewController.GetByID) // All users can read
@@ -207,7 +239,7 @@ func Setup(app *fiber.App) {
 
 	// Parameter Form routes (Admin only for CUD, All for Read)
 	parameterFormController := controllers.NewParameterFormController()
-	paramPublic := protected.Group("/parameter-form")
+	paramPublic := protected.Group("/parameter-form", middleware.RequireScope(services.ScopeReferenceRead))
 	{
 		paramPublic.Get("/", parameterFormController.GetList)
 		paramPublic.Get("/kategori/:id_kategori", parameterFormController.GetByKategori) // Important for mahasiswa
@@ -225,17 +257,23 @@ func Setup(app *fiber.App) {
 	users := protected.Group("/users", middleware.RequireAdmin())
 	{
 		users.Get("/", userManagementController.GetList)
+		users.Get("/weak-passwords", userManagementController.GetWeakPasswords)
 		users.Get("/:id", userManagementController.GetByID)
 		users.Post("/", userManagementController.Create)
 		users.Put("/:id", userManagementController.Update)
 		users.Post("/:id/reset-password", userManagementController.ResetPassword)
+		users.Post("/:id/revoke-tokens", userManagementController.RevokeTokens)
+		users.Post("/:id/reset-2fa", userManagementController.ResetTwoFactor)
+		users.Get("/:id/role-links", userManagementController.GetRoleLinks)
+		users.Post("/:id/role-links", userManagementController.CreateRoleLink)
+		users.Delete("/:id/role-links/:link_id", userManagementController.DeleteRoleLink)
 		users.Delete("/:id", userManagementController.Delete)
 	}
 
 	// Tanggal Setting routes
 	tglSettingController := controllers.NewTglSettingController()
 	// Public endpoint - check if registration is open
-	tglSettingPublic := protected.Group("/tgl-setting")
+	tglSettingPublic := protected.Group("/tgl-setting", middleware.RequireScope(services.ScopeReferenceRead))
 	{
 		tglSettingPublic.Get("/active", tglSettingController.GetActive) // All authenticated users can check
 	}
@@ -253,13 +291,30 @@ func Setup(app *fiber.App) {
 	PengajuanController := controllers.NewPengajuanController()
 
 	// Announcements (accessible to all authenticated users)
-	protected.Get("/pengajuan/announcements", PengajuanController.GetAnnouncements)
+	protected.Get("/pengajuan/announcements", middleware.RequireScope(services.ScopePengajuanRead), PengajuanController.GetAnnouncements)
+
+	// Timeline (team, dosen pembimbing, assigned reviewers and admin; access checked in service)
+	protected.Get("/pengajuan/:id/timeline", middleware.RequireUser(), PengajuanController.GetTimeline)
 
 	pengajuanMhs := protected.Group("/pengajuan", middleware.RequireMahasiswa())
 	{
 		// Judul PKM
 		pengajuanMhs.Post("/judul", PengajuanController.CreateJudulPKM)
 		pengajuanMhs.Put("/judul/:id", PengajuanController.UpdateJudul)
+		pengajuanMhs.Post("/:id/submit", PengajuanController.SubmitPengajuan)
+
+		// Undangan anggota tim
+		pengajuanMhs.Get("/invitations", PengajuanController.GetMyInvitations)
+		pengajuanMhs.Post("/invitations/:id/accept", PengajuanController.AcceptInvitation)
+		pengajuanMhs.Post("/invitations/:id/decline", PengajuanController.DeclineInvitation)
+
+		// Pengalihan ketua
+		pengajuanMhs.Get("/transfer-ketua", PengajuanController.GetMyTransfers)
+		pengajuanMhs.Post("/transfer-ketua/:id/accept", PengajuanController.AcceptKetua)
+		pengajuanMhs.Post("/transfer-ketua/:id/decline", PengajuanController.DeclineKetua)
+		pengajuanMhs.Delete("/transfer-ketua/:id", PengajuanController.CancelTransferKetua)
+		pengajuanMhs.Post("/:id/transfer-ketua", PengajuanController.NominateKetua)
+		pengajuanMhs.Post("/:id/withdraw", PengajuanController.WithdrawPengajuan)
 
 		// Proposal
 		pengajuanMhs.Post("/:id/proposal", PengajuanController.UploadProposal)
@@ -274,9 +329,19 @@ func Setup(app *fiber.App) {
 	pengajuanAdminController := controllers.NewPengajuanAdminController()
 	pengajuanAdmin := protected.Group("/admin/pengajuan")
 	{
-		// List & Detail - Accessible by Admin and Reviewer
-		pengajuanAdmin.Get("/", middleware.RequireAdminOrReviewer(), pengajuanAdminController.GetAllPengajuan)
-		pengajuanAdmin.Get("/:id", middleware.RequireAdminOrReviewer(), pengajuanAdminController.GetPengajuanDetail)
+		// List & Detail - Accessible by Admin, Reviewer and API key (pengajuan:read)
+		pengajuanAdmin.Get("/", middleware.RequireScope(services.ScopePengajuanRead, middleware.RequireAdminOrReviewer()), pengajuanAdminController.GetAllPengajuan)
+
+		// Similar judul clusters per periode - Strictly Admin only (before /:id)
+		pengajuanAdmin.Get("/kemiripan", middleware.RequireAdmin(), pengajuanAdminController.GetKemiripanReport)
+
+		// Import from CSV/XLSX (dry-run / commit) - Strictly Admin only
+		pengajuanAdmin.Post("/import", middleware.RequireAdmin(), pengajuanAdminController.ImportPengajuan)
+
+		// Export filtered list to CSV/XLSX - Strictly Admin only
+		pengajuanAdmin.Get("/export", middleware.RequireAdmin(), pengajuanAdminController.ExportPengajuan)
+
+		pengajuanAdmin.Get("/:id", middleware.RequireScope(services.ScopePengajuanRead, middleware.RequireAdminOrReviewer()), pengajuanAdminController.GetPengajuanDetail)
 
 		// Assign Reviewer - Strictly Admin only
 		pengajuanAdmin.Post("/:id/assign-reviewer-judul", middleware.RequireAdmin(), pengajuanAdminController.AssignReviewerJudul)
@@ -301,6 +366,7 @@ func Setup(app *fiber.App) {
 		reviewerAdmin.Get("/available", reviewerController.GetAvailablePegawai)
 		reviewerAdmin.Post("/", reviewerController.ActivateReviewer)
 		reviewerAdmin.Put("/:id", reviewerController.UpdateReviewer)
+		reviewerAdmin.Post("/:id/revoke-tokens", reviewerController.RevokeTokens)
 		reviewerAdmin.Delete("/:id", reviewerController.DeleteReviewer)
 	}
 
@@ -323,6 +389,66 @@ func Setup(app *fiber.App) {
 		pengajuanReviewer.Post("/proposal/:id/cancel-review", pengajuanReviewerController.CancelReviewProposal)
 	}
 
+	// dosen pembimbing endpoints (reviewer maupun pegawai yang hanya pembimbing)
+	pembimbingController := controllers.NewPembimbingController()
+	pembimbing := protected.Group("/pembimbing", middleware.RequirePembimbing())
+	{
+		pembimbing.Get("/teams", pembimbingController.GetMyTeams)
+		pembimbing.Get("/pengajuan/:id", pembimbingController.GetPengajuanDetail)
+		pembimbing.Post("/pengajuan/:id/pengesahan", pembimbingController.Endorse)
+	}
+
+	// kode pengajuan format - admin endpoints
+	kodePengajuanController := controllers.NewKodePengajuanController()
+	kodePengajuanAdmin := protected.Group("/admin/kode-pengajuan", middleware.RequireAdmin())
+	{
+		kodePengajuanAdmin.Get("/template", kodePengajuanController.GetTemplate)
+		kodePengajuanAdmin.Put("/template", kodePengajuanController.UpdateTemplate)
+	}
+
+	// recycle bin (soft-deleted data) - admin endpoints
+	recycleBinController := controllers.NewRecycleBinController()
+	recycleBinAdmin := protected.Group("/admin/recycle-bin", middleware.RequireAdmin())
+	{
+		recycleBinAdmin.Get("/", recycleBinController.GetAll)
+		recycleBinAdmin.Post("/:tipe/:id/restore", recycleBinController.Restore)
+		recycleBinAdmin.Delete("/:tipe/:id", recycleBinController.Purge)
+	}
+
+	// login lockout management - admin endpoints
+	loginAttemptController := controllers.NewLoginAttemptController()
+	loginLockoutAdmin := protected.Group("/admin/login-lockouts", middleware.RequireAdmin())
+	{
+		loginLockoutAdmin.Get("/", loginAttemptController.GetLockouts)
+		loginLockoutAdmin.Delete("/:id", loginAttemptController.ClearLockout)
+	}
+
+	// login session management - admin endpoints
+	sessionAdmin := protected.Group("/admin/sessions", middleware.RequireAdmin())
+	{
+		sessionAdmin.Get("/", sessionController.GetSessions)
+		sessionAdmin.Delete("/:id", sessionController.RevokeSession)
+	}
+
+	// impersonation ("view as user") - admin endpoints
+	protected.Post("/admin/impersonate", middleware.RequireAdmin(), impersonationController.Start)
+	protected.Get("/admin/impersonations", middleware.RequireAdmin(), impersonationController.GetLogs)
+
+	// statistics - admin & API key (statistics:read)
+	statisticsController := controllers.NewStatisticsController()
+	protected.Get("/admin/statistics/pengajuan", middleware.RequireScope(services.ScopeStatisticsRead, middleware.RequireAdmin()), statisticsController.GetPengajuanStatistics)
+
+	// API key management - admin endpoints
+	apiKeyController := controllers.NewAPIKeyController()
+	apiKeyAdmin := protected.Group("/admin/api-keys", middleware.RequireAdmin())
+	{
+		apiKeyAdmin.Get("/", apiKeyController.GetAll)
+		apiKeyAdmin.Get("/:id", apiKeyController.GetByID)
+		apiKeyAdmin.Post("/", apiKeyController.Create)
+		apiKeyAdmin.Put("/:id", apiKeyController.Update)
+		apiKeyAdmin.Delete("/:id", apiKeyController.Delete)
+	}
+
 	//user akses management routes
 	userAksesController := controllers.NewUserAksesController()
 	userAksesAdmin := protected.Group("/admin/user-akses", middleware.RequireAdmin())
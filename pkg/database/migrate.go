@@ -0,0 +1,42 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"rires-be/internal/models"
+)
+
+// Migrate membuat tabel-tabel yang dikelola aplikasi ini sendiri.
+// Tabel lama (db_user, db_pengajuan, dst.) tetap dikelola di luar aplikasi
+// dan sengaja tidak ikut di-AutoMigrate.
+func Migrate() error {
+	if err := DB.AutoMigrate(
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.LoginAttempt{},
+		&models.ImpersonationLog{},
+		&models.UserRoleLink{},
+		&models.APIKey{},
+		&models.UserTwoFactor{},
+		&models.TwoFactorChallenge{},
+		&models.UserSession{},
+		&models.PengajuanEvent{},
+		&models.UndanganAnggota{},
+		&models.TransferKetua{},
+		&models.PembimbingPengajuan{},
+		&models.KemiripanJudul{},
+		&models.PengajuanPeriode{},
+		&models.KategoriKode{},
+		&models.KodeSequence{},
+		&models.AppSetting{},
+		&models.PeriodeKode{},
+		&models.PartisipasiLock{},
+	); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	log.Println("✅ Database migrated")
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Scope API key yang dikenali
+const (
+	ScopePengajuanRead  = "pengajuan:read"
+	ScopeReferenceRead  = "reference:read"
+	ScopeStatisticsRead = "statistics:read"
+)
+
+// apiKeyPrefix menandai key milik aplikasi ini (memudahkan secret scanning)
+const apiKeyPrefix = "rk_"
+
+// apiKeyTouchInterval membatasi update last_used_at agar tidak menulis di setiap request
+const apiKeyTouchInterval = time.Minute
+
+// ErrInvalidAPIKey dikembalikan untuk key yang tidak dikenal, nonaktif atau kedaluwarsa
+var ErrInvalidAPIKey = errors.New("invalid or expired API key")
+
+// ValidScopes daftar scope yang boleh diberikan ke API key
+var ValidScopes = []string{ScopePengajuanRead, ScopeReferenceRead, ScopeStatisticsRead}
+
+// APIKeyService handles API key untuk integrasi machine-to-machine
+type APIKeyService struct{}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{}
+}
+
+// Create membuat API key baru. Key mentah hanya dikembalikan di sini.
+func (s *APIKeyService) Create(req *request.CreateAPIKeyRequest, userID int) (*response.APIKeyCreatedResponse, error) {
+	scopes, err := s.normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at harus di masa depan")
+	}
+
+	// 1. Generate prefix (unik, untuk lookup) + secret
+	idBytes := make([]byte, 4)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	prefix := apiKeyPrefix + hex.EncodeToString(idBytes)
+
+	secret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	rawKey := prefix + "." + secret
+
+	// 2. Simpan hash saja
+	now := time.Now()
+	key := &models.APIKey{
+		Nama:       strings.TrimSpace(req.Nama),
+		Prefix:     prefix,
+		KeyHash:    utils.HashOpaqueToken(rawKey),
+		Scopes:     strings.Join(scopes, ","),
+		ExpiresAt:  req.ExpiresAt,
+		Status:     1,
+		TglInsert:  &now,
+		UserUpdate: strconv.Itoa(userID),
+	}
+	if err := database.DB.Create(key).Error; err != nil {
+		return nil, err
+	}
+
+	return &response.APIKeyCreatedResponse{
+		APIKeyResponse: s.mapKey(key),
+		Key:            rawKey,
+	}, nil
+}
+
+// GetAll mengembalikan semua API key (tanpa hash)
+func (s *APIKeyService) GetAll() ([]response.APIKeyResponse, error) {
+	var keys []models.APIKey
+	if err := database.DB.Where("hapus = ?", 0).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, s.mapKey(&key))
+	}
+	return result, nil
+}
+
+// GetByID mengembalikan detail API key
+func (s *APIKeyService) GetByID(id int) (*response.APIKeyResponse, error) {
+	key, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.mapKey(key)
+	return &result, nil
+}
+
+// Update mengubah nama, scopes, masa berlaku atau status API key
+func (s *APIKeyService) Update(id int, req *request.UpdateAPIKeyRequest, userID int) (*response.APIKeyResponse, error) {
+	key, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"user_update": strconv.Itoa(userID),
+	}
+	if req.Nama != nil {
+		updates["nama"] = strings.TrimSpace(*req.Nama)
+	}
+	if req.Scopes != nil {
+		scopes, err := s.normalizeScopes(req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		updates["scopes"] = strings.Join(scopes, ",")
+	}
+	if req.ExpiresAt != nil {
+		updates["expires_at"] = req.ExpiresAt
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+
+	if err := database.DB.Model(key).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// Delete mencabut API key (soft delete)
+func (s *APIKeyService) Delete(id int, userID int) error {
+	key, err := s.find(id)
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Model(key).Updates(map[string]interface{}{
+		"hapus":       1,
+		"status":      0,
+		"user_update": strconv.Itoa(userID),
+	}).Error
+}
+
+// Authenticate memvalidasi key mentah dari header X-API-Key dan mencatat pemakaian terakhir
+func (s *APIKeyService) Authenticate(rawKey, ipAddress string) (*models.APIKey, error) {
+	prefix, _, ok := strings.Cut(strings.TrimSpace(rawKey), ".")
+	if !ok || !strings.HasPrefix(prefix, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	if err := database.DB.Where("prefix = ? AND hapus = ?", prefix, 0).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(utils.HashOpaqueToken(strings.TrimSpace(rawKey)))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if !key.IsUsable() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Catat pemakaian terakhir (paling sering sekali per menit)
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := database.DB.Model(&models.APIKey{}).Where("id = ?", key.ID).
+			UpdateColumns(map[string]interface{}{
+				"last_used_at": now,
+				"last_used_ip": truncate(ipAddress, 45),
+			}).Error; err != nil {
+			log.Printf("[APIKey] Failed to record usage of %s: %v", key.Prefix, err)
+		}
+		key.LastUsedAt = &now
+	}
+
+	return &key, nil
+}
+
+// find mengambil API key yang belum dihapus
+func (s *APIKeyService) find(id int) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := database.DB.Where("id = ? AND hapus = ?", id, 0).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("API key tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// normalizeScopes memvalidasi scopes dan membuang duplikat
+func (s *APIKeyService) normalizeScopes(scopes []string) ([]string, error) {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if !isValidScope(scope) {
+			return nil, fmt.Errorf("scope %s tidak dikenal, gunakan: %s", scope, strings.Join(ValidScopes, ", "))
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("minimal satu scope harus diberikan")
+	}
+	return result, nil
+}
+
+// mapKey memetakan model ke response
+func (s *APIKeyService) mapKey(key *models.APIKey) response.APIKeyResponse {
+	return response.APIKeyResponse{
+		ID:         key.ID,
+		Nama:       key.Nama,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		Status:     key.Status,
+		IsUsable:   key.IsUsable(),
+		TglInsert:  key.TglInsert,
+		UserUpdate: key.UserUpdate,
+	}
+}
+
+// isValidScope checks if scope is one of ValidScopes
+func isValidScope(scope string) bool {
+	for _, valid := range ValidScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
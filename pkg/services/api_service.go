@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,110 +26,82 @@ func NewAPIService() *APIService {
 	}
 }
 
+// apiLoginResult adalah format response API login kampus
+type apiLoginResult struct {
+	Status  interface{}     `json:"status"` // Can be bool or number
+	Kode    string          `json:"kode"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"` // Array of data
+}
+
 // MahasiswaLogin calls external API for mahasiswa login
 func (s *APIService) MahasiswaLogin(username, password string) (*response.MahasiswaLoginResponse, error) {
-	// Build URL: {baseURL}/mahasiswa/login/{token}/{username}/{password}
-	url := fmt.Sprintf("%s/mahasiswa/login/%s/%s/%s",
-		config.AppConfig.APIBaseURL,
-		config.AppConfig.APIToken,
-		username,
-		password,
-	)
-
-	// Make HTTP request
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call mahasiswa API: %w", err)
+	var data []response.MahasiswaLoginResponse
+	if err := s.postLogin("mahasiswa", username, password, &data); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("login failed: %s", string(body))
-	}
-
-	// Parse response - handle status as number (1/0) or boolean
-	var result struct {
-		Status  interface{}                       `json:"status"` // Can be bool or number
-		Kode    string                            `json:"kode"`
-		Message string                            `json:"message"`
-		Data    []response.MahasiswaLoginResponse `json:"data"` // Array of data
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	// Check if data array is empty
+	if len(data) == 0 {
+		return nil, fmt.Errorf("login failed: user not found")
 	}
 
-	// Check status - handle both boolean and number
-	statusOK := false
-	switch v := result.Status.(type) {
-	case bool:
-		statusOK = v
-	case float64:
-		statusOK = v == 1
-	case int:
-		statusOK = v == 1
-	case string:
-		statusOK = v == "1" || v == "true"
-	}
+	// Return first element from data array
+	return &data[0], nil
+}
 
-	if !statusOK {
-		return nil, fmt.Errorf("login failed: %s", result.Message)
+// PegawaiLogin calls external API for pegawai login
+func (s *APIService) PegawaiLogin(username, password string) (*response.PegawaiLoginResponse, error) {
+	var data []response.PegawaiLoginResponse
+	if err := s.postLogin("pegawai", username, password, &data); err != nil {
+		return nil, err
 	}
 
 	// Check if data array is empty
-	if len(result.Data) == 0 {
+	if len(data) == 0 {
 		return nil, fmt.Errorf("login failed: user not found")
 	}
 
 	// Return first element from data array
-	return &result.Data[0], nil
+	return &data[0], nil
 }
 
-// PegawaiLogin calls external API for pegawai login
-func (s *APIService) PegawaiLogin(username, password string) (*response.PegawaiLoginResponse, error) {
-	// Build URL: {baseURL}/pegawai/login/{token}/{username}/{password}
-	url := fmt.Sprintf("%s/pegawai/login/%s/%s/%s",
-		config.AppConfig.APIBaseURL,
-		config.AppConfig.APIToken,
-		username,
-		password,
-	)
+// postLogin memanggil {baseURL}/{userType}/login dengan kredensial di body (bukan di URL)
+// agar username/password tidak tercatat di access log proxy.
+func (s *APIService) postLogin(userType, username, password string, out interface{}) error {
+	url := fmt.Sprintf("%s/%s/login", config.AppConfig.APIBaseURL, userType)
+
+	payload, err := json.Marshal(map[string]string{
+		"token":    config.AppConfig.APIToken,
+		"username": username,
+		"password": password,
+	})
+	if err != nil {
+		return err
+	}
 
 	// Make HTTP request
-	resp, err := s.client.Get(url)
+	resp, err := s.client.Post(url, "application/json", bytes.NewReader(payload))
 	if err != nil {
-		return nil, fmt.Errorf("failed to call pegawai API: %w", err)
+		return fmt.Errorf("failed to call %s API: %w", userType, err)
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("login failed: %s", string(body))
+		return fmt.Errorf("login failed: %s", string(body))
 	}
 
 	// Parse response - handle status as number (1/0) or boolean
-	var result struct {
-		Status  interface{}                     `json:"status"` // Can be bool or number
-		Kode    string                          `json:"kode"`
-		Message string                          `json:"message"`
-		Data    []response.PegawaiLoginResponse `json:"data"` // Array of data
-	}
-
+	var result apiLoginResult
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check status - handle both boolean and number
@@ -145,14 +118,15 @@ func (s *APIService) PegawaiLogin(username, password string) (*response.PegawaiL
 	}
 
 	if !statusOK {
-		return nil, fmt.Errorf("login failed: %s", result.Message)
+		return fmt.Errorf("login failed: %s", result.Message)
 	}
 
-	// Check if data array is empty
-	if len(result.Data) == 0 {
-		return nil, fmt.Errorf("login failed: user not found")
+	if len(result.Data) == 0 || string(result.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return fmt.Errorf("failed to parse response data: %w", err)
 	}
 
-	// Return first element from data array
-	return &result.Data[0], nil
+	return nil
 }
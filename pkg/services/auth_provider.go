@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"rires-be/config"
+)
+
+// Tipe user hasil autentikasi
+const (
+	UserTypeAdmin     = "admin"
+	UserTypeMahasiswa = "mahasiswa"
+	UserTypePegawai   = "pegawai"
+)
+
+var (
+	// ErrAuthSkip: provider tidak menangani username ini, lanjut ke provider berikutnya
+	ErrAuthSkip = errors.New("provider tidak menangani username ini")
+
+	// ErrInvalidCredentials: username dikenali tetapi password salah, rantai provider dihentikan
+	ErrInvalidCredentials = errors.New("username atau password salah")
+
+	// ErrAccountInactive: akun dikenali tetapi tidak aktif, rantai provider dihentikan
+	ErrAccountInactive = errors.New("akun tidak aktif")
+)
+
+// AuthIdentity adalah identitas ter-normalisasi hasil autentikasi, apapun providernya
+type AuthIdentity struct {
+	Provider    string // nama provider yang berhasil
+	UserType    string // admin, mahasiswa, pegawai
+	UserID      uint   // id db_user (khusus admin)
+	Username    string // username lokal, NIM, atau NIP
+	NIM         string
+	NIP         string
+	Nama        string
+	Email       string
+	Unit        string // prodi (mahasiswa) atau unit kerja (pegawai)
+	Fakultas    string
+	Jabatan     string
+	IDUserLevel int // level db_user (khusus admin)
+}
+
+// AuthProvider memverifikasi username/password dan mengembalikan identitas ter-normalisasi.
+// Kembalikan ErrAuthSkip jika username bukan milik provider ini.
+type AuthProvider interface {
+	Name() string
+	Authenticate(username, password string) (*AuthIdentity, error)
+}
+
+// AuthProviderFactory membuat instance provider
+type AuthProviderFactory func() (AuthProvider, error)
+
+var (
+	authProvidersMu sync.RWMutex
+	authProviders   = map[string]AuthProviderFactory{}
+)
+
+// RegisterAuthProvider mendaftarkan provider agar bisa dipilih lewat AUTH_PROVIDERS
+func RegisterAuthProvider(name string, factory AuthProviderFactory) {
+	authProvidersMu.Lock()
+	defer authProvidersMu.Unlock()
+
+	if _, exists := authProviders[name]; exists {
+		panic(fmt.Sprintf("auth provider %q already registered", name))
+	}
+	authProviders[name] = factory
+}
+
+// RegisteredAuthProviders mengembalikan nama semua provider yang terdaftar
+func RegisteredAuthProviders() []string {
+	authProvidersMu.RLock()
+	defer authProvidersMu.RUnlock()
+
+	names := make([]string, 0, len(authProviders))
+	for name := range authProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewAuthProvider membuat satu provider berdasarkan nama
+func NewAuthProvider(name string) (AuthProvider, error) {
+	authProvidersMu.RLock()
+	factory, ok := authProviders[name]
+	authProvidersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown auth provider %q (available: %s)", name, strings.Join(RegisteredAuthProviders(), ", "))
+	}
+	return factory()
+}
+
+// AuthProviderChain menjalankan provider yang aktif sesuai urutan di config
+type AuthProviderChain struct {
+	providers []AuthProvider
+}
+
+// NewAuthProviderChain membuat rantai provider dari AUTH_PROVIDERS
+func NewAuthProviderChain() (*AuthProviderChain, error) {
+	chain := &AuthProviderChain{}
+	for _, name := range strings.Split(config.AppConfig.AuthProviders, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		provider, err := NewAuthProvider(name)
+		if err != nil {
+			return nil, err
+		}
+		chain.providers = append(chain.providers, provider)
+	}
+
+	if len(chain.providers) == 0 {
+		return nil, errors.New("no auth provider enabled, check AUTH_PROVIDERS")
+	}
+	return chain, nil
+}
+
+// Authenticate mencoba setiap provider berurutan sampai ada yang berhasil.
+// Kegagalan provider eksternal tidak menghentikan rantai, kecuali ErrInvalidCredentials / ErrAccountInactive.
+func (c *AuthProviderChain) Authenticate(username, password string) (*AuthIdentity, error) {
+	for _, provider := range c.providers {
+		identity, err := provider.Authenticate(username, password)
+		if err == nil {
+			identity.Provider = provider.Name()
+			return identity, nil
+		}
+
+		if errors.Is(err, ErrAuthSkip) {
+			continue
+		}
+		if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrAccountInactive) {
+			return nil, err
+		}
+
+		log.Printf("[Auth] Provider %s failed for %s: %v", provider.Name(), username, err)
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+// Providers mengembalikan nama provider aktif sesuai urutan
+func (c *AuthProviderChain) Providers() []string {
+	names := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"strings"
+)
+
+func init() {
+	RegisterAuthProvider("campus_mahasiswa", func() (AuthProvider, error) {
+		return &CampusMahasiswaAuthProvider{apiService: NewAPIService()}, nil
+	})
+	RegisterAuthProvider("campus_pegawai", func() (AuthProvider, error) {
+		return &CampusPegawaiAuthProvider{apiService: NewAPIService()}, nil
+	})
+}
+
+// CampusMahasiswaAuthProvider mengautentikasi mahasiswa lewat API SSO kampus
+type CampusMahasiswaAuthProvider struct {
+	apiService *APIService
+}
+
+// Name returns provider name
+func (p *CampusMahasiswaAuthProvider) Name() string {
+	return "campus_mahasiswa"
+}
+
+// Authenticate login mahasiswa (NIM). Username berformat email dilewati karena pasti pegawai.
+func (p *CampusMahasiswaAuthProvider) Authenticate(username, password string) (*AuthIdentity, error) {
+	if strings.Contains(username, "@") {
+		return nil, ErrAuthSkip
+	}
+
+	mahasiswa, err := p.apiService.MahasiswaLogin(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthIdentity{
+		UserType: UserTypeMahasiswa,
+		Username: mahasiswa.NIM,
+		NIM:      mahasiswa.NIM,
+		Nama:     mahasiswa.Nama,
+		Email:    mahasiswa.Email,
+		Unit:     mahasiswa.Prodi,
+		Fakultas: mahasiswa.Fakultas,
+	}, nil
+}
+
+// CampusPegawaiAuthProvider mengautentikasi pegawai (email UMM atau NIP) lewat API SSO kampus
+type CampusPegawaiAuthProvider struct {
+	apiService *APIService
+}
+
+// Name returns provider name
+func (p *CampusPegawaiAuthProvider) Name() string {
+	return "campus_pegawai"
+}
+
+// Authenticate login pegawai
+func (p *CampusPegawaiAuthProvider) Authenticate(username, password string) (*AuthIdentity, error) {
+	pegawai, err := p.apiService.PegawaiLogin(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthIdentity{
+		UserType: UserTypePegawai,
+		Username: pegawai.NIP,
+		NIP:      pegawai.NIP,
+		Nama:     pegawai.Nama,
+		Email:    pegawai.Email,
+		Unit:     pegawai.Unit,
+		Jabatan:  pegawai.Jabatan,
+	}, nil
+}
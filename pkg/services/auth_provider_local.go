@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	RegisterAuthProvider("local", func() (AuthProvider, error) {
+		return &LocalAuthProvider{}, nil
+	})
+}
+
+// LocalAuthProvider mengautentikasi akun admin di tabel db_user
+type LocalAuthProvider struct{}
+
+// Name returns provider name
+func (p *LocalAuthProvider) Name() string {
+	return "local"
+}
+
+// Authenticate memverifikasi password db_user dan meng-upgrade hash legacy ke bcrypt
+func (p *LocalAuthProvider) Authenticate(username, password string) (*AuthIdentity, error) {
+	var user models.User
+	if err := database.DB.Where("username = ? AND hapus = 0", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuthSkip
+		}
+		return nil, err
+	}
+
+	// Check if user is active
+	if user.Status != 1 {
+		return nil, ErrAccountInactive
+	}
+
+	// Verify password (bcrypt, MySQL PASSWORD() legacy, atau plain text legacy)
+	ok, needsRehash := utils.CheckStoredPassword(user.Password, password)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Upgrade hash legacy ke bcrypt selagi password asli tersedia
+	if needsRehash {
+		p.upgradePasswordHash(user, password)
+	}
+
+	return &AuthIdentity{
+		UserType:    UserTypeAdmin,
+		UserID:      uint(user.ID),
+		Username:    user.Username,
+		Nama:        user.NamaUser,
+		IDUserLevel: user.LevelUser,
+	}, nil
+}
+
+// upgradePasswordHash menyimpan ulang password legacy/plain text sebagai bcrypt.
+// Kegagalan hanya di-log agar login tetap berjalan.
+func (p *LocalAuthProvider) upgradePasswordHash(user models.User, password string) {
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		log.Printf("[Login] Failed to hash password for user %d: %v", user.ID, err)
+		return
+	}
+
+	// UpdateColumn agar tgl_update dan user_update tidak berubah
+	if err := database.DB.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("password", hashed).Error; err != nil {
+		log.Printf("[Login] Failed to upgrade password hash for user %d: %v", user.ID, err)
+		return
+	}
+
+	log.Printf("[Login] Password hash upgraded to bcrypt for user %d", user.ID)
+}
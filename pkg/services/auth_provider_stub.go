@@ -0,0 +1,111 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"rires-be/config"
+	"rires-be/pkg/utils"
+)
+
+func init() {
+	RegisterAuthProvider("stub", NewStubAuthProvider)
+}
+
+// StubAuthUser adalah satu akun di file stub (AUTH_STUB_FILE)
+type StubAuthUser struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"` // plain text atau bcrypt
+	UserType    string `json:"user_type"`
+	NIM         string `json:"nim"`
+	NIP         string `json:"nip"`
+	Nama        string `json:"nama"`
+	Email       string `json:"email"`
+	Unit        string `json:"unit"`
+	Fakultas    string `json:"fakultas"`
+	Jabatan     string `json:"jabatan"`
+	UserID      uint   `json:"id_user"`
+	IDUserLevel int    `json:"id_user_level"`
+}
+
+// StubAuthProvider membaca akun dari file JSON, untuk development tanpa akses SSO kampus
+type StubAuthProvider struct {
+	users map[string]StubAuthUser
+}
+
+// NewStubAuthProvider membaca AUTH_STUB_FILE. Provider ini ditolak di environment production.
+func NewStubAuthProvider() (AuthProvider, error) {
+	if config.AppConfig.AppEnv == "production" {
+		return nil, errors.New("stub auth provider cannot be enabled in production")
+	}
+
+	content, err := os.ReadFile(config.AppConfig.AuthStubFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth stub file: %w", err)
+	}
+
+	var users []StubAuthUser
+	if err := json.Unmarshal(content, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse auth stub file: %w", err)
+	}
+
+	provider := &StubAuthProvider{users: make(map[string]StubAuthUser, len(users))}
+	for _, user := range users {
+		switch user.UserType {
+		case UserTypeAdmin, UserTypeMahasiswa, UserTypePegawai:
+		default:
+			return nil, fmt.Errorf("auth stub user %q has invalid user_type %q", user.Username, user.UserType)
+		}
+		provider.users[strings.ToLower(user.Username)] = user
+	}
+
+	return provider, nil
+}
+
+// Name returns provider name
+func (p *StubAuthProvider) Name() string {
+	return "stub"
+}
+
+// Authenticate mencocokkan username/password dengan isi file stub
+func (p *StubAuthProvider) Authenticate(username, password string) (*AuthIdentity, error) {
+	user, ok := p.users[strings.ToLower(username)]
+	if !ok {
+		return nil, ErrAuthSkip
+	}
+
+	if ok, _ := utils.CheckStoredPassword(user.Password, password); !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	identity := &AuthIdentity{
+		UserType:    user.UserType,
+		UserID:      user.UserID,
+		Username:    user.Username,
+		NIM:         user.NIM,
+		NIP:         user.NIP,
+		Nama:        user.Nama,
+		Email:       user.Email,
+		Unit:        user.Unit,
+		Fakultas:    user.Fakultas,
+		Jabatan:     user.Jabatan,
+		IDUserLevel: user.IDUserLevel,
+	}
+
+	// Samakan username dengan provider kampus (NIM / NIP)
+	switch user.UserType {
+	case UserTypeMahasiswa:
+		if user.NIM != "" {
+			identity.Username = user.NIM
+		}
+	case UserTypePegawai:
+		if user.NIP != "" {
+			identity.Username = user.NIP
+		}
+	}
+
+	return identity, nil
+}
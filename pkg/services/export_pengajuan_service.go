@@ -0,0 +1,334 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/spreadsheet"
+)
+
+// Kolom opsional export (query include=anggota,reviewer,...)
+const (
+	ExportIncludeAnggota       = "anggota"
+	ExportIncludeReviewer      = "reviewer"
+	ExportIncludeCatatanReview = "catatan_review"
+	ExportIncludeParameter     = "parameter"
+)
+
+// exportBatchSize: jumlah pengajuan yang dibaca & ditulis per batch
+const exportBatchSize = 500
+
+// ExportOptions menentukan kolom opsional yang ikut diekspor
+type ExportOptions struct {
+	Anggota       bool
+	Reviewer      bool
+	CatatanReview bool
+	Parameter     bool
+}
+
+// ParseExportInclude membaca daftar kolom opsional dipisah koma
+func ParseExportInclude(value string) (ExportOptions, error) {
+	var options ExportOptions
+	for _, item := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(item)) {
+		case "":
+		case ExportIncludeAnggota:
+			options.Anggota = true
+		case ExportIncludeReviewer:
+			options.Reviewer = true
+		case ExportIncludeCatatanReview:
+			options.CatatanReview = true
+		case ExportIncludeParameter:
+			options.Parameter = true
+		default:
+			return options, fmt.Errorf("include %s tidak dikenal (gunakan %s, %s, %s, %s)", item,
+				ExportIncludeAnggota, ExportIncludeReviewer, ExportIncludeCatatanReview, ExportIncludeParameter)
+		}
+	}
+	return options, nil
+}
+
+// ExportPengajuanService exports the admin pengajuan list to CSV/XLSX
+type ExportPengajuanService struct {
+	pengajuan       *PengajuanService
+	externalService *ExternalDataService
+}
+
+// NewExportPengajuanService creates a new export service
+func NewExportPengajuanService() *ExportPengajuanService {
+	return &ExportPengajuanService{
+		pengajuan:       NewPengajuanService(),
+		externalService: NewExternalDataService(),
+	}
+}
+
+// exportBatch adalah data pendukung untuk satu batch pengajuan
+type exportBatch struct {
+	kategori  map[int]string
+	anggota   map[int][]models.PengajuanAnggota
+	reviewer  map[int]string // id_pegawai -> nama
+	namaKetua map[string]string
+}
+
+// Export menulis semua pengajuan yang cocok dengan filter GetAllPengajuan (tanpa paginasi)
+// ke w, dibaca per batch agar tidak dimuat sekaligus
+func (s *ExportPengajuanService) Export(w io.Writer, format string, filters map[string]interface{}, options ExportOptions) error {
+	// Kolom parameter form: gabungan nama_parameter aktif (kategori terfilter saja jika ada)
+	var parameters []string
+	if options.Parameter {
+		var err error
+		if parameters, err = s.parameterColumns(filters); err != nil {
+			return err
+		}
+	}
+
+	writer, err := spreadsheet.NewWriter(w, format)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteRow(exportHeader(options, parameters)); err != nil {
+		return err
+	}
+
+	// Keyset pagination (id < id terakhir), bukan OFFSET: pengajuan yang masuk selama export
+	// berjalan tidak menggeser halaman sehingga tidak ada baris ganda atau terlewat
+	no := 0
+	lastID := 0
+	for {
+		query := s.pengajuan.filterPengajuan(filters)
+		if lastID > 0 {
+			query = query.Where("id < ?", lastID)
+		}
+		var pengajuanList []models.Pengajuan
+		if err := query.Order("id DESC").Limit(exportBatchSize).Find(&pengajuanList).Error; err != nil {
+			return fmt.Errorf("failed to get pengajuan list: %w", err)
+		}
+		if len(pengajuanList) == 0 {
+			break
+		}
+		lastID = pengajuanList[len(pengajuanList)-1].ID
+
+		batch, err := s.loadBatch(pengajuanList, options)
+		if err != nil {
+			return err
+		}
+		for i := range pengajuanList {
+			no++
+			if err := writer.WriteRow(exportRow(no, &pengajuanList[i], batch, options, parameters)); err != nil {
+				return err
+			}
+		}
+
+		if len(pengajuanList) < exportBatchSize {
+			break
+		}
+	}
+
+	return writer.Close()
+}
+
+// parameterColumns mengambil nama_parameter aktif, urut kategori lalu urutan form
+func (s *ExportPengajuanService) parameterColumns(filters map[string]interface{}) ([]string, error) {
+	query := database.DB.Model(&models.ParameterForm{}).Where("hapus = ? AND status = ?", 0, 1)
+	if idKategori, _ := filters["id_kategori"].(int); idKategori > 0 {
+		query = query.Where("id_kategori = ?", idKategori)
+	}
+
+	var parameters []models.ParameterForm
+	if err := query.Order("id_kategori ASC, urutan ASC, id ASC").Find(&parameters).Error; err != nil {
+		return nil, fmt.Errorf("failed to load parameter form: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var columns []string
+	for _, parameter := range parameters {
+		if !seen[parameter.NamaParameter] {
+			seen[parameter.NamaParameter] = true
+			columns = append(columns, parameter.NamaParameter)
+		}
+	}
+	return columns, nil
+}
+
+// loadBatch memuat kategori, anggota, nama reviewer dan nama ketua untuk satu batch sekaligus
+func (s *ExportPengajuanService) loadBatch(pengajuanList []models.Pengajuan, options ExportOptions) (*exportBatch, error) {
+	batch := &exportBatch{
+		kategori:  make(map[int]string),
+		anggota:   make(map[int][]models.PengajuanAnggota),
+		reviewer:  make(map[int]string),
+		namaKetua: make(map[string]string),
+	}
+
+	ids := make([]int, 0, len(pengajuanList))
+	kategoriIDs := make([]int, 0)
+	reviewerIDs := make([]int, 0)
+	nimTanpaNama := make([]string, 0)
+	for _, pengajuan := range pengajuanList {
+		ids = append(ids, pengajuan.ID)
+		kategoriIDs = append(kategoriIDs, pengajuan.IDKategori)
+		if pengajuan.IDReviewerJudul != nil {
+			reviewerIDs = append(reviewerIDs, *pengajuan.IDReviewerJudul)
+		}
+		if pengajuan.IDReviewerProposal != nil {
+			reviewerIDs = append(reviewerIDs, *pengajuan.IDReviewerProposal)
+		}
+		if pengajuan.NamaKetua == "" {
+			nimTanpaNama = append(nimTanpaNama, pengajuan.NIMKetua)
+		}
+	}
+
+	var kategoriList []models.KategoriPKM
+	if err := database.DB.Where("id IN ?", kategoriIDs).Find(&kategoriList).Error; err != nil {
+		return nil, fmt.Errorf("failed to load kategori: %w", err)
+	}
+	for _, kategori := range kategoriList {
+		batch.kategori[kategori.ID] = kategori.NamaKategori
+	}
+
+	if options.Anggota {
+		var anggotaList []models.PengajuanAnggota
+		if err := database.DB.Where("id_pengajuan IN ? AND hapus = ?", ids, 0).
+			Order("id_pengajuan ASC, urutan ASC").
+			Find(&anggotaList).Error; err != nil {
+			return nil, fmt.Errorf("failed to load anggota: %w", err)
+		}
+		for _, anggota := range anggotaList {
+			batch.anggota[anggota.IDPengajuan] = append(batch.anggota[anggota.IDPengajuan], anggota)
+		}
+	}
+
+	// Nama reviewer dari db_reviewer (sudah bergelar), fallback SIMPEG
+	if options.Reviewer && len(reviewerIDs) > 0 {
+		var reviewers []models.Reviewer
+		if err := database.DB.Where("id_pegawai IN ? AND hapus = ?", reviewerIDs, 0).Find(&reviewers).Error; err != nil {
+			return nil, fmt.Errorf("failed to load reviewer: %w", err)
+		}
+		for _, reviewer := range reviewers {
+			batch.reviewer[reviewer.IDPegawai] = reviewer.NamaReviewer
+		}
+
+		var missing []int
+		for _, id := range reviewerIDs {
+			if _, ok := batch.reviewer[id]; !ok {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			if pegawaiList, err := s.externalService.GetPegawaiByIDs(missing); err == nil {
+				for i := range pegawaiList {
+					batch.reviewer[pegawaiList[i].ID] = pegawaiList[i].GetNamaLengkap()
+				}
+			}
+		}
+	}
+
+	// Data lama tanpa nama_ketua: ambil dari NEOMAA (diabaikan jika NEOMAA tidak tersedia)
+	if len(nimTanpaNama) > 0 {
+		if mahasiswaList, err := s.externalService.GetMahasiswaByNIMs(nimTanpaNama); err == nil {
+			for _, mahasiswa := range mahasiswaList {
+				batch.namaKetua[mahasiswa.KodeSiswa] = mahasiswa.NamaSiswa
+			}
+		}
+	}
+
+	return batch, nil
+}
+
+// exportHeader menyusun judul kolom
+func exportHeader(options ExportOptions, parameters []string) []interface{} {
+	header := []interface{}{
+		"No", "Kode Pengajuan", "Judul", "Kategori", "NIM Ketua", "Nama Ketua", "Email Ketua", "No HP Ketua",
+		"Program Studi", "Fakultas", "Dosen Pembimbing", "Tahun", "Tgl Pengajuan",
+		"Status Judul", "Status Proposal", "Status Final",
+	}
+	if options.Anggota {
+		header = append(header, "Jumlah Anggota", "Anggota")
+	}
+	if options.Reviewer {
+		header = append(header, "Reviewer Judul", "Reviewer Proposal")
+	}
+	if options.CatatanReview {
+		header = append(header, "Catatan Review Judul", "Tgl Review Judul", "Catatan Review Proposal", "Tgl Review Proposal")
+	}
+	// Sama dengan format kolom import
+	for _, nama := range parameters {
+		header = append(header, paramColumnPrefix+nama)
+	}
+	return header
+}
+
+// exportRow menyusun satu baris pengajuan
+func exportRow(no int, p *models.Pengajuan, batch *exportBatch, options ExportOptions, parameters []string) []interface{} {
+	namaKetua := p.NamaKetua
+	if namaKetua == "" {
+		namaKetua = batch.namaKetua[p.NIMKetua]
+	}
+
+	row := []interface{}{
+		no, p.KodePengajuan, p.Judul, batch.kategori[p.IDKategori], p.NIMKetua, namaKetua, p.EmailKetua, p.NoHPKetua,
+		p.ProgramStudi, p.Fakultas, p.DosenPembimbing, p.Tahun, p.TglPengajuan,
+		p.StatusJudul, p.StatusProposal, p.StatusFinal,
+	}
+
+	if options.Anggota {
+		// Anggota selain ketua: "NIM - Nama; NIM - Nama"
+		var anggota []string
+		for _, a := range batch.anggota[p.ID] {
+			if a.IsKetua == 1 {
+				continue
+			}
+			if a.NamaAnggota != "" {
+				anggota = append(anggota, a.NIMAnggota+" - "+a.NamaAnggota)
+			} else {
+				anggota = append(anggota, a.NIMAnggota)
+			}
+		}
+		row = append(row, len(batch.anggota[p.ID]), strings.Join(anggota, "; "))
+	}
+
+	if options.Reviewer {
+		row = append(row, reviewerName(batch, p.IDReviewerJudul), reviewerName(batch, p.IDReviewerProposal))
+	}
+
+	if options.CatatanReview {
+		row = append(row, p.CatatanReviewJudul, p.TglReviewJudul, p.CatatanReviewProposal, p.TglReviewProposal)
+	}
+
+	if len(parameters) > 0 {
+		var answers map[string]interface{}
+		if p.ParameterData != "" {
+			json.Unmarshal([]byte(p.ParameterData), &answers)
+		}
+		for _, nama := range parameters {
+			row = append(row, parameterAnswer(answers[nama]))
+		}
+	}
+
+	return row
+}
+
+// reviewerName mengembalikan nama reviewer yang di-assign (kosong jika belum)
+func reviewerName(batch *exportBatch, idPegawai *int) string {
+	if idPegawai == nil {
+		return ""
+	}
+	return batch.reviewer[*idPegawai]
+}
+
+// parameterAnswer mengubah jawaban parameter_data menjadi teks sel
+func parameterAnswer(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
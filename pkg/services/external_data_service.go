@@ -94,6 +94,20 @@ func (s *ExternalDataService) GetPegawaiByID(id int) (*external.Pegawai, error)
 	return &pegawai, nil
 }
 
+// GetPegawaiByEmail fetches pegawai data from SIMPEG by email UMM
+func (s *ExternalDataService) GetPegawaiByEmail(email string) (*external.Pegawai, error) {
+	if database.DBSimpeg == nil {
+		return nil, errors.New("SIMPEG database not connected")
+	}
+
+	var pegawai external.Pegawai
+	if err := database.DBSimpeg.Where("email_umm = ? AND hapus = 1", email).First(&pegawai).Error; err != nil {
+		return nil, err
+	}
+
+	return &pegawai, nil
+}
+
 // GetPegawaiByIDs fetches multiple pegawai by IDs
 func (s *ExternalDataService) GetPegawaiByIDs(ids []int) ([]external.Pegawai, error) {
 	if database.DBSimpeg == nil {
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"rires-be/config"
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// ImpersonationService handles admin "view as user" sessions
+type ImpersonationService struct {
+	roleService      *RoleService
+	reviewerIdentity *ReviewerIdentityService
+	tokenService     *TokenService
+}
+
+// NewImpersonationService creates a new impersonation service
+func NewImpersonationService() *ImpersonationService {
+	return &ImpersonationService{
+		roleService:      NewRoleService(),
+		reviewerIdentity: NewReviewerIdentityService(),
+		tokenService:     NewTokenService(),
+	}
+}
+
+// Start menerbitkan token impersonation untuk mahasiswa / reviewer dan mencatatnya di audit log.
+// Token tidak punya refresh token dan hanya berlaku selama durasi yang diminta.
+func (s *ImpersonationService) Start(admin *utils.JWTClaims, req *request.ImpersonateRequest, ip, userAgent string) (*response.ImpersonationResponse, error) {
+	// 1. Admin tidak boleh impersonate dari token impersonation
+	if admin == nil || admin.UserType != UserTypeAdmin {
+		return nil, errors.New("hanya admin yang dapat melakukan impersonation")
+	}
+	if admin.Impersonator != nil {
+		return nil, errors.New("hentikan impersonation yang sedang berjalan terlebih dahulu")
+	}
+
+	// 2. Susun claims user target
+	claims, targetID, user, err := s.targetClaims(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Hitung durasi
+	ttl := s.maxDuration()
+	if req.DurasiMenit > 0 && time.Duration(req.DurasiMenit)*time.Minute < ttl {
+		ttl = time.Duration(req.DurasiMenit) * time.Minute
+	}
+
+	// 4. Catat sesi lalu terbitkan token dengan id log di claims
+	now := time.Now()
+	var token string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		entry := &models.ImpersonationLog{
+			IDAdmin:       int(admin.UserID),
+			UsernameAdmin: admin.Username,
+			TargetType:    req.UserType,
+			TargetID:      targetID,
+			TargetNama:    claims.UserData["nama"],
+			Alasan:        req.Alasan,
+			IPAddress:     truncate(ip, 64),
+			UserAgent:     truncate(userAgent, 255),
+			MulaiAt:       now,
+			BerakhirAt:    now.Add(ttl),
+			TglInsert:     &now,
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+
+		claims.Impersonator = &utils.Impersonator{
+			UserID:   admin.UserID,
+			Username: admin.Username,
+			IDLog:    entry.ID,
+		}
+
+		signed, err := utils.SignAccessTokenWithTTL(claims, ttl)
+		if err != nil {
+			return err
+		}
+		token = signed
+
+		return tx.Model(entry).Update("jti", claims.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.ImpersonationResponse{
+		Token:     token,
+		UserType:  claims.UserType,
+		User:      user,
+		ExpiresIn: int(ttl.Seconds()),
+		ExpiresAt: claims.ExpiresAt.Time,
+		IDLog:     claims.Impersonator.IDLog,
+	}, nil
+}
+
+// Stop mengakhiri sesi impersonation dan mencabut tokennya
+func (s *ImpersonationService) Stop(claims *utils.JWTClaims) error {
+	if claims == nil || claims.Impersonator == nil {
+		return errors.New("token ini bukan token impersonation")
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&models.ImpersonationLog{}).
+		Where("id = ? AND selesai_at IS NULL", claims.Impersonator.IDLog).
+		Update("selesai_at", now).Error; err != nil {
+		return err
+	}
+
+	return s.tokenService.RevokeAccessToken(claims, "impersonation dihentikan")
+}
+
+// RecordBlocked menambah counter aksi tulis yang diblokir selama impersonation
+func (s *ImpersonationService) RecordBlocked(idLog int) error {
+	return database.DB.Model(&models.ImpersonationLog{}).
+		Where("id = ?", idLog).
+		UpdateColumn("jumlah_diblokir", gorm.Expr("jumlah_diblokir + 1")).Error
+}
+
+// GetLogs mengembalikan audit trail impersonation, terbaru di atas
+func (s *ImpersonationService) GetLogs(idAdmin int, targetID string, activeOnly bool) ([]response.ImpersonationLogResponse, error) {
+	query := database.DB.Model(&models.ImpersonationLog{})
+	if idAdmin > 0 {
+		query = query.Where("id_admin = ?", idAdmin)
+	}
+	if targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if activeOnly {
+		query = query.Where("selesai_at IS NULL AND berakhir_at > ?", time.Now())
+	}
+
+	var logs []models.ImpersonationLog
+	if err := query.Order("mulai_at DESC").Limit(500).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.ImpersonationLogResponse, 0, len(logs))
+	for _, entry := range logs {
+		result = append(result, response.ImpersonationLogResponse{
+			ID:             entry.ID,
+			IDAdmin:        entry.IDAdmin,
+			UsernameAdmin:  entry.UsernameAdmin,
+			TargetType:     entry.TargetType,
+			TargetID:       entry.TargetID,
+			TargetNama:     entry.TargetNama,
+			Alasan:         entry.Alasan,
+			IPAddress:      entry.IPAddress,
+			UserAgent:      entry.UserAgent,
+			MulaiAt:        entry.MulaiAt,
+			BerakhirAt:     entry.BerakhirAt,
+			SelesaiAt:      entry.SelesaiAt,
+			JumlahDiblokir: entry.JumlahDiblokir,
+			IsActive:       entry.IsActive(),
+		})
+	}
+	return result, nil
+}
+
+// targetClaims menyusun claims yang sama dengan login asli user target
+func (s *ImpersonationService) targetClaims(req *request.ImpersonateRequest) (*utils.JWTClaims, string, interface{}, error) {
+	switch req.UserType {
+	case UserTypeMahasiswa:
+		nim := strings.TrimSpace(req.NIM)
+		if nim == "" {
+			return nil, "", nil, errors.New("nim wajib diisi untuk impersonation mahasiswa")
+		}
+
+		claims, user, err := s.roleService.mahasiswaClaims(nim)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return claims, nim, user, nil
+
+	case UserTypePegawai:
+		if req.IDReviewer <= 0 {
+			return nil, "", nil, errors.New("id_reviewer wajib diisi untuk impersonation reviewer")
+		}
+
+		reviewer, err := s.reviewerIdentity.ResolveByID(req.IDReviewer)
+		if err != nil {
+			if errors.Is(err, ErrNotReviewer) {
+				return nil, "", nil, errors.New("reviewer tidak ditemukan atau tidak aktif")
+			}
+			return nil, "", nil, err
+		}
+
+		claims, user := NewReviewerClaims(reviewer, "", "", "")
+		return claims, strconv.Itoa(reviewer.IDReviewer), user, nil
+	}
+
+	return nil, "", nil, errors.New("user_type harus mahasiswa atau pegawai")
+}
+
+// maxDuration membaca batas umur token impersonation dari config
+func (s *ImpersonationService) maxDuration() time.Duration {
+	minutes, err := strconv.Atoi(config.AppConfig.ImpersonationMaxMinutes)
+	if err != nil || minutes < 1 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
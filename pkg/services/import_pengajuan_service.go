@@ -0,0 +1,466 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/internal/models/external"
+	"rires-be/pkg/database"
+	"rires-be/pkg/spreadsheet"
+	"rires-be/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Mode import pengajuan
+const (
+	ImportModeDryRun = "dry-run"
+	ImportModeCommit = "commit"
+)
+
+// maxImportRows membatasi jumlah baris data per file
+const maxImportRows = 500
+
+// paramColumnPrefix: kolom jawaban parameter form, mis. "param:luaran"
+const paramColumnPrefix = "param:"
+
+var (
+	ErrImportEmpty    = errors.New("file import tidak memiliki baris data")
+	ErrImportTooLarge = fmt.Errorf("file import maksimal %d baris data", maxImportRows)
+)
+
+// importColumns adalah kolom yang dikenali (header tidak peka huruf besar/kecil)
+var importColumns = map[string]bool{
+	"judul":               true,
+	"kategori":            true, // id, nama atau kode kategori
+	"nim_ketua":           true,
+	"nim_anggota":         true, // dipisah titik koma, koma atau spasi
+	"id_dosen_pembimbing": true,
+	"email_ketua":         true,
+	"no_hp_ketua":         true,
+	"program_studi":       true,
+	"fakultas":            true,
+}
+
+// importRequiredColumns wajib ada di header
+var importRequiredColumns = []string{"judul", "kategori", "nim_ketua", "id_dosen_pembimbing"}
+
+// ImportPengajuanService imports pengajuan from CSV/XLSX with the same validation as CreateJudulPKM
+type ImportPengajuanService struct {
+	pengajuan       *PengajuanService
+	kode            *KodePengajuanService
+	externalService *ExternalDataService
+	validate        *validator.Validate
+}
+
+// NewImportPengajuanService creates a new import service
+func NewImportPengajuanService() *ImportPengajuanService {
+	return &ImportPengajuanService{
+		pengajuan:       NewPengajuanService(),
+		kode:            NewKodePengajuanService(),
+		externalService: NewExternalDataService(),
+		validate:        validator.New(),
+	}
+}
+
+// importRow adalah satu baris data file beserta hasil validasinya
+type importRow struct {
+	result   *response.ImportPengajuanRowResponse
+	prepared *preparedPengajuan
+}
+
+// importHeader adalah posisi kolom di file
+type importHeader struct {
+	columns map[string]int // nama kolom -> index
+	params  []importParam  // kolom param:<nama_parameter>, urut sesuai file
+}
+
+// importParam adalah kolom jawaban parameter form
+type importParam struct {
+	nama  string
+	index int
+}
+
+// importLookup adalah data referensi yang dimuat sekali per file
+type importLookup struct {
+	kategori   []models.KategoriPKM
+	kode       map[int]string
+	parameters map[int][]models.ParameterForm // id_kategori -> parameter aktif
+	mahasiswa  map[string]external.Mahasiswa  // NIM -> data NEOMAA
+}
+
+// Import membaca file lalu memvalidasi setiap baris seperti CreateJudulPKM oleh admin
+// (ditambah cek NIM di NEOMAA). Mode commit menyimpan semua baris valid dalam satu transaksi;
+// baris tidak valid dilewati dan dilaporkan.
+func (s *ImportPengajuanService) Import(filename string, file io.Reader, mode string) (*response.ImportPengajuanResponse, error) {
+	if mode != ImportModeDryRun && mode != ImportModeCommit {
+		return nil, fmt.Errorf("mode harus %s atau %s", ImportModeDryRun, ImportModeCommit)
+	}
+
+	rows, err := spreadsheet.ReadRows(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	header, err := parseImportHeader(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// Baris data (nomor baris mengikuti file, header = baris 1); baris kosong dilewati
+	type dataRow struct {
+		baris int
+		cells []string
+	}
+	var dataRows []dataRow
+	for i, cells := range rows[1:] {
+		if !spreadsheet.IsEmptyRow(cells) {
+			dataRows = append(dataRows, dataRow{baris: i + 2, cells: cells})
+		}
+	}
+	if len(dataRows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(dataRows) > maxImportRows {
+		return nil, ErrImportTooLarge
+	}
+
+	lookup, err := s.loadLookup(header, rows[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &response.ImportPengajuanResponse{
+		Mode:       mode,
+		TotalBaris: len(dataRows),
+		Rows:       make([]response.ImportPengajuanRowResponse, 0, len(dataRows)),
+	}
+
+	// Validasi berurutan: batas ketua/anggota dan judul ganda ikut menghitung baris valid sebelumnya
+	pending := utils.NewPendingParticipation()
+	judulBaris := make(map[string]int)
+	imported := make([]importRow, 0, len(dataRows))
+	for _, data := range dataRows {
+		row := s.validateRow(data.baris, data.cells, header, lookup, pending, judulBaris)
+		if row.prepared != nil {
+			result.Valid++
+		} else {
+			result.Invalid++
+		}
+		imported = append(imported, row)
+	}
+
+	if mode == ImportModeCommit && result.Valid > 0 {
+		if err := s.commit(imported); err != nil {
+			return nil, err
+		}
+		result.Imported = result.Valid
+	}
+
+	for _, row := range imported {
+		result.Rows = append(result.Rows, *row.result)
+	}
+	return result, nil
+}
+
+// commit menyimpan semua baris valid dalam satu transaksi
+func (s *ImportPengajuanService) commit(rows []importRow) error {
+	tx := database.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var saved []*models.Pengajuan
+	for _, row := range rows {
+		if row.prepared == nil {
+			continue
+		}
+		pengajuan, err := s.pengajuan.insertPengajuan(tx, row.prepared)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("baris %d: %w", row.result.Baris, err)
+		}
+		row.result.IDPengajuan = pengajuan.ID
+		row.result.KodePengajuan = pengajuan.KodePengajuan
+		saved = append(saved, pengajuan)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Kemiripan judul dihitung setelah commit, sama seperti CreateJudulPKM, tetapi dengan
+	// satu index korpus untuk semua baris
+	if err := s.pengajuan.kemiripan.RefreshBatch(saved); err != nil {
+		log.Printf("Failed to check judul similarity for imported pengajuan: %v", err)
+	}
+	return nil
+}
+
+// validateRow membangun CreatePengajuanRequest dari satu baris lalu menjalankan validasi CreateJudulPKM
+func (s *ImportPengajuanService) validateRow(baris int, cells []string, header *importHeader, lookup *importLookup, pending *utils.PendingParticipation, judulBaris map[string]int) importRow {
+	cell := func(column string) string {
+		if i, ok := header.columns[column]; ok && i < len(cells) {
+			return cells[i]
+		}
+		return ""
+	}
+
+	rowResult := &response.ImportPengajuanRowResponse{
+		Baris:    baris,
+		Judul:    cell("judul"),
+		NIMKetua: cell("nim_ketua"),
+	}
+	row := importRow{result: rowResult}
+	fail := func(err error) importRow {
+		rowResult.Errors = append(rowResult.Errors, err.Error())
+		return row
+	}
+
+	nimKetua := rowResult.NIMKetua
+	if nimKetua == "" {
+		fail(errors.New("nim_ketua wajib diisi"))
+	}
+
+	kategori, err := lookup.findKategori(cell("kategori"))
+	if err != nil {
+		fail(err)
+	}
+
+	var idPembimbing int
+	if value := cell("id_dosen_pembimbing"); value != "" {
+		if idPembimbing, err = strconv.Atoi(value); err != nil || idPembimbing < 1 {
+			fail(fmt.Errorf("id_dosen_pembimbing tidak valid: %s", value))
+		}
+	}
+
+	// Jawaban parameter form sesuai kategori
+	var parameterData map[string]interface{}
+	if kategori != nil {
+		for _, param := range header.params {
+			if param.index >= len(cells) || cells[param.index] == "" {
+				continue
+			}
+			parameter := lookup.findParameter(kategori.ID, param.nama)
+			if parameter == nil {
+				fail(fmt.Errorf("parameter %s tidak ada pada kategori %s", param.nama, kategori.NamaKategori))
+				continue
+			}
+			if parameterData == nil {
+				parameterData = make(map[string]interface{})
+			}
+			parameterData[parameter.NamaParameter] = cells[param.index]
+		}
+	}
+
+	// NIM wajib terdaftar di NEOMAA (CreateJudulPKM melewati cek ini untuk admin)
+	anggotaNIMs := splitNIMs(cell("nim_anggota"))
+	var missing []string
+	for _, nim := range append([]string{nimKetua}, anggotaNIMs...) {
+		if _, ok := lookup.mahasiswa[nim]; nim != "" && !ok {
+			missing = append(missing, nim)
+		}
+	}
+	if len(missing) > 0 {
+		fail(fmt.Errorf("NIM tidak ditemukan di database mahasiswa: %s", strings.Join(missing, ", ")))
+	}
+
+	if len(rowResult.Errors) > 0 {
+		return row
+	}
+
+	ketua := lookup.mahasiswa[nimKetua]
+	req := &request.CreatePengajuanRequest{
+		IDKategori:        kategori.ID,
+		Judul:             rowResult.Judul,
+		NIMKetua:          nimKetua,
+		NamaKetua:         ketua.NamaSiswa,
+		EmailKetua:        cell("email_ketua"),
+		NoHPKetua:         cell("no_hp_ketua"),
+		ProgramStudi:      cell("program_studi"),
+		Fakultas:          cell("fakultas"),
+		IDDosenPembimbing: idPembimbing,
+		ParameterData:     parameterData,
+	}
+	if req.NoHPKetua == "" {
+		req.NoHPKetua = ketua.HPSiswa
+	}
+	for _, nim := range anggotaNIMs {
+		req.Anggota = append(req.Anggota, request.AnggotaRequest{
+			NIM:  nim,
+			Nama: lookup.mahasiswa[nim].NamaSiswa,
+		})
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return fail(err)
+	}
+
+	// Judul yang sama persis di file yang sama
+	judulKey := strings.ToLower(strings.Join(strings.Fields(req.Judul), " "))
+	if sebelumnya, ok := judulBaris[judulKey]; ok {
+		return fail(fmt.Errorf("judul sama dengan baris %d", sebelumnya))
+	}
+
+	prepared, err := s.pengajuan.preparePengajuan(req, nimKetua, true, pending)
+	if err != nil {
+		return fail(err)
+	}
+
+	judulBaris[judulKey] = baris
+	pending.Add(s.pengajuan.convertToAnggotaModels(req.Anggota), fmt.Sprintf("baris %d", baris))
+	row.prepared = prepared
+	rowResult.Valid = true
+	return row
+}
+
+// loadLookup memuat kategori, kode kategori, parameter form dan data NEOMAA semua NIM di file
+func (s *ImportPengajuanService) loadLookup(header *importHeader, rows [][]string) (*importLookup, error) {
+	lookup := &importLookup{
+		parameters: make(map[int][]models.ParameterForm),
+		mahasiswa:  make(map[string]external.Mahasiswa),
+	}
+
+	if err := database.DB.Where("hapus = ?", 0).Find(&lookup.kategori).Error; err != nil {
+		return nil, fmt.Errorf("failed to load kategori: %w", err)
+	}
+
+	kode, err := s.kode.KodeKategoriMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kode kategori: %w", err)
+	}
+	lookup.kode = kode
+
+	if len(header.params) > 0 {
+		var parameters []models.ParameterForm
+		if err := database.DB.Where("hapus = ? AND status = ?", 0, 1).Find(&parameters).Error; err != nil {
+			return nil, fmt.Errorf("failed to load parameter form: %w", err)
+		}
+		for _, parameter := range parameters {
+			lookup.parameters[parameter.IDKategori] = append(lookup.parameters[parameter.IDKategori], parameter)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var nims []string
+	for _, cells := range rows {
+		candidates := []string{}
+		if i := header.columns["nim_ketua"]; i < len(cells) {
+			candidates = append(candidates, cells[i])
+		}
+		if i, ok := header.columns["nim_anggota"]; ok && i < len(cells) {
+			candidates = append(candidates, splitNIMs(cells[i])...)
+		}
+		for _, nim := range candidates {
+			if nim != "" && !seen[nim] {
+				seen[nim] = true
+				nims = append(nims, nim)
+			}
+		}
+	}
+	if len(nims) > 0 {
+		mahasiswaList, err := s.externalService.GetMahasiswaByNIMs(nims)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch mahasiswa data: %w", err)
+		}
+		for _, mahasiswa := range mahasiswaList {
+			lookup.mahasiswa[mahasiswa.KodeSiswa] = mahasiswa
+		}
+	}
+
+	return lookup, nil
+}
+
+// findKategori mencari kategori berdasarkan id, nama atau kode singkat
+func (l *importLookup) findKategori(value string) (*models.KategoriPKM, error) {
+	if value == "" {
+		return nil, errors.New("kategori wajib diisi")
+	}
+
+	id, _ := strconv.Atoi(value)
+	for i := range l.kategori {
+		kategori := &l.kategori[i]
+		kode := l.kode[kategori.ID]
+		if kode == "" {
+			kode = utils.DeriveKodeKategori(kategori.NamaKategori)
+		}
+		if kategori.ID == id || strings.EqualFold(kategori.NamaKategori, value) || strings.EqualFold(kode, value) {
+			return kategori, nil
+		}
+	}
+	return nil, fmt.Errorf("kategori %s tidak ditemukan", value)
+}
+
+// findParameter mencari parameter aktif kategori berdasarkan nama_parameter
+func (l *importLookup) findParameter(idKategori int, nama string) *models.ParameterForm {
+	for i, parameter := range l.parameters[idKategori] {
+		if strings.EqualFold(parameter.NamaParameter, nama) {
+			return &l.parameters[idKategori][i]
+		}
+	}
+	return nil
+}
+
+// parseImportHeader memetakan header ke index kolom dan menolak kolom yang tidak dikenal
+func parseImportHeader(cells []string) (*importHeader, error) {
+	header := &importHeader{
+		columns: make(map[string]int),
+	}
+
+	var unknown []string
+	for i, cell := range cells {
+		if cell == "" {
+			continue
+		}
+		if len(cell) > len(paramColumnPrefix) && strings.EqualFold(cell[:len(paramColumnPrefix)], paramColumnPrefix) {
+			header.params = append(header.params, importParam{
+				nama:  strings.TrimSpace(cell[len(paramColumnPrefix):]),
+				index: i,
+			})
+			continue
+		}
+
+		column := strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(cell, "-", " ")), "_"))
+		if column == "id_kategori" {
+			column = "kategori"
+		}
+		if !importColumns[column] {
+			unknown = append(unknown, cell)
+			continue
+		}
+		if _, exists := header.columns[column]; exists {
+			return nil, fmt.Errorf("kolom %s muncul lebih dari satu kali", column)
+		}
+		header.columns[column] = i
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("kolom tidak dikenal: %s (jawaban parameter memakai kolom %s<nama_parameter>)",
+			strings.Join(unknown, ", "), paramColumnPrefix)
+	}
+	for _, column := range importRequiredColumns {
+		if _, ok := header.columns[column]; !ok {
+			return nil, fmt.Errorf("kolom %s wajib ada", column)
+		}
+	}
+	return header, nil
+}
+
+// splitNIMs memecah daftar NIM anggota
+func splitNIMs(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ';' || r == ',' || unicode.IsSpace(r)
+	})
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"rires-be/config"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/workflow"
+
+	"gorm.io/gorm"
+)
+
+// Status undangan anggota
+const (
+	UndanganPending   = "PENDING"
+	UndanganAccepted  = "ACCEPTED"
+	UndanganDeclined  = "DECLINED"
+	UndanganExpired   = "EXPIRED"
+	UndanganCancelled = "CANCELLED" // anggota dikeluarkan ketua sebelum menjawab
+)
+
+// Aksi timeline untuk undangan (bukan transisi status, tidak terdaftar di workflow)
+const (
+	EventAcceptInvitation  workflow.Action = "ACCEPT_INVITATION"
+	EventDeclineInvitation workflow.Action = "DECLINE_INVITATION"
+	EventExpireInvitation  workflow.Action = "EXPIRE_INVITATION"
+)
+
+// ErrInvitationNotFound dikembalikan jika undangan tidak ada atau bukan milik mahasiswa
+var ErrInvitationNotFound = errors.New("undangan tidak ditemukan")
+
+// ErrInvitationNotPending dikembalikan jika undangan sudah dijawab, dibatalkan atau kedaluwarsa
+// oleh request lain saat jawaban disimpan
+var ErrInvitationNotPending = errors.New("undangan sudah tidak menunggu jawaban")
+
+// InvitationService mengelola undangan dan persetujuan anggota tim
+type InvitationService struct {
+	events *PengajuanEventService
+}
+
+// NewInvitationService creates a new invitation service
+func NewInvitationService() *InvitationService {
+	return &InvitationService{
+		events: NewPengajuanEventService(),
+	}
+}
+
+// Sync menyamakan undangan dengan susunan tim terbaru. Dipanggil di dalam transaksi
+// setelah baris anggota dibuat: anggota baru diundang, anggota yang dikeluarkan
+// undangannya dibatalkan, undangan yang masih berlaku dibiarkan.
+func (s *InvitationService) Sync(tx *gorm.DB, pengajuan *models.Pengajuan, anggota []models.PengajuanAnggota, userUpdate string) error {
+	var existing []models.UndanganAnggota
+	if err := tx.Where("id_pengajuan = ? AND status_undangan IN ?", pengajuan.ID, []string{UndanganPending, UndanganAccepted}).
+		Find(&existing).Error; err != nil {
+		return err
+	}
+
+	wanted := make(map[string]models.PengajuanAnggota)
+	for _, a := range anggota {
+		if a.IsKetua == 1 || a.NIMAnggota == pengajuan.NIMKetua {
+			continue
+		}
+		wanted[a.NIMAnggota] = a
+	}
+
+	now := time.Now()
+	active := make(map[string]bool)
+	for _, undangan := range existing {
+		// Anggota yang kini menjadi ketua (pengalihan ketua) tidak perlu undangan
+		if undangan.NIMAnggota == pengajuan.NIMKetua {
+			continue
+		}
+		_, stillMember := wanted[undangan.NIMAnggota]
+
+		status := ""
+		switch {
+		case !stillMember:
+			status = UndanganCancelled
+		case undangan.StatusUndangan == UndanganPending && !undangan.IsActive():
+			// Sudah lewat batas waktu tapi belum diproses ExpireStale: diundang ulang
+			status = UndanganExpired
+		default:
+			active[undangan.NIMAnggota] = true
+			continue
+		}
+
+		if err := tx.Model(&models.UndanganAnggota{}).Where("id = ?", undangan.ID).Updates(map[string]interface{}{
+			"status_undangan": status,
+			"user_update":     userUpdate,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	expiresAt := now.Add(invitationExpiry())
+	for _, a := range anggota {
+		if _, ok := wanted[a.NIMAnggota]; !ok || active[a.NIMAnggota] {
+			continue
+		}
+
+		undangan := &models.UndanganAnggota{
+			IDPengajuan:    pengajuan.ID,
+			NIMAnggota:     a.NIMAnggota,
+			NamaAnggota:    a.NamaAnggota,
+			NIMKetua:       pengajuan.NIMKetua,
+			StatusUndangan: UndanganPending,
+			ExpiresAt:      expiresAt,
+			TglInsert:      &now,
+			UserUpdate:     userUpdate,
+		}
+		if err := tx.Create(undangan).Error; err != nil {
+			return err
+		}
+		active[a.NIMAnggota] = true
+	}
+
+	return nil
+}
+
+// MarkAccepted mencatat anggota yang sudah menyetujui tim tanpa melalui undangan
+// (mis. ketua lama setelah pengalihan ketua), agar Sync tidak mengundangnya ulang.
+func (s *InvitationService) MarkAccepted(tx *gorm.DB, pengajuan *models.Pengajuan, nim string, nama string, userUpdate string) error {
+	if err := tx.Model(&models.UndanganAnggota{}).
+		Where("id_pengajuan = ? AND nim_anggota = ? AND status_undangan = ?", pengajuan.ID, nim, UndanganPending).
+		Updates(map[string]interface{}{"status_undangan": UndanganCancelled, "user_update": userUpdate}).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Create(&models.UndanganAnggota{
+		IDPengajuan:    pengajuan.ID,
+		NIMAnggota:     nim,
+		NamaAnggota:    nama,
+		NIMKetua:       pengajuan.NIMKetua,
+		StatusUndangan: UndanganAccepted,
+		ExpiresAt:      now,
+		RespondedAt:    &now,
+		TglInsert:      &now,
+		UserUpdate:     userUpdate,
+	}).Error
+}
+
+// PendingMembers mengembalikan NIM anggota yang belum menerima undangan
+func (s *InvitationService) PendingMembers(idPengajuan int) ([]string, error) {
+	var nims []string
+	if err := database.DB.Model(&models.UndanganAnggota{}).
+		Where("id_pengajuan = ? AND status_undangan = ?", idPengajuan, UndanganPending).
+		Order("id ASC").
+		Pluck("nim_anggota", &nims).Error; err != nil {
+		return nil, err
+	}
+	return nims, nil
+}
+
+// StatusByNIM mengembalikan status undangan aktif per NIM anggota untuk detail pengajuan
+func (s *InvitationService) StatusByNIM(idPengajuan int) map[string]string {
+	var undangan []models.UndanganAnggota
+	database.DB.Where("id_pengajuan = ? AND status_undangan IN ?", idPengajuan, []string{UndanganPending, UndanganAccepted}).
+		Find(&undangan)
+
+	result := make(map[string]string, len(undangan))
+	for _, u := range undangan {
+		result[u.NIMAnggota] = u.StatusUndangan
+	}
+	return result
+}
+
+// GetMyInvitations returns invitations for the logged in mahasiswa, newest first.
+// statusFilter kosong = semua status.
+func (s *InvitationService) GetMyInvitations(nim string, statusFilter string) ([]response.InvitationResponse, error) {
+	query := database.DB.Where("nim_anggota = ?", nim)
+	if statusFilter != "" {
+		query = query.Where("status_undangan = ?", statusFilter)
+	}
+
+	var undangan []models.UndanganAnggota
+	if err := query.Order("tgl_insert DESC, id DESC").Find(&undangan).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(undangan))
+	for _, u := range undangan {
+		ids = append(ids, u.IDPengajuan)
+	}
+	pengajuanMap := make(map[int]models.Pengajuan)
+	if len(ids) > 0 {
+		var pengajuanList []models.Pengajuan
+		if err := database.DB.Where("id IN ? AND hapus = ?", ids, 0).Find(&pengajuanList).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range pengajuanList {
+			pengajuanMap[p.ID] = p
+		}
+	}
+
+	result := make([]response.InvitationResponse, 0, len(undangan))
+	for _, u := range undangan {
+		p, ok := pengajuanMap[u.IDPengajuan]
+		if !ok {
+			continue
+		}
+		result = append(result, s.toResponse(&u, &p))
+	}
+	return result, nil
+}
+
+// Respond menerima atau menolak undangan. Penolakan membebaskan slot anggota.
+func (s *InvitationService) Respond(id int, nim string, accept bool) (*response.InvitationResponse, error) {
+	var undangan models.UndanganAnggota
+	if err := database.DB.Where("id = ? AND nim_anggota = ?", id, nim).First(&undangan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+
+	if undangan.StatusUndangan != UndanganPending {
+		return nil, errors.New("undangan sudah tidak menunggu jawaban (status: " + undangan.StatusUndangan + ")")
+	}
+	if !undangan.IsActive() {
+		return nil, errors.New("undangan sudah kedaluwarsa")
+	}
+
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", undangan.IDPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPengajuanNotFound
+		}
+		return nil, err
+	}
+
+	status, action := UndanganAccepted, EventAcceptInvitation
+	if !accept {
+		status, action = UndanganDeclined, EventDeclineInvitation
+	}
+
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Update bersyarat: jawaban ganda, Sync/MarkAccepted atau ExpireStale yang bersamaan
+		// tidak boleh ditimpa
+		result := tx.Model(&models.UndanganAnggota{}).
+			Where("id = ? AND status_undangan = ? AND expires_at > ?", undangan.ID, UndanganPending, now).
+			Updates(map[string]interface{}{
+				"status_undangan": status,
+				"responded_at":    now,
+				"user_update":     nim,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationNotPending
+		}
+
+		if !accept {
+			if err := tx.Where("id_pengajuan = ? AND nim_anggota = ? AND is_ketua = ?", pengajuan.ID, nim, 0).
+				Delete(&models.PengajuanAnggota{}).Error; err != nil {
+				return err
+			}
+		}
+
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    action,
+			Actor:     workflow.Actor{NIM: nim, UserUpdate: nim},
+			Pengajuan: &pengajuan,
+			DataBaru:  map[string]interface{}{"nim_anggota": nim, "status_undangan": status},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	undangan.StatusUndangan = status
+	undangan.RespondedAt = &now
+	result := s.toResponse(&undangan, &pengajuan)
+	return &result, nil
+}
+
+// ExpireStale menandai undangan yang lewat batas waktu sebagai EXPIRED dan membebaskan slotnya
+func (s *InvitationService) ExpireStale() error {
+	var stale []models.UndanganAnggota
+	if err := database.DB.Where("status_undangan = ? AND expires_at < ?", UndanganPending, time.Now()).
+		Find(&stale).Error; err != nil {
+		return err
+	}
+
+	for _, undangan := range stale {
+		undangan := undangan
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			// Lewati undangan yang baru saja dijawab sebelum sempat ditandai kedaluwarsa
+			result := tx.Model(&models.UndanganAnggota{}).
+				Where("id = ? AND status_undangan = ?", undangan.ID, UndanganPending).
+				Updates(map[string]interface{}{
+					"status_undangan": UndanganExpired,
+					"user_update":     "system",
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+
+			if err := tx.Where("id_pengajuan = ? AND nim_anggota = ? AND is_ketua = ?", undangan.IDPengajuan, undangan.NIMAnggota, 0).
+				Delete(&models.PengajuanAnggota{}).Error; err != nil {
+				return err
+			}
+
+			var pengajuan models.Pengajuan
+			if err := tx.Where("id = ?", undangan.IDPengajuan).First(&pengajuan).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			return s.events.Record(tx, &PengajuanEvent{
+				Action:    EventExpireInvitation,
+				Actor:     workflow.Actor{UserUpdate: "system"},
+				Pengajuan: &pengajuan,
+				DataBaru:  map[string]interface{}{"nim_anggota": undangan.NIMAnggota, "status_undangan": UndanganExpired},
+			})
+		})
+		if err != nil {
+			log.Printf("Failed to expire invitation %d: %v", undangan.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// toResponse memetakan undangan ke DTO; undangan PENDING yang lewat waktu ditampilkan EXPIRED
+func (s *InvitationService) toResponse(u *models.UndanganAnggota, p *models.Pengajuan) response.InvitationResponse {
+	status := u.StatusUndangan
+	if status == UndanganPending && !u.IsActive() {
+		status = UndanganExpired
+	}
+
+	return response.InvitationResponse{
+		ID:             u.ID,
+		IDPengajuan:    u.IDPengajuan,
+		KodePengajuan:  p.KodePengajuan,
+		Judul:          p.Judul,
+		NamaKetua:      p.NamaKetua,
+		NIMKetua:       p.NIMKetua,
+		StatusUndangan: status,
+		ExpiresAt:      u.ExpiresAt,
+		RespondedAt:    u.RespondedAt,
+		TglInsert:      u.TglInsert,
+	}
+}
+
+// invitationExpiry membaca batas waktu undangan dari config
+func invitationExpiry() time.Duration {
+	hours, err := strconv.Atoi(config.AppConfig.InvitationExpiryHours)
+	if err != nil || hours < 1 {
+		hours = 72
+	}
+	return time.Duration(hours) * time.Hour
+}
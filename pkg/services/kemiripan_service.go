@@ -0,0 +1,266 @@
+package services
+
+import (
+	"strconv"
+	"time"
+
+	"rires-be/config"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/similarity"
+
+	"gorm.io/gorm"
+)
+
+// KemiripanService mendeteksi judul yang mirip/duplikat di seluruh tahun pengajuan
+type KemiripanService struct{}
+
+// NewKemiripanService creates a new kemiripan service
+func NewKemiripanService() *KemiripanService {
+	return &KemiripanService{}
+}
+
+// Refresh menghitung ulang judul mirip untuk satu pengajuan dan menyimpannya
+// ke db_kemiripan_judul (menggantikan hasil sebelumnya). Dipanggil setelah judul disimpan.
+func (s *KemiripanService) Refresh(idPengajuan int, judul string) ([]response.KemiripanResponse, error) {
+	corpus, err := s.loadCorpus()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := newKemiripanMatcher(corpus).rows(idPengajuan, judul, time.Now())
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_pengajuan = ?", idPengajuan).Delete(&models.KemiripanJudul{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponses(rows), nil
+}
+
+// RefreshBatch menghitung ulang judul mirip untuk banyak pengajuan sekaligus (mis. hasil import).
+// Korpus dibaca dan diindeks sekali, bukan sekali per pengajuan seperti Refresh.
+func (s *KemiripanService) RefreshBatch(pengajuanList []*models.Pengajuan) error {
+	if len(pengajuanList) == 0 {
+		return nil
+	}
+
+	corpus, err := s.loadCorpus()
+	if err != nil {
+		return err
+	}
+
+	matcher := newKemiripanMatcher(corpus)
+	now := time.Now()
+	ids := make([]int, 0, len(pengajuanList))
+	var rows []models.KemiripanJudul
+	for _, p := range pengajuanList {
+		ids = append(ids, p.ID)
+		rows = append(rows, matcher.rows(p.ID, p.Judul, now)...)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_pengajuan IN ?", ids).Delete(&models.KemiripanJudul{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+}
+
+// GetMatches mengembalikan judul mirip yang tersimpan untuk pengajuan, skor tertinggi dulu.
+// Judul mirip yang sudah dihapus tidak ditampilkan.
+func (s *KemiripanService) GetMatches(idPengajuan int) ([]response.KemiripanResponse, error) {
+	var rows []models.KemiripanJudul
+	if err := database.DB.
+		Joins("JOIN db_pengajuan_pkm p ON p.id = db_kemiripan_judul.id_pengajuan_mirip AND p.hapus = 0").
+		Where("db_kemiripan_judul.id_pengajuan = ?", idPengajuan).
+		Order("db_kemiripan_judul.skor DESC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return s.toResponses(rows), nil
+}
+
+// ClusterReport mengelompokkan judul satu periode yang saling mirip dengan skor >= minSkor.
+// Periode dipilih lewat idPeriode (db_pengajuan_periode); idPeriode 0 memakai tahun pengajuan.
+// IDF dihitung dari seluruh tahun agar kata yang umum di semua periode tidak dianggap pembeda.
+func (s *KemiripanService) ClusterReport(idPeriode int, tahun int, minSkor float64) ([]response.KemiripanClusterResponse, error) {
+	if minSkor <= 0 {
+		minSkor = similarityThreshold()
+	}
+
+	corpus, err := s.loadCorpus()
+	if err != nil {
+		return nil, err
+	}
+
+	var inPeriode map[int]bool
+	if idPeriode > 0 {
+		var idPengajuan []int
+		if err := database.DB.Model(&models.PengajuanPeriode{}).
+			Where("id_periode = ?", idPeriode).
+			Pluck("id_pengajuan", &idPengajuan).Error; err != nil {
+			return nil, err
+		}
+		inPeriode = make(map[int]bool, len(idPengajuan))
+		for _, id := range idPengajuan {
+			inPeriode[id] = true
+		}
+	}
+
+	byID := make(map[int]models.Pengajuan, len(corpus))
+	ids := make([]int, 0)
+	for _, p := range corpus {
+		byID[p.ID] = p
+		if (inPeriode != nil && inPeriode[p.ID]) || (inPeriode == nil && p.Tahun == tahun) {
+			ids = append(ids, p.ID)
+		}
+	}
+
+	result := make([]response.KemiripanClusterResponse, 0)
+	if len(ids) < 2 {
+		return result, nil
+	}
+
+	var kategoriList []models.KategoriPKM
+	if err := database.DB.Find(&kategoriList).Error; err != nil {
+		return nil, err
+	}
+	namaKategori := make(map[int]string, len(kategoriList))
+	for _, k := range kategoriList {
+		namaKategori[k.ID] = k.NamaKategori
+	}
+
+	index := similarity.NewIndex(documents(corpus))
+	for _, cluster := range index.Clusters(ids, minSkor) {
+		item := response.KemiripanClusterResponse{
+			Pengajuan: make([]response.KemiripanClusterItem, 0, len(cluster)),
+		}
+		for i, id := range cluster {
+			p := byID[id]
+			item.Pengajuan = append(item.Pengajuan, response.KemiripanClusterItem{
+				IDPengajuan:   p.ID,
+				KodePengajuan: p.KodePengajuan,
+				Judul:         p.Judul,
+				NamaKetua:     p.NamaKetua,
+				NIMKetua:      p.NIMKetua,
+				NamaKategori:  namaKategori[p.IDKategori],
+				StatusJudul:   p.StatusJudul,
+			})
+			for _, other := range cluster[i+1:] {
+				if score := roundScore(index.Score(id, other)); score > item.SkorMaks {
+					item.SkorMaks = score
+				}
+			}
+		}
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// loadCorpus membaca semua judul pengajuan yang belum dihapus (semua tahun)
+func (s *KemiripanService) loadCorpus() ([]models.Pengajuan, error) {
+	var corpus []models.Pengajuan
+	if err := database.DB.
+		Select("id, kode_pengajuan, judul, tahun, nama_ketua, nim_ketua, id_kategori, status_judul").
+		Where("hapus = ? AND judul <> ''", 0).
+		Find(&corpus).Error; err != nil {
+		return nil, err
+	}
+	return corpus, nil
+}
+
+// kemiripanMatcher adalah index korpus yang dipakai ulang untuk beberapa judul
+type kemiripanMatcher struct {
+	index *similarity.Index
+	byID  map[int]models.Pengajuan
+}
+
+// newKemiripanMatcher membangun index dari korpus
+func newKemiripanMatcher(corpus []models.Pengajuan) *kemiripanMatcher {
+	byID := make(map[int]models.Pengajuan, len(corpus))
+	for _, p := range corpus {
+		byID[p.ID] = p
+	}
+	return &kemiripanMatcher{index: similarity.NewIndex(documents(corpus)), byID: byID}
+}
+
+// rows menyusun baris db_kemiripan_judul untuk satu judul
+func (m *kemiripanMatcher) rows(idPengajuan int, judul string, now time.Time) []models.KemiripanJudul {
+	matches := m.index.TopMatches(judul, idPengajuan, similarityMaxMatches(), similarityThreshold())
+
+	rows := make([]models.KemiripanJudul, 0, len(matches))
+	for _, match := range matches {
+		mirip := m.byID[match.ID]
+		rows = append(rows, models.KemiripanJudul{
+			IDPengajuan:        idPengajuan,
+			IDPengajuanMirip:   mirip.ID,
+			KodePengajuanMirip: mirip.KodePengajuan,
+			JudulMirip:         mirip.Judul,
+			TahunMirip:         mirip.Tahun,
+			Skor:               roundScore(match.Score),
+			TglInsert:          now,
+		})
+	}
+	return rows
+}
+
+// toResponses memetakan baris kemiripan ke response
+func (s *KemiripanService) toResponses(rows []models.KemiripanJudul) []response.KemiripanResponse {
+	result := make([]response.KemiripanResponse, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, response.KemiripanResponse{
+			IDPengajuan:   row.IDPengajuanMirip,
+			KodePengajuan: row.KodePengajuanMirip,
+			Judul:         row.JudulMirip,
+			Tahun:         row.TahunMirip,
+			Skor:          row.Skor,
+		})
+	}
+	return result
+}
+
+// similarityThreshold membaca skor minimal judul mirip dari konfigurasi
+func similarityThreshold() float64 {
+	threshold, err := strconv.ParseFloat(config.AppConfig.SimilarityThreshold, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return 0.5 // Default
+	}
+	return threshold
+}
+
+// similarityMaxMatches membaca jumlah judul mirip yang disimpan per pengajuan
+func similarityMaxMatches() int {
+	limit, err := strconv.Atoi(config.AppConfig.SimilarityMaxMatches)
+	if err != nil || limit <= 0 {
+		return 5 // Default
+	}
+	return limit
+}
+
+// documents mengubah pengajuan menjadi dokumen korpus
+func documents(corpus []models.Pengajuan) []similarity.Document {
+	docs := make([]similarity.Document, 0, len(corpus))
+	for _, p := range corpus {
+		docs = append(docs, similarity.Document{ID: p.ID, Text: p.Judul})
+	}
+	return docs
+}
+
+// roundScore membulatkan skor ke 4 desimal (kolom decimal(5,4))
+func roundScore(score float64) float64 {
+	return float64(int(score*10000+0.5)) / 10000
+}
@@ -0,0 +1,517 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rires-be/config"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+	"rires-be/pkg/workflow"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kunci db_app_setting untuk template kode pengajuan
+const settingKodeTemplate = "kode_pengajuan_template"
+
+// Aksi timeline saat kode pengajuan lama diperbaiki (cmd/regenerate-kode)
+const EventRegenerateKode workflow.Action = "REGENERATE_KODE"
+
+// maxKodeAttempts membatasi percobaan nomor urut (atau tahun periode) jika hasilnya sudah dipakai
+const maxKodeAttempts = 20
+
+// ErrKodeKategoriTaken dikembalikan jika kode singkat sudah dipakai kategori lain
+var ErrKodeKategoriTaken = errors.New("kode kategori sudah dipakai kategori lain")
+
+// ErrKodeKategoriEmpty dikembalikan jika kategori belum punya kode dan kode tidak bisa diturunkan dari nama
+var ErrKodeKategoriEmpty = errors.New("kode kategori belum diisi, lengkapi kode kategori PKM terlebih dahulu")
+
+// KodeChange adalah satu kode pengajuan lama yang diganti
+type KodeChange struct {
+	IDPengajuan int
+	KodeLama    string
+	KodeBaru    string // kosong pada dry-run
+	Alasan      string
+}
+
+// KodePengajuanService membuat kode pengajuan dari template dengan nomor urut atomik per kategori & periode
+type KodePengajuanService struct{}
+
+// NewKodePengajuanService creates a new kode pengajuan service
+func NewKodePengajuanService() *KodePengajuanService {
+	return &KodePengajuanService{}
+}
+
+// Template mengembalikan template aktif: pengaturan admin, atau default dari konfigurasi
+func (s *KodePengajuanService) Template() string {
+	var setting models.AppSetting
+	if err := database.DB.Where("kunci = ?", settingKodeTemplate).First(&setting).Error; err == nil {
+		if utils.ValidateKodeTemplate(setting.Nilai) == nil {
+			return setting.Nilai
+		}
+	}
+	return defaultKodeTemplate()
+}
+
+// GetTemplate mengembalikan template aktif beserta contoh kode (kategori RE, nomor urut 1)
+func (s *KodePengajuanService) GetTemplate() *response.KodeTemplateResponse {
+	template := s.Template()
+	return &response.KodeTemplateResponse{
+		Template:        template,
+		TemplateDefault: defaultKodeTemplate(),
+		Contoh:          utils.FormatKodePengajuan(template, "RE", "2026", 1),
+	}
+}
+
+// SetTemplate menyimpan template kode baru. Kosong = kembali ke default konfigurasi.
+// Kode yang sudah terbit tidak berubah; nomor urut tetap melanjutkan db_kode_sequence.
+func (s *KodePengajuanService) SetTemplate(template string, userUpdate string) (*response.KodeTemplateResponse, error) {
+	template = strings.TrimSpace(template)
+	if template == "" {
+		if err := database.DB.Where("kunci = ?", settingKodeTemplate).Delete(&models.AppSetting{}).Error; err != nil {
+			return nil, err
+		}
+		return s.GetTemplate(), nil
+	}
+
+	if err := utils.ValidateKodeTemplate(template); err != nil {
+		return nil, err
+	}
+
+	setting := models.AppSetting{Kunci: settingKodeTemplate, Nilai: template, UserUpdate: userUpdate}
+	if err := database.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"nilai", "user_update", "tgl_update"}),
+	}).Create(&setting).Error; err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(), nil
+}
+
+// KodeKategori mengembalikan kode singkat kategori: isian admin, atau turunan dari nama kategori
+func (s *KodePengajuanService) KodeKategori(kategori *models.KategoriPKM) (string, error) {
+	var row models.KategoriKode
+	err := database.DB.Where("id_kategori = ?", kategori.ID).First(&row).Error
+	if err == nil {
+		return row.Kode, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	if kode := utils.DeriveKodeKategori(kategori.NamaKategori); kode != "" {
+		return kode, nil
+	}
+	return "", ErrKodeKategoriEmpty
+}
+
+// KodeKategoriMap mengembalikan kode tersimpan semua kategori (id_kategori -> kode)
+func (s *KodePengajuanService) KodeKategoriMap() (map[int]string, error) {
+	var rows []models.KategoriKode
+	if err := database.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int]string, len(rows))
+	for _, row := range rows {
+		result[row.IDKategori] = row.Kode
+	}
+	return result, nil
+}
+
+// ValidateKodeKategori menormalkan kode singkat dan memastikan belum dipakai kategori lain
+// (idKategori 0 untuk kategori baru)
+func (s *KodePengajuanService) ValidateKodeKategori(idKategori int, kode string) (string, error) {
+	kode, err := utils.NormalizeKodeKategori(kode)
+	if err != nil {
+		return "", err
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.KategoriKode{}).
+		Where("kode = ? AND id_kategori <> ?", kode, idKategori).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "", ErrKodeKategoriTaken
+	}
+	return kode, nil
+}
+
+// SetKodeKategori menyimpan kode singkat kategori. Kosong = hapus (kembali diturunkan dari nama).
+func (s *KodePengajuanService) SetKodeKategori(idKategori int, kode string, userUpdate string) (string, error) {
+	if strings.TrimSpace(kode) == "" {
+		return "", database.DB.Where("id_kategori = ?", idKategori).Delete(&models.KategoriKode{}).Error
+	}
+
+	kode, err := s.ValidateKodeKategori(idKategori, kode)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	row := models.KategoriKode{IDKategori: idKategori, Kode: kode, TglInsert: &now, UserUpdate: userUpdate}
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_kategori"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kode", "user_update", "tgl_update"}),
+	}).Create(&row).Error; err != nil {
+		return "", err
+	}
+	return kode, nil
+}
+
+// Generate membuat kode pengajuan baru. Wajib dipanggil di dalam transaksi pembuatan pengajuan:
+// baris db_kode_sequence terkunci sampai commit sehingga dua pengajuan bersamaan tidak mendapat nomor sama.
+func (s *KodePengajuanService) Generate(tx *gorm.DB, kategori *models.KategoriPKM, periode *models.TglSetting) (string, error) {
+	template, kode, tahun, err := s.parts(tx, kategori, periode, true)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; attempt < maxKodeAttempts; attempt++ {
+		sequence, err := s.nextSequence(tx, template, kode, tahun, kategori.ID, periode.ID)
+		if err != nil {
+			return "", err
+		}
+
+		// Nomor yang bentrok dengan kode lain (mis. kode kategori sama dengan turunan nama kategori lain) dilewati
+		kodePengajuan := utils.FormatKodePengajuan(template, kode, tahun, sequence)
+		taken, err := kodeTaken(tx, kodePengajuan)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return kodePengajuan, nil
+		}
+	}
+	return "", fmt.Errorf("gagal membuat kode pengajuan unik untuk kategori %s", kode)
+}
+
+// Preview menampilkan kode berikutnya tanpa memakai nomor urut
+func (s *KodePengajuanService) Preview(kategori *models.KategoriPKM, periode *models.TglSetting) (string, error) {
+	template, kode, tahun, err := s.parts(database.DB, kategori, periode, false)
+	if err != nil {
+		return "", err
+	}
+
+	var seq models.KodeSequence
+	err = database.DB.Where("id_kategori = ? AND id_periode = ?", kategori.ID, periode.ID).First(&seq).Error
+	if err == nil {
+		return utils.FormatKodePengajuan(template, kode, tahun, seq.LastSeq+1), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	last, err := lastUsedSequence(database.DB, template, kode, tahun, kategori.ID)
+	if err != nil {
+		return "", err
+	}
+	return utils.FormatKodePengajuan(template, kode, tahun, last+1), nil
+}
+
+// RegenerateMalformed mencari kode pengajuan buatan generator lama (utils.IsLegacyKodePengajuan,
+// mis. "PKM-PKM RE (Riset Eksakata)-2026-004") atau yang dipakai lebih dari satu pengajuan,
+// lalu menggantinya dengan kode baru jika apply = true. Kode yang sekadar berbeda dari template
+// aktif (dibuat sebelum template diganti) tidak disentuh.
+// Pengajuan yang belum tertaut ke periode dilewati. File proposal yang sudah ter-upload tidak di-rename.
+func (s *KodePengajuanService) RegenerateMalformed(apply bool) ([]KodeChange, error) {
+	var pengajuanList []models.Pengajuan
+	if err := database.DB.Select("id, kode_pengajuan, id_kategori").
+		Order("id ASC").
+		Find(&pengajuanList).Error; err != nil {
+		return nil, err
+	}
+
+	var mappings []models.PengajuanPeriode
+	if err := database.DB.Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	periodeByPengajuan := make(map[int]int, len(mappings))
+	for _, m := range mappings {
+		periodeByPengajuan[m.IDPengajuan] = m.IDPeriode
+	}
+
+	var periodeList []models.TglSetting
+	if err := database.DB.Find(&periodeList).Error; err != nil {
+		return nil, err
+	}
+	periodeByID := make(map[int]*models.TglSetting, len(periodeList))
+	for i := range periodeList {
+		periodeByID[periodeList[i].ID] = &periodeList[i]
+	}
+
+	var kategoriList []models.KategoriPKM
+	if err := database.DB.Find(&kategoriList).Error; err != nil {
+		return nil, err
+	}
+	kategoriByID := make(map[int]*models.KategoriPKM, len(kategoriList))
+	kodeByKategori := make(map[int]string, len(kategoriList))
+	for i := range kategoriList {
+		kategoriByID[kategoriList[i].ID] = &kategoriList[i]
+		if kode, err := s.KodeKategori(&kategoriList[i]); err == nil {
+			kodeByKategori[kategoriList[i].ID] = kode
+		}
+	}
+
+	// 1. Kumpulkan kode format lama atau duplikat (pengajuan tertua mempertahankan kodenya)
+	changes := make([]KodeChange, 0)
+	seen := make(map[string]bool, len(pengajuanList))
+	for _, p := range pengajuanList {
+		idPeriode, ok := periodeByPengajuan[p.ID]
+		kode := kodeByKategori[p.IDKategori]
+		if !ok || periodeByID[idPeriode] == nil || kategoriByID[p.IDKategori] == nil || kode == "" {
+			continue
+		}
+
+		alasan := ""
+		if utils.IsLegacyKodePengajuan(p.KodePengajuan) {
+			alasan = "format lama"
+		} else if seen[p.KodePengajuan] {
+			alasan = "duplikat"
+		}
+		seen[p.KodePengajuan] = true
+
+		if alasan != "" {
+			changes = append(changes, KodeChange{IDPengajuan: p.ID, KodeLama: p.KodePengajuan, Alasan: alasan})
+		}
+	}
+
+	if !apply {
+		return changes, nil
+	}
+
+	// 2. Ganti satu per satu, masing-masing dalam transaksi sendiri
+	for i := range changes {
+		change := &changes[i]
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var pengajuan models.Pengajuan
+			if err := tx.Where("id = ?", change.IDPengajuan).First(&pengajuan).Error; err != nil {
+				return err
+			}
+
+			idPeriode := periodeByPengajuan[pengajuan.ID]
+			kodeBaru, err := s.Generate(tx, kategoriByID[pengajuan.IDKategori], periodeByID[idPeriode])
+			if err != nil {
+				return err
+			}
+
+			before := pengajuan
+			updates := map[string]interface{}{
+				"kode_pengajuan": kodeBaru,
+				"user_update":    "system",
+			}
+			if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
+				return err
+			}
+			change.KodeBaru = kodeBaru
+
+			return NewPengajuanEventService().Record(tx, &PengajuanEvent{
+				Action:    EventRegenerateKode,
+				Actor:     workflow.Actor{UserUpdate: "system"},
+				Pengajuan: &pengajuan,
+				Before:    &before,
+				Updates:   updates,
+				Catatan:   "Kode pengajuan diperbaiki (" + change.Alasan + ")",
+			})
+		})
+		if err != nil {
+			return changes, fmt.Errorf("pengajuan %d (%s): %w", change.IDPengajuan, change.KodeLama, err)
+		}
+	}
+
+	return changes, nil
+}
+
+// parts menyiapkan template, kode kategori dan tahun periode.
+// persist = true menyimpan tahun periode yang baru pertama kali dipakai (Generate).
+func (s *KodePengajuanService) parts(db *gorm.DB, kategori *models.KategoriPKM, periode *models.TglSetting, persist bool) (string, string, string, error) {
+	if kategori == nil || periode == nil {
+		return "", "", "", errors.New("kategori dan periode wajib diisi")
+	}
+
+	kode, err := s.KodeKategori(kategori)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var tahun string
+	if persist {
+		tahun, err = s.reserveTahunKode(db, periode)
+	} else {
+		tahun, err = s.tahunKode(db, periode)
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+	return s.Template(), kode, tahun, nil
+}
+
+// tahunKode mengembalikan bagian {TAHUN} tersimpan untuk periode, atau usulan yang belum dipakai
+// periode lain jika periode belum pernah membuat kode
+func (s *KodePengajuanService) tahunKode(db *gorm.DB, periode *models.TglSetting) (string, error) {
+	var stored models.PeriodeKode
+	err := db.Where("id_periode = ?", periode.ID).First(&stored).Error
+	if err == nil {
+		return stored.TahunKode, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return s.proposeTahunKode(db, periode, nil)
+}
+
+// proposeTahunKode mengusulkan bagian {TAHUN} yang belum dipakai periode lain.
+// skip berisi usulan yang ternyata sudah diambil transaksi lain (lihat reserveTahunKode).
+func (s *KodePengajuanService) proposeTahunKode(db *gorm.DB, periode *models.TglSetting, skip map[string]bool) (string, error) {
+	tahun, err := utils.KodeTahunPeriode(periode)
+	if err != nil {
+		return "", err
+	}
+
+	// Periode yang ditambah/dimundurkan belakangan bisa mendapat usulan yang sudah dipakai periode lain
+	var used []string
+	if err := db.Model(&models.PeriodeKode{}).
+		Where("tahun_kode = ? OR tahun_kode LIKE ?", strconv.Itoa(periode.Tahun()), fmt.Sprintf("%d-%%", periode.Tahun())).
+		Pluck("tahun_kode", &used).Error; err != nil {
+		return "", err
+	}
+	taken := make(map[string]bool, len(used)+len(skip))
+	for t := range skip {
+		taken[t] = true
+	}
+	for _, t := range used {
+		taken[t] = true
+	}
+	for n := 2; taken[tahun]; n++ {
+		tahun = fmt.Sprintf("%d-%d", periode.Tahun(), n)
+	}
+	return tahun, nil
+}
+
+// reserveTahunKode menyimpan tahun periode saat pertama kali dipakai membuat kode.
+// Insert bisa kalah dari transaksi lain dengan dua cara: periode yang sama sudah disimpan
+// (nilai tersimpan yang dipakai), atau periode lain di tahun yang sama mengambil usulan yang sama
+// (tahun_kode unik, usulan berikutnya dicoba).
+func (s *KodePengajuanService) reserveTahunKode(tx *gorm.DB, periode *models.TglSetting) (string, error) {
+	tahun, err := s.tahunKode(tx, periode)
+	if err != nil {
+		return "", err
+	}
+
+	skip := make(map[string]bool)
+	for attempt := 0; attempt < maxKodeAttempts; attempt++ {
+		now := time.Now()
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.PeriodeKode{
+			IDPeriode: periode.ID,
+			TahunKode: tahun,
+			TglInsert: &now,
+		})
+		if result.Error != nil {
+			return "", result.Error
+		}
+		if result.RowsAffected > 0 {
+			return tahun, nil
+		}
+
+		// Locking read: baca versi terbaru yang sudah di-commit, bukan snapshot transaksi
+		var stored models.PeriodeKode
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id_periode = ?", periode.ID).First(&stored).Error
+		if err == nil {
+			return stored.TahunKode, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+
+		skip[tahun] = true
+		if tahun, err = s.proposeTahunKode(tx, periode, skip); err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("gagal menyimpan tahun kode untuk periode %d", periode.ID)
+}
+
+// nextSequence menaikkan nomor urut kategori & periode secara atomik.
+// Baris pertama diawali dari nomor terbesar kode yang sudah ada (data sebelum db_kode_sequence);
+// jika dua transaksi membuatnya bersamaan, yang kalah jatuh ke ON DUPLICATE KEY UPDATE.
+func (s *KodePengajuanService) nextSequence(tx *gorm.DB, template, kode, tahun string, idKategori, idPeriode int) (int, error) {
+	var count int64
+	if err := tx.Model(&models.KodeSequence{}).
+		Where("id_kategori = ? AND id_periode = ?", idKategori, idPeriode).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	start := 1
+	if count == 0 {
+		last, err := lastUsedSequence(tx, template, kode, tahun, idKategori)
+		if err != nil {
+			return 0, err
+		}
+		start = last + 1
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_kategori"}, {Name: "id_periode"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"last_seq": gorm.Expr("last_seq + 1")}),
+	}).Create(&models.KodeSequence{
+		IDKategori: idKategori,
+		IDPeriode:  idPeriode,
+		LastSeq:    start,
+	}).Error; err != nil {
+		return 0, err
+	}
+
+	var seq models.KodeSequence
+	if err := tx.Where("id_kategori = ? AND id_periode = ?", idKategori, idPeriode).First(&seq).Error; err != nil {
+		return 0, err
+	}
+	return seq.LastSeq, nil
+}
+
+// defaultKodeTemplate membaca template default dari konfigurasi
+func defaultKodeTemplate() string {
+	template := strings.TrimSpace(config.AppConfig.KodePengajuanTemplate)
+	if utils.ValidateKodeTemplate(template) != nil {
+		return utils.DefaultKodeTemplate
+	}
+	return template
+}
+
+// lastUsedSequence mencari nomor urut terbesar dari kode kategori yang sesuai template, kode dan tahun
+// (termasuk pengajuan yang sudah dihapus, karena kodenya tetap unik di tabel)
+func lastUsedSequence(db *gorm.DB, template, kode, tahun string, idKategori int) (int, error) {
+	var kodeList []string
+	if err := db.Model(&models.Pengajuan{}).
+		Where("id_kategori = ?", idKategori).
+		Pluck("kode_pengajuan", &kodeList).Error; err != nil {
+		return 0, err
+	}
+
+	last := 0
+	for _, kodePengajuan := range kodeList {
+		if sequence, ok := utils.ParseKodeSequence(template, kode, tahun, kodePengajuan); ok && sequence > last {
+			last = sequence
+		}
+	}
+	return last, nil
+}
+
+// kodeTaken memeriksa apakah kode pengajuan sudah dipakai (termasuk pengajuan yang dihapus)
+func kodeTaken(tx *gorm.DB, kodePengajuan string) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.Pengajuan{}).
+		Where("kode_pengajuan = ?", kodePengajuan).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rires-be/config"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tipe kunci counter login
+const (
+	LoginAttemptUsername = "username"
+	LoginAttemptIP       = "ip"
+)
+
+// LoginLockedError dikembalikan jika username atau IP sedang terkunci
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("terlalu banyak percobaan login gagal, coba lagi dalam %d detik", e.RetryAfter())
+}
+
+// RetryAfter mengembalikan sisa waktu lockout dalam detik
+func (e *LoginLockedError) RetryAfter() int {
+	seconds := int(time.Until(e.Until).Seconds()) + 1
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// LoginThrottleService handles failed-login counters, backoff and lockout
+type LoginThrottleService struct{}
+
+// NewLoginThrottleService creates a new login throttle service
+func NewLoginThrottleService() *LoginThrottleService {
+	return &LoginThrottleService{}
+}
+
+// Check mengembalikan *LoginLockedError jika username atau IP sedang terkunci
+func (s *LoginThrottleService) Check(username, ip string) error {
+	var attempts []models.LoginAttempt
+	if err := database.DB.
+		Where("kunci IN ? AND locked_until > ?", s.keys(username, ip), time.Now()).
+		Find(&attempts).Error; err != nil {
+		return err
+	}
+
+	var locked *LoginLockedError
+	for _, attempt := range attempts {
+		if locked == nil || attempt.LockedUntil.After(locked.Until) {
+			locked = &LoginLockedError{Until: *attempt.LockedUntil}
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// RegisterFailure menambah counter gagal untuk username dan IP, lalu mengunci jika melewati batas
+func (s *LoginThrottleService) RegisterFailure(username, ip string) error {
+	if username != "" {
+		if err := s.increment(LoginAttemptUsername, s.normalizeUsername(username), s.maxAttempts(config.AppConfig.LoginMaxAttempts, 5)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if err := s.increment(LoginAttemptIP, ip, s.maxAttempts(config.AppConfig.LoginMaxAttemptsIP, 20)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterSuccess me-reset counter username setelah login berhasil.
+// Counter IP sengaja tidak di-reset agar satu akun valid tidak bisa dipakai untuk menutupi tebakan dari IP yang sama.
+func (s *LoginThrottleService) RegisterSuccess(username string) error {
+	return database.DB.Model(&models.LoginAttempt{}).
+		Where("kunci = ?", LoginAttemptUsername+":"+s.normalizeUsername(username)).
+		Updates(map[string]interface{}{
+			"jumlah_gagal": 0,
+			"locked_until": nil,
+		}).Error
+}
+
+// GetAttempts mengembalikan daftar counter login gagal untuk admin
+func (s *LoginThrottleService) GetAttempts(lockedOnly bool, search string) ([]response.LoginAttemptResponse, error) {
+	query := database.DB.Model(&models.LoginAttempt{}).Where("jumlah_gagal > ?", 0)
+	if lockedOnly {
+		query = query.Where("locked_until > ?", time.Now())
+	}
+	if search != "" {
+		query = query.Where("nilai LIKE ?", "%"+search+"%")
+	}
+
+	var attempts []models.LoginAttempt
+	if err := query.Order("terakhir_gagal DESC").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.LoginAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, s.mapAttempt(&attempt))
+	}
+	return result, nil
+}
+
+// ClearAttempt menghapus lockout dan counter untuk satu kunci
+func (s *LoginThrottleService) ClearAttempt(id int, userID int) error {
+	var attempt models.LoginAttempt
+	if err := database.DB.Where("id = ?", id).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("data lockout tidak ditemukan")
+		}
+		return err
+	}
+
+	return database.DB.Model(&attempt).Updates(map[string]interface{}{
+		"jumlah_gagal": 0,
+		"locked_until": nil,
+		"user_update":  strconv.Itoa(userID),
+	}).Error
+}
+
+// increment menaikkan counter secara atomik dan menghitung lockout (exponential backoff)
+func (s *LoginThrottleService) increment(tipe, nilai string, maxAttempts int) error {
+	kunci := tipe + ":" + nilai
+	now := time.Now()
+
+	// Pastikan baris ada (aman untuk request bersamaan karena unique index)
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginAttempt{
+		Kunci:     kunci,
+		Tipe:      tipe,
+		Nilai:     nilai,
+		TglInsert: &now,
+	}).Error; err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var attempt models.LoginAttempt
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kunci = ?", kunci).
+			First(&attempt).Error; err != nil {
+			return err
+		}
+
+		// Reset counter jika kegagalan terakhir sudah di luar window dan tidak sedang terkunci
+		failures := attempt.JumlahGagal
+		if attempt.TerakhirGagal != nil && now.Sub(*attempt.TerakhirGagal) > s.window() && !attempt.IsLocked() {
+			failures = 0
+		}
+		failures++
+
+		updates := map[string]interface{}{
+			"jumlah_gagal":   failures,
+			"terakhir_gagal": now,
+		}
+		if failures >= maxAttempts {
+			updates["locked_until"] = now.Add(s.lockoutDuration(failures - maxAttempts))
+		}
+
+		return tx.Model(&attempt).Updates(updates).Error
+	})
+}
+
+// lockoutDuration menghitung lama lockout: base * 2^n, dibatasi maksimum
+func (s *LoginThrottleService) lockoutDuration(n int) time.Duration {
+	baseSeconds, err := strconv.Atoi(config.AppConfig.LoginLockoutBaseSeconds)
+	if err != nil || baseSeconds < 1 {
+		baseSeconds = 30
+	}
+	maxMinutes, err := strconv.Atoi(config.AppConfig.LoginLockoutMaxMinutes)
+	if err != nil || maxMinutes < 1 {
+		maxMinutes = 60
+	}
+
+	maxDuration := time.Duration(maxMinutes) * time.Minute
+	duration := time.Duration(baseSeconds) * time.Second
+	for i := 0; i < n && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+	return duration
+}
+
+// window mengembalikan rentang waktu counter sebelum di-reset
+func (s *LoginThrottleService) window() time.Duration {
+	minutes, err := strconv.Atoi(config.AppConfig.LoginAttemptWindowMinutes)
+	if err != nil || minutes < 1 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// maxAttempts membaca batas percobaan dari config
+func (s *LoginThrottleService) maxAttempts(value string, defaultValue int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return defaultValue
+	}
+	return n
+}
+
+// keys mengembalikan kunci counter untuk username dan IP
+func (s *LoginThrottleService) keys(username, ip string) []string {
+	keys := make([]string, 0, 2)
+	if username != "" {
+		keys = append(keys, LoginAttemptUsername+":"+s.normalizeUsername(username))
+	}
+	if ip != "" {
+		keys = append(keys, LoginAttemptIP+":"+ip)
+	}
+	return keys
+}
+
+// normalizeUsername menyamakan penulisan username (case-insensitive, tanpa spasi)
+func (s *LoginThrottleService) normalizeUsername(username string) string {
+	return truncate(strings.ToLower(strings.TrimSpace(username)), 150)
+}
+
+// mapAttempt memetakan model ke response
+func (s *LoginThrottleService) mapAttempt(attempt *models.LoginAttempt) response.LoginAttemptResponse {
+	resp := response.LoginAttemptResponse{
+		ID:            attempt.ID,
+		Tipe:          attempt.Tipe,
+		Nilai:         attempt.Nilai,
+		JumlahGagal:   attempt.JumlahGagal,
+		TerakhirGagal: attempt.TerakhirGagal,
+		LockedUntil:   attempt.LockedUntil,
+		IsLocked:      attempt.IsLocked(),
+	}
+	if resp.IsLocked {
+		resp.SisaDetik = int(time.Until(*attempt.LockedUntil).Seconds())
+	}
+	return resp
+}
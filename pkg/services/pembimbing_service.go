@@ -0,0 +1,386 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/internal/models/external"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+	"rires-be/pkg/workflow"
+
+	"gorm.io/gorm"
+)
+
+// Status pengesahan judul oleh dosen pembimbing
+const (
+	PengesahanPending   = "PENDING"
+	PengesahanDisetujui = "DISETUJUI"
+	PengesahanDitolak   = "DITOLAK"
+)
+
+// Aksi timeline untuk pengesahan pembimbing (bukan transisi status, tidak terdaftar di workflow)
+const EventPengesahanPembimbing workflow.Action = "PENGESAHAN_PEMBIMBING"
+
+// ErrPembimbingNotFound dikembalikan jika id_dosen_pembimbing tidak ada di SIMPEG
+var ErrPembimbingNotFound = errors.New("dosen pembimbing tidak ditemukan di SIMPEG")
+
+// ErrPembimbingRequired dikembalikan jika pengajuan di-submit tanpa dosen pembimbing
+var ErrPembimbingRequired = errors.New("dosen pembimbing wajib dipilih (id_dosen_pembimbing)")
+
+// ErrNotPembimbing dikembalikan jika pegawai bukan pembimbing pengajuan / bukan pembimbing sama sekali
+var ErrNotPembimbing = errors.New("anda bukan dosen pembimbing pengajuan ini")
+
+// ErrNotReviewerOrPembimbing dikembalikan saat login pegawai yang bukan reviewer maupun dosen pembimbing
+var ErrNotReviewerOrPembimbing = errors.New("anda bukan reviewer aktif maupun dosen pembimbing")
+
+// PembimbingService mengelola dosen pembimbing pengajuan dan pengesahan judul
+type PembimbingService struct {
+	externalService  *ExternalDataService
+	reviewerIdentity *ReviewerIdentityService
+	events           *PengajuanEventService
+}
+
+// NewPembimbingService creates a new pembimbing service
+func NewPembimbingService() *PembimbingService {
+	return &PembimbingService{
+		externalService:  NewExternalDataService(),
+		reviewerIdentity: NewReviewerIdentityService(),
+		events:           NewPengajuanEventService(),
+	}
+}
+
+// ResolvePegawai memvalidasi id_dosen_pembimbing ke SIMPEG
+func (s *PembimbingService) ResolvePegawai(idPegawai int) (*external.Pegawai, error) {
+	pegawai, err := s.externalService.GetPegawaiByID(idPegawai)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPembimbingNotFound
+		}
+		return nil, err
+	}
+	return pegawai, nil
+}
+
+// Assign menetapkan dosen pembimbing pengajuan. Dipanggil di dalam transaksi.
+// Pergantian pembimbing mengembalikan pengesahan ke PENDING; kolom dosen_pembimbing
+// (teks) diisi oleh pemanggil dengan snapshot nama yang sama.
+func (s *PembimbingService) Assign(tx *gorm.DB, pengajuan *models.Pengajuan, pegawai *external.Pegawai, userUpdate string) error {
+	nama := pegawai.GetNamaLengkap()
+
+	var existing models.PembimbingPengajuan
+	err := tx.Where("id_pengajuan = ?", pengajuan.ID).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		now := time.Now()
+		return tx.Create(&models.PembimbingPengajuan{
+			IDPengajuan:      pengajuan.ID,
+			IDPegawai:        pegawai.ID,
+			NamaPegawai:      nama,
+			StatusPengesahan: PengesahanPending,
+			TglInsert:        &now,
+			UserUpdate:       userUpdate,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing.IDPegawai == pegawai.ID {
+		return nil
+	}
+
+	return tx.Model(&existing).Updates(map[string]interface{}{
+		"id_pegawai":         pegawai.ID,
+		"nama_pegawai":       nama,
+		"status_pengesahan":  PengesahanPending,
+		"catatan_pengesahan": "",
+		"tgl_pengesahan":     nil,
+		"user_update":        userUpdate,
+	}).Error
+}
+
+// Get mengembalikan pembimbing pengajuan, nil jika pengajuan belum memakai pembimbing SIMPEG
+func (s *PembimbingService) Get(idPengajuan int) (*models.PembimbingPengajuan, error) {
+	var pembimbing models.PembimbingPengajuan
+	if err := database.DB.Where("id_pengajuan = ?", idPengajuan).First(&pembimbing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pembimbing, nil
+}
+
+// EndorsementPending true jika pengajuan punya pembimbing yang belum menyetujui judul.
+// Pengajuan lama tanpa pembimbing SIMPEG tidak ditahan.
+func (s *PembimbingService) EndorsementPending(idPengajuan int) (bool, error) {
+	pembimbing, err := s.Get(idPengajuan)
+	if err != nil {
+		return false, err
+	}
+	return pembimbing != nil && !pembimbing.IsEndorsed(), nil
+}
+
+// Endorse mencatat keputusan pembimbing atas judul. Hanya selama judul masih PENDING
+// (sebelum plotting reviewer); keputusan boleh diubah selama masih PENDING.
+func (s *PembimbingService) Endorse(idPengajuan int, idPegawai int, req *request.PengesahanPembimbingRequest, userUpdate string) (*response.PembimbingResponse, error) {
+	var pembimbing models.PembimbingPengajuan
+	if err := database.DB.Where("id_pengajuan = ? AND id_pegawai = ?", idPengajuan, idPegawai).First(&pembimbing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotPembimbing
+		}
+		return nil, err
+	}
+
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPengajuanNotFound
+		}
+		return nil, err
+	}
+
+	if pengajuan.StatusJudul != workflow.StatusPending {
+		return nil, errors.New("pengesahan hanya dapat diberikan untuk judul berstatus PENDING")
+	}
+	catatan := strings.TrimSpace(req.Catatan)
+	if req.Keputusan == PengesahanDitolak && catatan == "" {
+		return nil, errors.New("catatan wajib diisi jika judul tidak disetujui")
+	}
+
+	statusLama := pembimbing.StatusPengesahan
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&pembimbing).Updates(map[string]interface{}{
+			"status_pengesahan":  req.Keputusan,
+			"catatan_pengesahan": catatan,
+			"tgl_pengesahan":     &now,
+			"user_update":        userUpdate,
+		}).Error; err != nil {
+			return err
+		}
+
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    EventPengesahanPembimbing,
+			Actor:     workflow.Actor{IDPegawai: idPegawai, UserUpdate: userUpdate},
+			Pengajuan: &pengajuan,
+			Catatan:   catatan,
+			DataLama:  map[string]interface{}{"status_pengesahan": statusLama},
+			DataBaru:  map[string]interface{}{"status_pengesahan": req.Keputusan},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(&pembimbing), nil
+}
+
+// GetTeams mengembalikan semua tim yang dibimbing pegawai, terbaru di atas.
+// statusFilter: status pengesahan (PENDING, DISETUJUI, DITOLAK) atau kosong.
+func (s *PembimbingService) GetTeams(idPegawai int, statusFilter string) ([]response.TimBimbinganResponse, error) {
+	query := database.DB.Where("id_pegawai = ?", idPegawai)
+	if statusFilter != "" {
+		query = query.Where("status_pengesahan = ?", statusFilter)
+	}
+
+	var rows []models.PembimbingPengajuan
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.TimBimbinganResponse, 0, len(rows))
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	byPengajuan := make(map[int]models.PembimbingPengajuan, len(rows))
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		byPengajuan[row.IDPengajuan] = row
+		ids = append(ids, row.IDPengajuan)
+	}
+
+	var pengajuanList []models.Pengajuan
+	if err := database.DB.Preload("Kategori").
+		Where("id IN ? AND hapus = ?", ids, 0).
+		Order("tgl_pengajuan DESC").
+		Find(&pengajuanList).Error; err != nil {
+		return nil, err
+	}
+
+	var anggotaList []models.PengajuanAnggota
+	if err := database.DB.Where("id_pengajuan IN ? AND hapus = ?", ids, 0).
+		Order("urutan ASC").
+		Find(&anggotaList).Error; err != nil {
+		return nil, err
+	}
+	anggotaByPengajuan := make(map[int][]response.AnggotaResponse)
+	for _, a := range anggotaList {
+		anggotaByPengajuan[a.IDPengajuan] = append(anggotaByPengajuan[a.IDPengajuan], response.AnggotaResponse{
+			ID:          a.ID,
+			NIMAnggota:  a.NIMAnggota,
+			NamaAnggota: a.NamaAnggota,
+			IsKetua:     a.IsKetua,
+			Urutan:      a.Urutan,
+		})
+	}
+
+	for _, p := range pengajuanList {
+		pembimbing := byPengajuan[p.ID]
+		item := response.TimBimbinganResponse{
+			IDPengajuan:       p.ID,
+			KodePengajuan:     p.KodePengajuan,
+			Judul:             p.Judul,
+			Tahun:             p.Tahun,
+			NamaKetua:         p.NamaKetua,
+			NIMKetua:          p.NIMKetua,
+			Anggota:           anggotaByPengajuan[p.ID],
+			StatusJudul:       p.StatusJudul,
+			StatusProposal:    p.StatusProposal,
+			StatusFinal:       p.StatusFinal,
+			StatusPengesahan:  pembimbing.StatusPengesahan,
+			CatatanPengesahan: pembimbing.CatatanPengesahan,
+			TglPengesahan:     pembimbing.TglPengesahan,
+			TglPengajuan:      p.TglPengajuan,
+		}
+		if p.Kategori != nil {
+			item.NamaKategori = p.Kategori.NamaKategori
+		}
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// Supervises memeriksa apakah pegawai adalah pembimbing pengajuan
+func (s *PembimbingService) Supervises(idPengajuan int, idPegawai int) (bool, error) {
+	var count int64
+	if err := database.DB.Model(&models.PembimbingPengajuan{}).
+		Where("id_pengajuan = ? AND id_pegawai = ?", idPengajuan, idPegawai).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ResolveFromLogin mencari pegawai yang login untuk dosen pembimbing yang bukan reviewer.
+// Email SSO di-resolve ke id_pegawai lewat SIMPEG, lalu dicocokkan ke db_pembimbing_pengajuan.
+func (s *PembimbingService) ResolveFromLogin(identity *AuthIdentity, loginUsername string) (*external.Pegawai, error) {
+	email := strings.TrimSpace(identity.Email)
+	if email == "" && strings.Contains(loginUsername, "@") {
+		email = strings.TrimSpace(loginUsername)
+	}
+	if email == "" {
+		return nil, ErrNotReviewerOrPembimbing
+	}
+
+	pegawai, err := s.externalService.GetPegawaiByEmail(email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[Pembimbing] SIMPEG lookup failed for %s: %v", email, err)
+		}
+		return nil, ErrNotReviewerOrPembimbing
+	}
+
+	active, err := isActivePembimbing(pegawai.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrNotReviewerOrPembimbing
+	}
+	return pegawai, nil
+}
+
+// ResolveFromClaims mengembalikan id_pegawai pemilik token pegawai.
+// Token reviewer diverifikasi ke db_reviewer, token khusus pembimbing (id_user 0) harus masih membimbing.
+func (s *PembimbingService) ResolveFromClaims(claims *utils.JWTClaims) (int, error) {
+	if claims == nil || claims.UserType != UserTypePegawai {
+		return 0, ErrNotPembimbing
+	}
+
+	if claims.UserID != 0 {
+		reviewer, err := s.reviewerIdentity.ResolveFromClaims(claims)
+		if err != nil {
+			return 0, err
+		}
+		return reviewer.IDPegawai, nil
+	}
+
+	idPegawai, err := strconv.Atoi(claims.UserData["id_pegawai"])
+	if err != nil || idPegawai == 0 {
+		return 0, ErrNotPembimbing
+	}
+	active, err := isActivePembimbing(idPegawai)
+	if err != nil {
+		return 0, err
+	}
+	if !active {
+		return 0, ErrNotPembimbing
+	}
+	return idPegawai, nil
+}
+
+// toResponse memetakan model pembimbing ke response
+func (s *PembimbingService) toResponse(p *models.PembimbingPengajuan) *response.PembimbingResponse {
+	return &response.PembimbingResponse{
+		IDPegawai:         p.IDPegawai,
+		NamaPegawai:       p.NamaPegawai,
+		StatusPengesahan:  p.StatusPengesahan,
+		CatatanPengesahan: p.CatatanPengesahan,
+		TglPengesahan:     p.TglPengesahan,
+	}
+}
+
+// NewPembimbingClaims menyusun claims pegawai yang hanya dosen pembimbing (bukan reviewer).
+// id_user 0 menandai token tanpa db_reviewer; subject memakai NIP (atau email jika NIP kosong).
+func NewPembimbingClaims(pegawai *external.Pegawai, nip, jabatan, unit string) (*utils.JWTClaims, interface{}) {
+	username := nip
+	if username == "" {
+		username = pegawai.EmailUMM
+	}
+
+	claims := utils.NewClaims(
+		0,
+		username,
+		pegawai.EmailUMM,
+		UserTypePegawai,
+		4, // Level pegawai
+		map[string]string{
+			"nama":       pegawai.GetNamaLengkap(),
+			"jabatan":    jabatan,
+			"unit":       unit,
+			"id_pegawai": strconv.Itoa(pegawai.ID),
+			"pembimbing": "1",
+		},
+	)
+
+	return claims, map[string]interface{}{
+		"id_pegawai": pegawai.ID,
+		"nip":        nip,
+		"nama":       pegawai.GetNamaLengkap(),
+		"email":      pegawai.EmailUMM,
+		"jabatan":    jabatan,
+		"unit":       unit,
+		"pembimbing": true,
+	}
+}
+
+// isActivePembimbing memeriksa apakah pegawai masih membimbing pengajuan yang belum dihapus
+func isActivePembimbing(idPegawai int) (bool, error) {
+	var count int64
+	if err := database.DB.Model(&models.PembimbingPengajuan{}).
+		Joins("JOIN db_pengajuan_pkm p ON p.id = db_pembimbing_pengajuan.id_pengajuan").
+		Where("db_pembimbing_pengajuan.id_pegawai = ? AND p.hapus = ?", idPegawai, 0).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
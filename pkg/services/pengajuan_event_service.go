@@ -0,0 +1,273 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/workflow"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrPengajuanNotFound dikembalikan jika pengajuan tidak ada atau sudah dihapus
+var ErrPengajuanNotFound = errors.New("pengajuan tidak ditemukan")
+
+// ErrTimelineForbidden dikembalikan jika user bukan tim, reviewer atau admin pengajuan
+var ErrTimelineForbidden = errors.New("anda tidak memiliki akses ke riwayat pengajuan ini")
+
+// PengajuanEvent adalah data satu event yang akan dicatat ke db_pengajuan_event
+type PengajuanEvent struct {
+	Action    workflow.Action
+	Actor     workflow.Actor
+	Pengajuan *models.Pengajuan      // kondisi setelah transisi
+	Before    *models.Pengajuan      // snapshot sebelum transisi (nil untuk pengajuan baru)
+	Updates   map[string]interface{} // kolom db_pengajuan_pkm yang di-update
+	Catatan   string
+
+	// Perubahan di luar kolom db_pengajuan_pkm (mis. anggota tim)
+	DataLama map[string]interface{}
+	DataBaru map[string]interface{}
+}
+
+// PengajuanEventService mencatat dan membaca riwayat perubahan pengajuan
+type PengajuanEventService struct {
+	workflow *workflow.Engine
+}
+
+// NewPengajuanEventService creates a new pengajuan event service
+func NewPengajuanEventService() *PengajuanEventService {
+	return &PengajuanEventService{
+		workflow: workflow.Default(),
+	}
+}
+
+// Record menyimpan satu event. Dipanggil di dalam transaksi yang sama dengan perubahan datanya.
+func (s *PengajuanEventService) Record(tx *gorm.DB, event *PengajuanEvent) error {
+	p := event.Pengajuan
+	entry := &models.PengajuanEvent{
+		IDPengajuan: p.ID,
+		Aksi:        string(event.Action),
+		Catatan:     event.Catatan,
+		ActorType:   eventActorType(event.Actor),
+		ActorID:     event.Actor.UserUpdate,
+		TglInsert:   time.Now(),
+	}
+
+	// 1. Status lama -> baru pada tahap transisi
+	statusColumn := ""
+	if t, err := s.workflow.Transition(p, event.Action); err == nil {
+		statusColumn = t.Stage.StatusColumn()
+		entry.Tahap = string(t.Stage)
+		entry.StatusBaru = t.Stage.Status(p)
+		if event.Before != nil {
+			entry.StatusLama = t.Stage.Status(event.Before)
+		}
+	}
+
+	// 2. Kolom lain yang berubah
+	dataLama := make(map[string]interface{})
+	dataBaru := make(map[string]interface{})
+	for column, value := range event.Updates {
+		if column == "user_update" || column == statusColumn {
+			continue
+		}
+
+		newValue := eventValue(value)
+		if event.Before == nil {
+			dataBaru[column] = newValue
+			continue
+		}
+
+		oldValue := pengajuanColumnValue(event.Before, column)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		dataLama[column] = oldValue
+		dataBaru[column] = newValue
+	}
+	for key, value := range event.DataLama {
+		dataLama[key] = value
+	}
+	for key, value := range event.DataBaru {
+		dataBaru[key] = value
+	}
+
+	var err error
+	if entry.DataLama, err = encodeEventData(dataLama); err != nil {
+		return err
+	}
+	if entry.DataBaru, err = encodeEventData(dataBaru); err != nil {
+		return err
+	}
+
+	return tx.Create(entry).Error
+}
+
+// GetTimeline mengembalikan riwayat pengajuan, terlama di atas.
+// Hanya tim (ketua/anggota), dosen pembimbing, reviewer yang pernah di-plot dan admin yang boleh melihat.
+func (s *PengajuanEventService) GetTimeline(idPengajuan int, viewer workflow.Actor) ([]response.PengajuanEventResponse, error) {
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPengajuanNotFound
+		}
+		return nil, err
+	}
+
+	allowed, err := s.canView(&pengajuan, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrTimelineForbidden
+	}
+
+	var events []models.PengajuanEvent
+	if err := database.DB.Where("id_pengajuan = ?", idPengajuan).
+		Order("tgl_insert ASC, id ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.PengajuanEventResponse, 0, len(events))
+	for _, event := range events {
+		result = append(result, response.PengajuanEventResponse{
+			ID:         event.ID,
+			Aksi:       event.Aksi,
+			Tahap:      event.Tahap,
+			StatusLama: event.StatusLama,
+			StatusBaru: event.StatusBaru,
+			DataLama:   decodeEventData(event.DataLama),
+			DataBaru:   decodeEventData(event.DataBaru),
+			Catatan:    event.Catatan,
+			ActorType:  event.ActorType,
+			ActorID:    event.ActorID,
+			TglInsert:  event.TglInsert,
+		})
+	}
+	return result, nil
+}
+
+// canView memeriksa hak akses timeline
+func (s *PengajuanEventService) canView(p *models.Pengajuan, viewer workflow.Actor) (bool, error) {
+	if viewer.IsAdmin {
+		return true, nil
+	}
+
+	if viewer.IDPegawai > 0 {
+		if (p.IDReviewerJudul != nil && *p.IDReviewerJudul == viewer.IDPegawai) ||
+			(p.IDReviewerProposal != nil && *p.IDReviewerProposal == viewer.IDPegawai) {
+			return true, nil
+		}
+
+		// Reviewer yang plottingnya sudah dibatalkan tetap boleh melihat riwayatnya
+		var count int64
+		if err := database.DB.Model(&models.PlottingReviewer{}).
+			Where("id_pengajuan = ? AND id_pegawai = ?", p.ID, viewer.IDPegawai).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+
+		// Dosen pembimbing tim
+		if err := database.DB.Model(&models.PembimbingPengajuan{}).
+			Where("id_pengajuan = ? AND id_pegawai = ?", p.ID, viewer.IDPegawai).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		return count > 0, nil
+	}
+
+	if viewer.NIM != "" {
+		if p.NIMKetua == viewer.NIM {
+			return true, nil
+		}
+
+		var count int64
+		if err := database.DB.Model(&models.PengajuanAnggota{}).
+			Where("id_pengajuan = ? AND nim_anggota = ? AND hapus = ?", p.ID, viewer.NIM, 0).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		return count > 0, nil
+	}
+
+	return false, nil
+}
+
+// eventActorType memetakan aktor workflow ke jenis user
+func eventActorType(actor workflow.Actor) string {
+	switch {
+	case actor.IsAdmin:
+		return UserTypeAdmin
+	case actor.IDPegawai > 0:
+		return UserTypePegawai
+	case actor.NIM != "":
+		return UserTypeMahasiswa
+	}
+	return ""
+}
+
+// pengajuanColumnValue membaca nilai kolom db_pengajuan_pkm dari struct berdasarkan tag gorm
+func pengajuanColumnValue(p *models.Pengajuan, column string) interface{} {
+	value := reflect.ValueOf(p).Elem()
+	fields := value.Type()
+	for i := 0; i < fields.NumField(); i++ {
+		settings := schema.ParseTagSetting(fields.Field(i).Tag.Get("gorm"), ";")
+		if settings["COLUMN"] == column {
+			return eventValue(value.Field(i).Interface())
+		}
+	}
+	return nil
+}
+
+// eventValue men-dereference pointer agar nilai lama & baru bisa dibandingkan
+func eventValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// encodeEventData menyimpan map perubahan sebagai JSON (kosong jika tidak ada perubahan)
+func encodeEventData(data map[string]interface{}) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal event data: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// decodeEventData membaca kembali JSON perubahan
+func decodeEventData(data string) map[string]interface{} {
+	if data == "" {
+		return nil
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return map[string]interface{}{"raw": data}
+	}
+	return result
+}
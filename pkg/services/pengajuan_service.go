@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"mime/multipart"
 	"strings"
 	"time"
@@ -14,16 +15,33 @@ import (
 	"rires-be/internal/models/external"
 	"rires-be/pkg/database"
 	"rires-be/pkg/utils"
+	"rires-be/pkg/workflow"
 
 	"gorm.io/gorm"
 )
 
+// Peran mahasiswa pada sebuah pengajuan
+const (
+	PeranKetua   = "ketua"
+	PeranAnggota = "anggota" // hanya baca; aksi yang mengubah data tetap khusus ketua
+)
+
+// ErrNotTeamMember dikembalikan jika mahasiswa bukan ketua/anggota pengajuan
+var ErrNotTeamMember = errors.New("anda bukan anggota tim pengajuan ini")
+
 // PengajuanService handles PKM submission business logic
 type PengajuanService struct {
 	externalService *ExternalDataService
 	fileService     *FileUploadService
 	validator       *utils.StatusValidator
 	mapper          *MapperService
+	workflow        *workflow.Engine
+	events          *PengajuanEventService
+	invitations     *InvitationService
+	pembimbing      *PembimbingService
+	kemiripan       *KemiripanService
+	periode         *PeriodeService
+	kode            *KodePengajuanService
 }
 
 // NewPengajuanService creates a new pengajuan service
@@ -33,6 +51,13 @@ func NewPengajuanService() *PengajuanService {
 		fileService:     NewFileUploadService(),
 		validator:       utils.NewStatusValidator(),
 		mapper:          NewMapperService(),
+		workflow:        workflow.Default(),
+		events:          NewPengajuanEventService(),
+		invitations:     NewInvitationService(),
+		pembimbing:      NewPembimbingService(),
+		kemiripan:       NewKemiripanService(),
+		periode:         NewPeriodeService(),
+		kode:            NewKodePengajuanService(),
 	}
 }
 
@@ -40,8 +65,56 @@ func NewPengajuanService() *PengajuanService {
 // CREATE JUDUL PKM
 // ========================================
 
+// preparedPengajuan adalah data CreateJudulPKM yang sudah divalidasi dan siap disimpan
+type preparedPengajuan struct {
+	req        *request.CreatePengajuanRequest
+	nimKetua   string
+	isAdmin    bool
+	kategori   models.KategoriPKM
+	periode    *models.TglSetting
+	pembimbing *external.Pegawai
+}
+
 // CreateJudulPKM creates new PKM title submission
 func (s *PengajuanService) CreateJudulPKM(req *request.CreatePengajuanRequest, nimKetua string, isAdmin bool) (*response.PengajuanResponse, error) {
+	// 1-9. Lengkapi tim dan validasi
+	prepared, err := s.preparePengajuan(req, nimKetua, isAdmin, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 10. START TRANSACTION
+	tx := database.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 11-14. Kode, pengajuan, tim, undangan dan timeline
+	pengajuan, err := s.insertPengajuan(tx, prepared)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 15. COMMIT TRANSACTION
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// 16. Fetch created data for response, with similar judul as warning
+	result, err := s.GetPengajuanDetail(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.KemiripanJudul = s.refreshKemiripan(pengajuan.ID, pengajuan.Judul)
+	return result, nil
+}
+
+// preparePengajuan melengkapi ketua & urutan tim lalu menjalankan seluruh validasi CreateJudulPKM
+// tanpa menyimpan apa pun (dipakai juga oleh import). pending = tim lain yang belum tersimpan, boleh nil.
+func (s *PengajuanService) preparePengajuan(req *request.CreatePengajuanRequest, nimKetua string, isAdmin bool, pending *utils.PendingParticipation) (*preparedPengajuan, error) {
 	// 1. Auto-add ketua to anggota list if not already present
 	ketuaFound := false
 	for i, anggota := range req.Anggota {
@@ -81,52 +154,35 @@ func (s *PengajuanService) CreateJudulPKM(req *request.CreatePengajuanRequest, n
 		return nil, err
 	}
 
-	// 4. Check if registration period is open (skip for admin)
-	if !isAdmin {
-		if err := s.validator.CanSubmitPengajuan(); err != nil {
+	// 4-8. Full validation (registration period, team, NEOMAA) - drafts skip it until submitted
+	if !req.IsDraft {
+		if err := s.validateSubmission(s.convertToAnggotaModels(req.Anggota), isAdmin); err != nil {
 			return nil, err
 		}
 	}
 
-	// 5. Validate team size
-	if err := s.validator.ValidateTeamSize(s.convertToAnggotaModels(req.Anggota)); err != nil {
-		return nil, err
-	}
-
-	// 6. Validate team structure (1 ketua)
-	if err := s.validator.ValidateTeamStructure(s.convertToAnggotaModels(req.Anggota)); err != nil {
+	// Pengajuan terikat ke periode pendaftaran aktif; tahun mengikuti periode, bukan tanggal hari ini
+	periode, err := s.periode.Active()
+	if err != nil {
 		return nil, err
 	}
 
-	// 7. Validate no duplicate NIM
-	if err := s.validator.ValidateNoDuplicateNIM(s.convertToAnggotaModels(req.Anggota)); err != nil {
+	// Batas ketua/anggota per periode juga berlaku untuk draft (slot sudah terpakai)
+	if err := s.validator.ValidateParticipationLimitsWithPending(s.convertToAnggotaModels(req.Anggota), periode.ID, 0, pending); err != nil {
 		return nil, err
 	}
 
-	// 8. Get ketua NIM (now guaranteed to be nimKetua)
-	ketuaNIM := nimKetua
-
-	// 9. Validate all NIMs exist in NEOMAA (skip for admin - for testing purposes)
-	nims := make([]string, len(req.Anggota))
-	for i, anggota := range req.Anggota {
-		nims[i] = anggota.NIM
-	}
-
-	var mahasiswaList []external.Mahasiswa
-	if !isAdmin {
-		// For mahasiswa: validate NIMs in NEOMAA
-		var err error
-		mahasiswaList, err = s.externalService.GetMahasiswaByNIMs(nims)
+	// Dosen pembimbing dari SIMPEG, wajib kecuali draft
+	var pembimbing *external.Pegawai
+	if req.IDDosenPembimbing != 0 {
+		pegawai, err := s.pembimbing.ResolvePegawai(req.IDDosenPembimbing)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch mahasiswa data: %w", err)
-		}
-
-		if len(mahasiswaList) != len(nims) {
-			return nil, errors.New("beberapa NIM tidak ditemukan di database mahasiswa")
+			return nil, err
 		}
-	} else {
-		// For admin: try to fetch but don't fail if not found
-		mahasiswaList, _ = s.externalService.GetMahasiswaByNIMs(nims)
+		pembimbing = pegawai
+		req.DosenPembimbing = pegawai.GetNamaLengkap()
+	} else if !req.IsDraft {
+		return nil, ErrPembimbingRequired
 	}
 
 	// 9. Get kategori
@@ -135,21 +191,31 @@ func (s *PengajuanService) CreateJudulPKM(req *request.CreatePengajuanRequest, n
 		return nil, errors.New("kategori PKM tidak ditemukan")
 	}
 
-	// 10. Generate kode pengajuan
-	tahun := time.Now().Year()
-	kodePengajuan, err := utils.GenerateKodePengajuan(&kategori, tahun)
+	return &preparedPengajuan{
+		req:        req,
+		nimKetua:   nimKetua,
+		isAdmin:    isAdmin,
+		kategori:   kategori,
+		periode:    periode,
+		pembimbing: pembimbing,
+	}, nil
+}
+
+// insertPengajuan menyimpan pengajuan yang sudah lolos preparePengajuan beserta periode, pembimbing,
+// tim, undangan dan timeline. Dipanggil di dalam transaksi; rollback dilakukan pemanggil.
+func (s *PengajuanService) insertPengajuan(tx *gorm.DB, p *preparedPengajuan) (*models.Pengajuan, error) {
+	// 10. Batas ketua/anggota per periode dicek ulang di dalam transaksi (dikunci per NIM sampai commit)
+	req, nimKetua := p.req, p.nimKetua
+	if err := s.validator.ValidateParticipationLimitsTx(tx, s.convertToAnggotaModels(req.Anggota), p.periode.ID, 0); err != nil {
+		return nil, err
+	}
+
+	// 11. Generate kode pengajuan (nomor urut dikunci sampai commit)
+	kodePengajuan, err := s.kode.Generate(tx, &p.kategori, p.periode)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate kode pengajuan: %w", err)
 	}
 
-	// 11. START TRANSACTION
-	tx := database.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
 	// 12. Create pengajuan
 	now := time.Now()
 
@@ -166,7 +232,7 @@ func (s *PengajuanService) CreateJudulPKM(req *request.CreatePengajuanRequest, n
 	pengajuan := &models.Pengajuan{
 		KodePengajuan:   kodePengajuan,
 		NamaKetua:       req.NamaKetua,
-		NIMKetua:        ketuaNIM,
+		NIMKetua:        nimKetua,
 		IDKategori:      req.IDKategori,
 		Judul:           req.Judul,
 		EmailKetua:      req.EmailKetua,
@@ -176,21 +242,42 @@ func (s *PengajuanService) CreateJudulPKM(req *request.CreatePengajuanRequest, n
 		DosenPembimbing: req.DosenPembimbing,
 		ParameterData:   parameterDataJSON,
 		TglPengajuan:    &now,
-		Tahun:           tahun,
-		StatusJudul:     "PENDING",
-		StatusFinal:     "DRAFT",
+		Tahun:           p.periode.Tahun(),
 		Status:          1,
 		Hapus:           0,
 		TglInsert:       &now,
 		UserUpdate:      nimKetua, // Store NIM for mahasiswa
 	}
 
+	// Status awal (status_judul & status_final) ditentukan workflow: DRAFT atau langsung PENDING
+	action := workflow.ActionSubmitJudul
+	if req.IsDraft {
+		action = workflow.ActionSaveDraft
+	}
+	input := workflow.Input{
+		Actor: workflow.Actor{NIM: nimKetua, IsAdmin: p.isAdmin, UserUpdate: nimKetua},
+	}
+	updates, err := s.workflow.Apply(pengajuan, action, input)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := tx.Create(pengajuan).Error; err != nil {
-		tx.Rollback()
 		return nil, fmt.Errorf("failed to create pengajuan: %w", err)
 	}
 
+	if err := s.periode.Assign(tx, pengajuan.ID, p.periode.ID, nimKetua); err != nil {
+		return nil, fmt.Errorf("failed to save periode pengajuan: %w", err)
+	}
+
+	if p.pembimbing != nil {
+		if err := s.pembimbing.Assign(tx, pengajuan, p.pembimbing, nimKetua); err != nil {
+			return nil, fmt.Errorf("failed to save dosen pembimbing: %w", err)
+		}
+	}
+
 	// 13. Create anggota tim
+	anggotaList := make([]models.PengajuanAnggota, 0, len(req.Anggota))
 	for _, anggota := range req.Anggota {
 		anggotaModel := &models.PengajuanAnggota{
 			IDPengajuan: pengajuan.ID,
@@ -203,18 +290,30 @@ func (s *PengajuanService) CreateJudulPKM(req *request.CreatePengajuanRequest, n
 		}
 
 		if err := tx.Create(anggotaModel).Error; err != nil {
-			tx.Rollback()
 			return nil, fmt.Errorf("failed to create anggota tim: %w", err)
 		}
+		anggotaList = append(anggotaList, *anggotaModel)
 	}
 
-	// 14. COMMIT TRANSACTION
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// Undang anggota non-ketua
+	if err := s.invitations.Sync(tx, pengajuan, anggotaList, nimKetua); err != nil {
+		return nil, fmt.Errorf("failed to create undangan anggota: %w", err)
 	}
 
-	// 16. Fetch created data for response
-	return s.GetPengajuanDetail(pengajuan.ID)
+	// 14. Record timeline event
+	updates["judul"] = pengajuan.Judul
+	updates["id_kategori"] = pengajuan.IDKategori
+	if err := s.events.Record(tx, &PengajuanEvent{
+		Action:    action,
+		Actor:     input.Actor,
+		Pengajuan: pengajuan,
+		Updates:   updates,
+		DataBaru:  map[string]interface{}{"anggota": anggotaNIMs(req.Anggota)},
+	}); err != nil {
+		return nil, err
+	}
+
+	return pengajuan, nil
 }
 
 // ========================================
@@ -305,7 +404,7 @@ func (s *PengajuanService) GetPengajuanDetail(idPengajuan int) (*response.Pengaj
 		Find(&reviewProposalHistory)
 
 	// 8. Map to response DTO
-	return s.mapper.MapPengajuanToDetailResponse(
+	result := s.mapper.MapPengajuanToDetailResponse(
 		&pengajuan,
 		ketua,
 		mahasiswaList,
@@ -316,28 +415,61 @@ func (s *PengajuanService) GetPengajuanDetail(idPengajuan int) (*response.Pengaj
 		reviewerProposal,
 		reviewJudulHistory,
 		reviewProposalHistory,
-	), nil
+	)
+
+	// 9. Status undangan tiap anggota
+	statusUndangan := s.invitations.StatusByNIM(pengajuan.ID)
+	for i := range result.AnggotaList {
+		result.AnggotaList[i].StatusUndangan = statusUndangan[result.AnggotaList[i].NIMAnggota]
+	}
+
+	// 10. Dosen pembimbing dan status pengesahan
+	if pembimbing, err := s.pembimbing.Get(pengajuan.ID); err == nil && pembimbing != nil {
+		result.Pembimbing = s.pembimbing.toResponse(pembimbing)
+	}
+
+	// 11. Periode pendaftaran
+	if idPeriode, err := s.periode.Get(pengajuan.ID); err == nil {
+		result.IDPeriode = idPeriode
+	}
+
+	return result, nil
 }
 
 // ========================================
 // GET MY SUBMISSIONS
 // ========================================
 
-// GetMySubmissions gets all submissions by authenticated mahasiswa (ketua)
-func (s *PengajuanService) GetMySubmissions(nimKetua string, statusFilter string) ([]response.PengajuanListResponse, error) {
-	// Build query
-	query := database.DB.Where("nim_ketua = ? AND hapus = ?", nimKetua, 0)
+// GetMySubmissions gets all submissions where authenticated mahasiswa is ketua or anggota.
+// peranFilter: "ketua", "anggota" atau kosong untuk keduanya.
+func (s *PengajuanService) GetMySubmissions(nim string, statusFilter string, peranFilter string) ([]response.PengajuanListResponse, error) {
+	// Build query: ketua, atau tercatat sebagai anggota tim
+	memberOf := database.DB.Model(&models.PengajuanAnggota{}).
+		Select("id_pengajuan").
+		Where("nim_anggota = ? AND hapus = ?", nim, 0)
+
+	query := database.DB.Where("hapus = ?", 0)
+	switch peranFilter {
+	case PeranKetua:
+		query = query.Where("nim_ketua = ?", nim)
+	case PeranAnggota:
+		query = query.Where("nim_ketua <> ? AND id IN (?)", nim, memberOf)
+	default:
+		query = query.Where("nim_ketua = ? OR id IN (?)", nim, memberOf)
+	}
 
 	// Apply status filter
 	switch statusFilter {
+	case "draft":
+		query = query.Where("status_judul = ?", workflow.StatusDraft)
 	case "pending":
-		query = query.Where("status_judul = ?", "PENDING")
+		query = query.Where("status_judul = ?", workflow.StatusPending)
 	case "acc":
-		query = query.Where("status_judul = ?", "ACC")
+		query = query.Where("status_judul = ?", workflow.StatusACC)
 	case "revisi":
-		query = query.Where("status_judul = ?", "REVISI")
+		query = query.Where("status_judul = ?", workflow.StatusRevisi)
 	case "tolak":
-		query = query.Where("status_judul = ?", "TOLAK")
+		query = query.Where("status_judul = ?", workflow.StatusTolak)
 		// "all" = no filter
 	}
 
@@ -378,6 +510,7 @@ func (s *PengajuanService) GetMySubmissions(nimKetua string, statusFilter string
 			reviewerProposal,
 			"", // reviewerJudulNama not needed for my-submissions
 		)
+		listResp.Peran = peranOf(&pengajuan, nim)
 
 		result = append(result, *listResp)
 	}
@@ -385,6 +518,60 @@ func (s *PengajuanService) GetMySubmissions(nimKetua string, statusFilter string
 	return result, nil
 }
 
+// GetTeamPengajuanDetail returns detail for a team member (read-only for anggota).
+// Admin boleh melihat semua pengajuan.
+func (s *PengajuanService) GetTeamPengajuanDetail(idPengajuan int, nim string, isAdmin bool) (*response.PengajuanResponse, error) {
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPengajuanNotFound
+		}
+		return nil, err
+	}
+
+	if !isAdmin && pengajuan.NIMKetua != nim {
+		var count int64
+		if err := database.DB.Model(&models.PengajuanAnggota{}).
+			Where("id_pengajuan = ? AND nim_anggota = ? AND hapus = ?", pengajuan.ID, nim, 0).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, ErrNotTeamMember
+		}
+	}
+
+	result, err := s.GetPengajuanDetail(idPengajuan)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		result.Peran = peranOf(&pengajuan, nim)
+	}
+	return result, nil
+}
+
+// GetBimbinganDetail returns detail of a pengajuan for its dosen pembimbing
+func (s *PengajuanService) GetBimbinganDetail(idPengajuan int, idPegawai int) (*response.PengajuanResponse, error) {
+	var count int64
+	if err := database.DB.Model(&models.Pengajuan{}).Where("id = ? AND hapus = ?", idPengajuan, 0).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrPengajuanNotFound
+	}
+
+	supervises, err := s.pembimbing.Supervises(idPengajuan, idPegawai)
+	if err != nil {
+		return nil, err
+	}
+	if !supervises {
+		return nil, ErrNotPembimbing
+	}
+
+	return s.GetPengajuanDetail(idPengajuan)
+}
+
 // ========================================
 // UPDATE JUDUL
 // ========================================
@@ -400,14 +587,21 @@ func (s *PengajuanService) UpdateJudul(idPengajuan int, req *request.UpdateJudul
 		return nil, err
 	}
 
-	// 2. Check if user is ketua OR admin
-	if !pengajuan.IsOwner(nimKetua) && !isAdmin {
-		return nil, errors.New("hanya ketua yang dapat merevisi judul")
+	// 2-3. Ketua/admin only: REVISI -> revisi judul (ON_REVIEW), PENDING -> edit anggota, DRAFT -> edit semua
+	before := pengajuan
+	input := workflow.Input{
+		Actor: workflow.Actor{NIM: nimKetua, IsAdmin: isAdmin, UserUpdate: nimKetua},
+	}
+	action, updates, err := s.workflow.ApplyFirst(&pengajuan, input, workflow.ActionReviseJudul, workflow.ActionEditAnggota, workflow.ActionEditDraft)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. Check if can update (status must be REVISI or PENDING)
-	if !pengajuan.CanReviseJudul() {
-		return nil, errors.New("pengajuan hanya dapat diupdate jika status = PENDING atau REVISI")
+	var pembimbing *external.Pegawai
+	if req.IDDosenPembimbing != 0 {
+		if pembimbing, err = s.pembimbing.ResolvePegawai(req.IDDosenPembimbing); err != nil {
+			return nil, err
+		}
 	}
 
 	// 4. START TRANSACTION
@@ -429,14 +623,11 @@ func (s *PengajuanService) UpdateJudul(idPengajuan int, req *request.UpdateJudul
 	}
 
 	// 6. Update based on status
-	updates := map[string]interface{}{
-		"user_update": nimKetua,
-	}
 
-	// Mode: REVISI - Only Update Judul and Parameters
-	if pengajuan.CanEditJudulData() {
+	// Mode: REVISI - Only Update Judul and Parameters (status back to ON_REVIEW, keep reviewer assignment)
+	// Mode: DRAFT - Judul and Parameters may still be incomplete
+	if action == workflow.ActionReviseJudul || action == workflow.ActionEditDraft {
 		updates["judul"] = req.Judul
-		updates["status_judul"] = "ON_REVIEW" // Back to ON_REVIEW, keep reviewer assignment
 		if req.IDKategori != 0 {
 			updates["id_kategori"] = req.IDKategori
 		}
@@ -445,11 +636,20 @@ func (s *PengajuanService) UpdateJudul(idPengajuan int, req *request.UpdateJudul
 		}
 
 		// Update Dosen Pembimbing as it's part of pengajuan data (not strictly ketua biodata)
-		if req.DosenPembimbing != "" {
+		if req.DosenPembimbing != "" && req.IDDosenPembimbing == 0 {
 			updates["dosen_pembimbing"] = req.DosenPembimbing
 		}
 	}
 
+	// Dosen pembimbing SIMPEG boleh diganti di semua mode (mis. setelah pengesahan ditolak)
+	if pembimbing != nil {
+		updates["dosen_pembimbing"] = pembimbing.GetNamaLengkap()
+		if err := s.pembimbing.Assign(tx, &pengajuan, pembimbing, nimKetua); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to save dosen pembimbing: %w", err)
+		}
+	}
+
 	// Note: NamaKetua, EmailKetua, etc. are currently locked (Data Ketua: Read-Only)
 
 	if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
@@ -457,8 +657,16 @@ func (s *PengajuanService) UpdateJudul(idPengajuan int, req *request.UpdateJudul
 		return nil, err
 	}
 
-	// 7. Update anggota if provided and status is PENDING
-	if len(req.Anggota) > 0 && pengajuan.CanEditMembers() {
+	event := &PengajuanEvent{
+		Action:    action,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}
+
+	// 7. Update anggota if provided and status is PENDING or DRAFT
+	if len(req.Anggota) > 0 && (action == workflow.ActionEditAnggota || action == workflow.ActionEditDraft) {
 		// --- Ensure Ketua logic (copied from CreateJudulPKM) ---
 		// Use pengajuan.NIMKetua as the source of truth for who the ketua is
 		ownerNIM := pengajuan.NIMKetua
@@ -493,19 +701,43 @@ func (s *PengajuanService) UpdateJudul(idPengajuan int, req *request.UpdateJudul
 		}
 		// --- End Ensure Ketua logic ---
 
-		// Validate new team structure
-		if err := s.validator.ValidateTeamSize(s.convertToAnggotaModels(req.Anggota)); err != nil {
+		// Validate new team structure (draft team may still be forming, checked on submit)
+		if action != workflow.ActionEditDraft {
+			if err := s.validator.ValidateTeamSize(s.convertToAnggotaModels(req.Anggota)); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if err := s.validator.ValidateTeamStructure(s.convertToAnggotaModels(req.Anggota)); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if err := s.validator.ValidateNoDuplicateNIM(s.convertToAnggotaModels(req.Anggota)); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+		idPeriode, err := s.periode.Get(pengajuan.ID)
+		if err != nil {
 			tx.Rollback()
 			return nil, err
 		}
-		if err := s.validator.ValidateTeamStructure(s.convertToAnggotaModels(req.Anggota)); err != nil {
+		if err := s.validator.ValidateParticipationLimitsTx(tx, s.convertToAnggotaModels(req.Anggota), idPeriode, pengajuan.ID); err != nil {
 			tx.Rollback()
 			return nil, err
 		}
-		if err := s.validator.ValidateNoDuplicateNIM(s.convertToAnggotaModels(req.Anggota)); err != nil {
+
+		// Keep old team for timeline
+		var oldAnggota []models.PengajuanAnggota
+		if err := tx.Where("id_pengajuan = ? AND hapus = ?", pengajuan.ID, 0).Order("urutan ASC").Find(&oldAnggota).Error; err != nil {
 			tx.Rollback()
 			return nil, err
 		}
+		oldNIMs := make([]string, 0, len(oldAnggota))
+		for _, anggota := range oldAnggota {
+			oldNIMs = append(oldNIMs, anggota.NIMAnggota)
+		}
+		event.DataLama = map[string]interface{}{"anggota": oldNIMs}
+		event.DataBaru = map[string]interface{}{"anggota": anggotaNIMs(req.Anggota)}
 
 		// Delete old anggota
 		if err := tx.Where("id_pengajuan = ?", pengajuan.ID).Delete(&models.PengajuanAnggota{}).Error; err != nil {
@@ -514,6 +746,7 @@ func (s *PengajuanService) UpdateJudul(idPengajuan int, req *request.UpdateJudul
 		}
 
 		// Create new anggota
+		anggotaList := make([]models.PengajuanAnggota, 0, len(req.Anggota))
 		for _, anggota := range req.Anggota {
 			anggotaModel := &models.PengajuanAnggota{
 				IDPengajuan: pengajuan.ID,
@@ -529,18 +762,206 @@ func (s *PengajuanService) UpdateJudul(idPengajuan int, req *request.UpdateJudul
 				tx.Rollback()
 				return nil, err
 			}
+			anggotaList = append(anggotaList, *anggotaModel)
+		}
+
+		// Anggota baru diundang, anggota yang dikeluarkan undangannya dibatalkan
+		if err := s.invitations.Sync(tx, &pengajuan, anggotaList, nimKetua); err != nil {
+			tx.Rollback()
+			return nil, err
 		}
 	}
 
-	// 8. COMMIT
+	// 8. Record timeline event
+	if err := s.events.Record(tx, event); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 9. COMMIT
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 
-	// 9. Return updated detail
+	// 10. Return updated detail; judul yang berubah dicek ulang kemiripannya
+	result, err := s.GetPengajuanDetail(idPengajuan)
+	if err != nil {
+		return nil, err
+	}
+	if judul, ok := updates["judul"].(string); ok && judul != before.Judul {
+		result.KemiripanJudul = s.refreshKemiripan(idPengajuan, judul)
+	} else {
+		result.KemiripanJudul, _ = s.kemiripan.GetMatches(idPengajuan)
+	}
+	return result, nil
+}
+
+// refreshKemiripan menghitung ulang judul mirip. Gagal tidak membatalkan penyimpanan judul.
+func (s *PengajuanService) refreshKemiripan(idPengajuan int, judul string) []response.KemiripanResponse {
+	matches, err := s.kemiripan.Refresh(idPengajuan, judul)
+	if err != nil {
+		log.Printf("Failed to check judul similarity for pengajuan %d: %v", idPengajuan, err)
+		return nil
+	}
+	return matches
+}
+
+// ========================================
+// SUBMIT DRAFT
+// ========================================
+
+// SubmitPengajuan submits a draft: runs full validation then moves status_judul DRAFT -> PENDING
+func (s *PengajuanService) SubmitPengajuan(idPengajuan int, nimKetua string, isAdmin bool) (*response.PengajuanResponse, error) {
+	// 1. Get pengajuan
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pengajuan tidak ditemukan")
+		}
+		return nil, err
+	}
+
+	// 2. Ketua/admin only, status must be DRAFT
+	before := pengajuan
+	input := workflow.Input{
+		Actor: workflow.Actor{NIM: nimKetua, IsAdmin: isAdmin, UserUpdate: nimKetua},
+	}
+	if err := s.workflow.Check(&pengajuan, workflow.ActionSubmitDraft, input.Actor); err != nil {
+		return nil, err
+	}
+
+	// 3. Data that may be incomplete in draft
+	judulLength := len([]rune(strings.TrimSpace(pengajuan.Judul)))
+	if judulLength < 10 || judulLength > 500 {
+		return nil, errors.New("judul harus antara 10 sampai 500 karakter")
+	}
+
+	var kategori models.KategoriPKM
+	if err := database.DB.Where("id = ? AND hapus = ?", pengajuan.IDKategori, 0).First(&kategori).Error; err != nil {
+		return nil, errors.New("kategori PKM tidak ditemukan")
+	}
+
+	// 4. Full validation (registration period, team, NEOMAA)
+	var anggota []models.PengajuanAnggota
+	if err := database.DB.Where("id_pengajuan = ? AND hapus = ?", pengajuan.ID, 0).Order("urutan ASC").Find(&anggota).Error; err != nil {
+		return nil, err
+	}
+	if err := s.validateSubmission(anggota, isAdmin); err != nil {
+		return nil, err
+	}
+	idPeriode, err := s.periode.Get(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. All anggota must have accepted their invitation, dosen pembimbing must be chosen
+	pendingMembers, err := s.invitations.PendingMembers(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
+	input.PendingMembers = pendingMembers
+
+	pembimbing, err := s.pembimbing.Get(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pembimbing == nil {
+		return nil, ErrPembimbingRequired
+	}
+
+	// 6. DRAFT -> PENDING, tgl_pengajuan = waktu submit
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionSubmitDraft, input)
+	if err != nil {
+		return nil, err
+	}
+	updates["tgl_pengajuan"] = time.Now()
+
+	// Batas ketua/anggota per periode dicek di transaksi yang sama dengan perubahan status
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.validator.ValidateParticipationLimitsTx(tx, anggota, idPeriode, pengajuan.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
+			return err
+		}
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    workflow.ActionSubmitDraft,
+			Actor:     input.Actor,
+			Pengajuan: &pengajuan,
+			Before:    &before,
+			Updates:   updates,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 7. Return updated detail
 	return s.GetPengajuanDetail(idPengajuan)
 }
 
+// ========================================
+// WITHDRAW
+// ========================================
+
+// WithdrawPengajuan lets the ketua withdraw a pengajuan before judul ACC (soft delete with reason)
+func (s *PengajuanService) WithdrawPengajuan(idPengajuan int, nimKetua string, alasan string) error {
+	// 1. Get pengajuan
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPengajuanNotFound
+		}
+		return err
+	}
+
+	// 2. Ketua only, status_judul before ACC -> hapus = 1
+	before := pengajuan
+	input := workflow.Input{
+		Actor: workflow.Actor{NIM: nimKetua, UserUpdate: nimKetua},
+	}
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionWithdraw, input)
+	if err != nil {
+		return err
+	}
+
+	// 3. Save, close open invitations & ketua transfers so they don't act on a withdrawn pengajuan.
+	// Update bersyarat: review (mis. ACC) atau penghapusan yang masuk setelah pengajuan dibaca membatalkan penarikan.
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&pengajuan).Where("hapus = ? AND nim_ketua = ?", 0, before.NIMKetua)
+		if before.StatusJudul == "" {
+			query = query.Where("(status_judul IS NULL OR status_judul = '')")
+		} else {
+			query = query.Where("status_judul = ?", before.StatusJudul)
+		}
+		result := query.Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("status pengajuan sudah berubah, silakan muat ulang sebelum menarik pengajuan")
+		}
+		if err := tx.Model(&models.UndanganAnggota{}).
+			Where("id_pengajuan = ? AND status_undangan = ?", pengajuan.ID, UndanganPending).
+			Updates(map[string]interface{}{"status_undangan": UndanganCancelled, "user_update": nimKetua}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TransferKetua{}).
+			Where("id_pengajuan = ? AND status_transfer = ?", pengajuan.ID, TransferPending).
+			Updates(map[string]interface{}{"status_transfer": TransferCancelled, "user_update": nimKetua}).Error; err != nil {
+			return err
+		}
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    workflow.ActionWithdraw,
+			Actor:     input.Actor,
+			Pengajuan: &pengajuan,
+			Before:    &before,
+			Updates:   updates,
+			Catatan:   alasan,
+		})
+	})
+}
+
 // ========================================
 // UPLOAD PROPOSAL
 // ========================================
@@ -556,14 +977,15 @@ func (s *PengajuanService) UploadProposal(idPengajuan int, file *multipart.FileH
 		return nil, err
 	}
 
-	// 2. Check if user is ketua OR admin
-	if !pengajuan.IsOwner(nimKetua) && !isAdmin {
-		return nil, errors.New("hanya ketua yang dapat upload proposal")
+	// 2-3. Ketua/admin only, status_judul must be ACC.
+	// If reviewer already assigned (re-upload after revision), status becomes ON_REVIEW, else PENDING
+	before := pengajuan
+	input := workflow.Input{
+		Actor: workflow.Actor{NIM: nimKetua, IsAdmin: isAdmin, UserUpdate: nimKetua},
 	}
-
-	// 3. Check if can upload (status_judul must be ACC)
-	if !pengajuan.CanUploadProposal() {
-		return nil, errors.New("proposal hanya dapat diupload jika judul sudah ACC")
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionUploadProposal, input)
+	if err != nil {
+		return nil, err
 	}
 
 	// 4. Upload file using FileUploadService
@@ -573,19 +995,15 @@ func (s *PengajuanService) UploadProposal(idPengajuan int, file *multipart.FileH
 	}
 
 	// 5. Update pengajuan (store just filename, frontend constructs full URL)
-	// If reviewer already assigned (re-upload after revision), set to ON_REVIEW, else PENDING
-	newStatus := "PENDING"
-	if pengajuan.IDReviewerProposal != nil {
-		newStatus = "ON_REVIEW" // Keep reviewer assigned
-	}
-
-	updates := map[string]interface{}{
-		"file_proposal":   filename,
-		"status_proposal": newStatus,
-		"user_update":     nimKetua,
-	}
-
-	if err := database.DB.Model(&pengajuan).Updates(updates).Error; err != nil {
+	updates["file_proposal"] = filename
+
+	if err := s.saveTransition(&PengajuanEvent{
+		Action:    workflow.ActionUploadProposal,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
 		// Delete uploaded file if DB update fails
 		s.fileService.DeleteFile(filename)
 		return nil, err
@@ -610,14 +1028,14 @@ func (s *PengajuanService) ReviseProposal(idPengajuan int, file *multipart.FileH
 		return nil, err
 	}
 
-	// 2. Check if user is ketua
-	if !pengajuan.IsOwner(nimKetua) {
-		return nil, errors.New("hanya ketua yang dapat merevisi proposal")
+	// 2-3. Ketua only, status_proposal must be REVISI (-> ON_REVIEW, keep reviewer assigned)
+	before := pengajuan
+	input := workflow.Input{
+		Actor: workflow.Actor{NIM: nimKetua, UserUpdate: nimKetua},
 	}
-
-	// 3. Check if can revise (status_proposal must be REVISI)
-	if !pengajuan.CanReviseProposal() {
-		return nil, errors.New("proposal hanya dapat direvisi jika status = REVISI")
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionReviseProposal, input)
+	if err != nil {
+		return nil, err
 	}
 
 	// 4. Delete old file (handle both old and new format)
@@ -634,14 +1052,15 @@ func (s *PengajuanService) ReviseProposal(idPengajuan int, file *multipart.FileH
 	}
 
 	// 6. Update pengajuan (store just filename)
-	// Status: REVISI -> ON_REVIEW (keep reviewer assigned, don't reset to PENDING)
-	updates := map[string]interface{}{
-		"file_proposal":   filename,
-		"status_proposal": "ON_REVIEW", // Keep reviewer assigned, not PENDING
-		"user_update":     nimKetua,
-	}
-
-	if err := database.DB.Model(&pengajuan).Updates(updates).Error; err != nil {
+	updates["file_proposal"] = filename
+
+	if err := s.saveTransition(&PengajuanEvent{
+		Action:    workflow.ActionReviseProposal,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
 		// Delete uploaded file if DB update fails
 		s.fileService.DeleteFile(filename)
 		return nil, err
@@ -657,34 +1076,12 @@ func (s *PengajuanService) ReviseProposal(idPengajuan int, file *multipart.FileH
 
 // GetAllPengajuan gets all pengajuan with filters and pagination (admin only)
 func (s *PengajuanService) GetAllPengajuan(filters map[string]interface{}) ([]response.PengajuanListResponse, *response.PaginationResponse, error) {
-	// 1. Parse filters
+	// 1. Parse pagination
 	page := filters["page"].(int)
 	perPage := filters["per_page"].(int)
-	statusJudul := filters["status_judul"].(string)
-	statusProposal := filters["status_proposal"].(string)
-	statusFinal := filters["status_final"].(string)
-	idKategori := filters["id_kategori"].(int)
-	tahun := filters["tahun"].(int)
 
 	// 2. Build query
-	query := database.DB.Where("hapus = ?", 0)
-
-	// Apply filters
-	if statusJudul != "" {
-		query = query.Where("status_judul = ?", statusJudul)
-	}
-	if statusProposal != "" {
-		query = query.Where("status_proposal = ?", statusProposal)
-	}
-	if statusFinal != "" {
-		query = query.Where("status_final = ?", statusFinal)
-	}
-	if idKategori > 0 {
-		query = query.Where("id_kategori = ?", idKategori)
-	}
-	if tahun > 0 {
-		query = query.Where("tahun = ?", tahun)
-	}
+	query := s.filterPengajuan(filters)
 
 	// 3. Count total records
 	var totalRecords int64
@@ -750,6 +1147,43 @@ func (s *PengajuanService) GetAllPengajuan(filters map[string]interface{}) ([]re
 	return result, paginationResp, nil
 }
 
+// filterPengajuan membangun query daftar pengajuan admin dari filter status_judul, status_proposal,
+// status_final, id_kategori, tahun, id_periode dan include_draft (dipakai juga oleh export)
+func (s *PengajuanService) filterPengajuan(filters map[string]interface{}) *gorm.DB {
+	statusJudul, _ := filters["status_judul"].(string)
+	statusProposal, _ := filters["status_proposal"].(string)
+	statusFinal, _ := filters["status_final"].(string)
+	idKategori, _ := filters["id_kategori"].(int)
+	tahun, _ := filters["tahun"].(int)
+	idPeriode, _ := filters["id_periode"].(int)
+	includeDraft, _ := filters["include_draft"].(bool)
+
+	query := database.DB.Where("hapus = ?", 0)
+
+	// Apply filters (draft belum diajukan, disembunyikan kecuali diminta)
+	if statusJudul != "" {
+		query = query.Where("status_judul = ?", statusJudul)
+	} else if !includeDraft {
+		query = query.Where("status_judul <> ?", workflow.StatusDraft)
+	}
+	if statusProposal != "" {
+		query = query.Where("status_proposal = ?", statusProposal)
+	}
+	if statusFinal != "" {
+		query = query.Where("status_final = ?", statusFinal)
+	}
+	if idKategori > 0 {
+		query = query.Where("id_kategori = ?", idKategori)
+	}
+	if tahun > 0 {
+		query = query.Where("tahun = ?", tahun)
+	}
+	if idPeriode > 0 {
+		query = s.periode.FilterPengajuan(query, idPeriode)
+	}
+	return query
+}
+
 // ========================================
 // ADMIN - ASSIGN REVIEWER
 // ========================================
@@ -775,21 +1209,48 @@ func (s *PengajuanService) AssignReviewerJudul(idPengajuan int, idReviewer int,
 	}
 	idPegawai := reviewer.IDPegawai
 
-	// 3. Check if status allows assignment (must be PENDING or ON_REVIEW)
-	if pengajuan.StatusJudul != "PENDING" && pengajuan.StatusJudul != "ON_REVIEW" {
-		return nil, errors.New("reviewer hanya dapat di-assign untuk pengajuan dengan status PENDING atau ON_REVIEW")
+	// 3. All anggota must have accepted their invitation and dosen pembimbing must have endorsed the judul.
+	// Anggota yang menolak/kedaluwarsa sudah dihapus dari tim, jadi susunan tim saat ini dicek ulang.
+	var anggota []models.PengajuanAnggota
+	if err := database.DB.Where("id_pengajuan = ? AND hapus = ?", pengajuan.ID, 0).Find(&anggota).Error; err != nil {
+		return nil, err
+	}
+	if err := s.validator.ValidateTeamSize(anggota); err != nil {
+		return nil, fmt.Errorf("tim belum lengkap: %w", err)
+	}
+	if err := s.validator.ValidateTeamStructure(anggota); err != nil {
+		return nil, fmt.Errorf("tim belum lengkap: %w", err)
 	}
 
-	// 4. Update pengajuan
-	userUpdateStr := fmt.Sprintf("%d", userID)
+	pendingMembers, err := s.invitations.PendingMembers(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
+	endorsementPending, err := s.pembimbing.EndorsementPending(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
 
-	updates := map[string]interface{}{
-		"id_reviewer_judul": idPegawai,
-		"status_judul":      "ON_REVIEW",
-		"user_update":       userUpdateStr,
+	// 4. Status must be PENDING or ON_REVIEW -> ON_REVIEW with reviewer assigned
+	before := pengajuan
+	input := workflow.Input{
+		Actor:              s.adminActor(userID),
+		Reviewer:           idPegawai,
+		PendingMembers:     pendingMembers,
+		EndorsementPending: endorsementPending,
+	}
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionAssignReviewerJudul, input)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Model(&pengajuan).Updates(updates).Error; err != nil {
+	if err := s.saveTransition(&PengajuanEvent{
+		Action:    workflow.ActionAssignReviewerJudul,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
 		return nil, err
 	}
 
@@ -816,26 +1277,23 @@ func (s *PengajuanService) CancelPlottingJudul(idPengajuan int, userID int) (*re
 		return nil, err
 	}
 
-	// 2. Check if reviewer is assigned
-	if pengajuan.IDReviewerJudul == nil {
-		return nil, errors.New("tidak ada reviewer yang di-assign untuk judul ini")
+	// 2-4. Reviewer must be assigned and not yet reviewed (ON_REVIEW) -> remove reviewer, back to PENDING
+	before := pengajuan
+	input := workflow.Input{
+		Actor: s.adminActor(userID),
 	}
-
-	// 3. Check if status allows cancel (must be ON_REVIEW, not already reviewed)
-	if pengajuan.StatusJudul != "ON_REVIEW" {
-		return nil, errors.New("plotting hanya dapat dibatalkan untuk pengajuan dengan status ON_REVIEW")
-	}
-
-	// 4. Update pengajuan - remove reviewer and reset status to PENDING
-	userUpdateStr := fmt.Sprintf("%d", userID)
-
-	updates := map[string]interface{}{
-		"id_reviewer_judul": nil,
-		"status_judul":      "PENDING",
-		"user_update":       userUpdateStr,
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionCancelPlottingJudul, input)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Model(&pengajuan).Updates(updates).Error; err != nil {
+	if err := s.saveTransition(&PengajuanEvent{
+		Action:    workflow.ActionCancelPlottingJudul,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
 		return nil, err
 	}
 
@@ -854,26 +1312,23 @@ func (s *PengajuanService) CancelPlottingProposal(idPengajuan int, userID int) (
 		return nil, err
 	}
 
-	// 2. Check if reviewer is assigned
-	if pengajuan.IDReviewerProposal == nil {
-		return nil, errors.New("tidak ada reviewer yang di-assign untuk proposal ini")
+	// 2-4. Reviewer must be assigned and not yet reviewed (ON_REVIEW) -> remove reviewer, back to PENDING
+	before := pengajuan
+	input := workflow.Input{
+		Actor: s.adminActor(userID),
 	}
-
-	// 3. Check if status allows cancel (must be ON_REVIEW, not already reviewed)
-	if pengajuan.StatusProposal != "ON_REVIEW" {
-		return nil, errors.New("plotting hanya dapat dibatalkan untuk proposal dengan status ON_REVIEW")
-	}
-
-	// 4. Update pengajuan - remove reviewer and reset status to PENDING
-	userUpdateStr := fmt.Sprintf("%d", userID)
-
-	updates := map[string]interface{}{
-		"id_reviewer_proposal": nil,
-		"status_proposal":      "PENDING",
-		"user_update":          userUpdateStr,
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionCancelPlottingProposal, input)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Model(&pengajuan).Updates(updates).Error; err != nil {
+	if err := s.saveTransition(&PengajuanEvent{
+		Action:    workflow.ActionCancelPlottingProposal,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
 		return nil, err
 	}
 
@@ -902,26 +1357,24 @@ func (s *PengajuanService) AssignReviewerProposal(idPengajuan int, idReviewer in
 	}
 	idPegawai := reviewer.IDPegawai
 
-	// 3. Check if proposal has been uploaded
-	if pengajuan.FileProposal == "" {
-		return nil, errors.New("proposal belum diupload")
-	}
-
-	// 4. Check if status allows assignment
-	if pengajuan.StatusProposal != "PENDING" && pengajuan.StatusProposal != "ON_REVIEW" {
-		return nil, errors.New("reviewer hanya dapat di-assign untuk proposal dengan status PENDING atau ON_REVIEW")
+	// 3-5. Proposal must be uploaded, status PENDING or ON_REVIEW -> ON_REVIEW with reviewer assigned
+	before := pengajuan
+	input := workflow.Input{
+		Actor:    s.adminActor(userID),
+		Reviewer: idPegawai,
 	}
-
-	// 5. Update pengajuan
-	userUpdateStr := fmt.Sprintf("%d", userID)
-
-	updates := map[string]interface{}{
-		"id_reviewer_proposal": idPegawai,
-		"status_proposal":      "ON_REVIEW",
-		"user_update":          userUpdateStr,
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionAssignReviewerProposal, input)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Model(&pengajuan).Updates(updates).Error; err != nil {
+	if err := s.saveTransition(&PengajuanEvent{
+		Action:    workflow.ActionAssignReviewerProposal,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
 		return nil, err
 	}
 
@@ -953,24 +1406,24 @@ func (s *PengajuanService) AnnounceFinalResult(idPengajuan int, statusFinal stri
 		return nil, err
 	}
 
-	// 2. Validate both judul and proposal are reviewed (ACC)
-	if pengajuan.StatusJudul != "ACC" {
-		return nil, errors.New("judul harus ACC sebelum pengumuman final")
-	}
-
-	if pengajuan.StatusProposal != "ACC" {
-		return nil, errors.New("proposal harus ACC sebelum pengumuman final")
+	// 2-3. Judul and proposal must both be ACC
+	before := pengajuan
+	input := workflow.Input{
+		Actor:  s.adminActor(userID),
+		Target: statusFinal,
 	}
-
-	// 3. Update status final
-	userUpdateStr := fmt.Sprintf("%d", userID)
-
-	updates := map[string]interface{}{
-		"status_final": statusFinal,
-		"user_update":  userUpdateStr,
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionAnnounceFinal, input)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Model(&pengajuan).Updates(updates).Error; err != nil {
+	if err := s.saveTransition(&PengajuanEvent{
+		Action:    workflow.ActionAnnounceFinal,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
 		return nil, err
 	}
 
@@ -983,13 +1436,9 @@ func (s *PengajuanService) AnnounceFinalResult(idPengajuan int, statusFinal stri
 // ========================================
 
 // GetMyAssignments gets all pengajuan assigned to reviewer (pegawai)
-func (s *PengajuanService) GetMyAssignments(userID int, tipeFilter string) ([]response.PengajuanListResponse, error) {
-	// Note: userID here is from db_user
-	// We need to find corresponding pegawai.id from SIMPEG
-
-	// For now, assume userID directly maps to pegawai.id
-	// In production, you might need to join db_user with pegawai table
-	idPegawai := userID
+func (s *PengajuanService) GetMyAssignments(reviewer *ReviewerIdentity, tipeFilter string) ([]response.PengajuanListResponse, error) {
+	// Plotting disimpan dengan id_pegawai, bukan db_reviewer.id
+	idPegawai := reviewer.IDPegawai
 
 	// Build query based on tipe filter
 	var pengajuanList []models.Pengajuan
@@ -1051,7 +1500,7 @@ func (s *PengajuanService) GetMyAssignments(userID int, tipeFilter string) ([]re
 // ========================================
 
 // ReviewJudul submits review for PKM title
-func (s *PengajuanService) ReviewJudul(idPengajuan int, req *request.ReviewJudulRequest, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
+func (s *PengajuanService) ReviewJudul(idPengajuan int, req *request.ReviewJudulRequest, reviewer *ReviewerIdentity, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
 	// 1. Get pengajuan
 	var pengajuan models.Pengajuan
 	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
@@ -1061,25 +1510,29 @@ func (s *PengajuanService) ReviewJudul(idPengajuan int, req *request.ReviewJudul
 		return nil, err
 	}
 
-	// 2. Verify reviewer is assigned OR user is admin
-	idPegawai := userID // Assume userID maps to pegawai.id
-	isAssignedReviewer := pengajuan.IDReviewerJudul != nil && *pengajuan.IDReviewerJudul == idPegawai
-
-	if !isAssignedReviewer && !isAdmin {
-		return nil, errors.New("anda tidak memiliki akses untuk mereview pengajuan ini")
-	}
-
-	// 3. Check if status allows review (must be ON_REVIEW)
-	if pengajuan.StatusJudul != "ON_REVIEW" {
-		return nil, errors.New("pengajuan harus dalam status ON_REVIEW untuk dapat direview")
+	// 2. Verify reviewer is assigned OR user is admin, and status allows review (must be ON_REVIEW)
+	actor := s.reviewActor(reviewer, userID, isAdmin)
+	if err := s.workflow.Check(&pengajuan, workflow.ActionReviewJudul, actor); err != nil {
+		return nil, err
 	}
+	isAssignedReviewer := reviewer != nil && *pengajuan.IDReviewerJudul == reviewer.IDPegawai
 
-	// 4. Get status review info
+	// 3-4. Get status review info; kode_status (ACC, REVISI, or TOLAK) is the target status
 	var statusReview models.StatusReview
 	if err := database.DB.Where("id = ?", req.IDStatusReview).First(&statusReview).Error; err != nil {
 		return nil, errors.New("status review tidak valid")
 	}
 
+	before := pengajuan
+	input := workflow.Input{
+		Actor:  actor,
+		Target: statusReview.KodeStatus,
+	}
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionReviewJudul, input)
+	if err != nil {
+		return nil, err
+	}
+
 	// 5. START TRANSACTION
 	tx := database.DB.Begin()
 	defer func() {
@@ -1088,12 +1541,15 @@ func (s *PengajuanService) ReviewJudul(idPengajuan int, req *request.ReviewJudul
 		}
 	}()
 
-	// 6. Get reviewer's id_reviewer from db_reviewer
-	var reviewer models.Reviewer
+	// 6. Get id_reviewer of the assigned reviewer (admin may review on their behalf)
+	idPegawai := *pengajuan.IDReviewerJudul
 	var idReviewer int
-	if pengajuan.IDReviewerJudul != nil {
-		if err := database.DB.Where("id_pegawai = ? AND hapus = ?", *pengajuan.IDReviewerJudul, 0).First(&reviewer).Error; err == nil {
-			idReviewer = reviewer.ID
+	if isAssignedReviewer {
+		idReviewer = reviewer.IDReviewer
+	} else {
+		var assigned models.Reviewer
+		if err := database.DB.Where("id_pegawai = ? AND hapus = ?", idPegawai, 0).First(&assigned).Error; err == nil {
+			idReviewer = assigned.ID
 		}
 	}
 
@@ -1113,19 +1569,24 @@ func (s *PengajuanService) ReviewJudul(idPengajuan int, req *request.ReviewJudul
 	}
 
 	// 7. Update pengajuan status
-	userUpdateStr := fmt.Sprintf("%d", userID)
-
-	updates := map[string]interface{}{
-		"status_judul":         statusReview.KodeStatus, // ACC, REVISI, or TOLAK
-		"catatan_review_judul": req.Catatan,
-		"tgl_review_judul":     &now,
-		"user_update":          userUpdateStr,
-	}
+	updates["catatan_review_judul"] = req.Catatan
+	updates["tgl_review_judul"] = &now
 
 	if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
+	if err := s.events.Record(tx, &PengajuanEvent{
+		Action:    workflow.ActionReviewJudul,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+		Catatan:   req.Catatan,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// 8. Update plotting status to REVIEWED
 	tx.Model(&models.PlottingReviewer{}).
@@ -1146,7 +1607,7 @@ func (s *PengajuanService) ReviewJudul(idPengajuan int, req *request.ReviewJudul
 // ========================================
 
 // CancelReviewJudul cancels/resets review for PKM title (back to ON_REVIEW status)
-func (s *PengajuanService) CancelReviewJudul(idPengajuan int, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
+func (s *PengajuanService) CancelReviewJudul(idPengajuan int, reviewer *ReviewerIdentity, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
 	// 1. Get pengajuan
 	var pengajuan models.Pengajuan
 	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
@@ -1156,17 +1617,14 @@ func (s *PengajuanService) CancelReviewJudul(idPengajuan int, userID int, isAdmi
 		return nil, err
 	}
 
-	// 2. Verify reviewer is assigned OR user is admin
-	idPegawai := userID
-	isAssignedReviewer := pengajuan.IDReviewerJudul != nil && *pengajuan.IDReviewerJudul == idPegawai
-
-	if !isAssignedReviewer && !isAdmin {
-		return nil, errors.New("anda tidak memiliki akses untuk membatalkan review pengajuan ini")
+	// 2-3. Verify reviewer is assigned OR user is admin, status must be ACC, REVISI, or TOLAK (-> ON_REVIEW)
+	before := pengajuan
+	input := workflow.Input{
+		Actor: s.reviewActor(reviewer, userID, isAdmin),
 	}
-
-	// 3. Check if status allows cancel (must be ACC, REVISI, or TOLAK)
-	if pengajuan.StatusJudul != "ACC" && pengajuan.StatusJudul != "REVISI" && pengajuan.StatusJudul != "TOLAK" {
-		return nil, errors.New("hanya pengajuan yang sudah direview yang dapat dibatalkan")
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionCancelReviewJudul, input)
+	if err != nil {
+		return nil, err
 	}
 
 	// 4. START TRANSACTION
@@ -1180,15 +1638,20 @@ func (s *PengajuanService) CancelReviewJudul(idPengajuan int, userID int, isAdmi
 	// 5. Update status back to ON_REVIEW
 	userUpdateStr := fmt.Sprintf("%d", userID)
 
-	updates := map[string]interface{}{
-		"status_judul": "ON_REVIEW",
-		"user_update":  userUpdateStr,
-	}
-
 	if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
+	if err := s.events.Record(tx, &PengajuanEvent{
+		Action:    workflow.ActionCancelReviewJudul,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// 6. Soft-delete review record in db_review_judul
 	if err := tx.Model(&models.ReviewJudul{}).
@@ -1211,7 +1674,7 @@ func (s *PengajuanService) CancelReviewJudul(idPengajuan int, userID int, isAdmi
 }
 
 // CancelReviewProposal cancels/resets review for PKM proposal (back to ON_REVIEW status)
-func (s *PengajuanService) CancelReviewProposal(idPengajuan int, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
+func (s *PengajuanService) CancelReviewProposal(idPengajuan int, reviewer *ReviewerIdentity, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
 	// 1. Get pengajuan
 	var pengajuan models.Pengajuan
 	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
@@ -1221,17 +1684,14 @@ func (s *PengajuanService) CancelReviewProposal(idPengajuan int, userID int, isA
 		return nil, err
 	}
 
-	// 2. Verify reviewer is assigned OR user is admin
-	idPegawai := userID
-	isAssignedReviewer := pengajuan.IDReviewerProposal != nil && *pengajuan.IDReviewerProposal == idPegawai
-
-	if !isAssignedReviewer && !isAdmin {
-		return nil, errors.New("anda tidak memiliki akses untuk membatalkan review proposal ini")
+	// 2-3. Verify reviewer is assigned OR user is admin, status must be ACC, REVISI, or TOLAK (-> ON_REVIEW)
+	before := pengajuan
+	input := workflow.Input{
+		Actor: s.reviewActor(reviewer, userID, isAdmin),
 	}
-
-	// 3. Check if status allows cancel (must be ACC, REVISI, or TOLAK)
-	if pengajuan.StatusProposal != "ACC" && pengajuan.StatusProposal != "REVISI" && pengajuan.StatusProposal != "TOLAK" {
-		return nil, errors.New("hanya proposal yang sudah direview yang dapat dibatalkan")
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionCancelReviewProposal, input)
+	if err != nil {
+		return nil, err
 	}
 
 	// 4. START TRANSACTION
@@ -1245,15 +1705,20 @@ func (s *PengajuanService) CancelReviewProposal(idPengajuan int, userID int, isA
 	// 5. Update status back to ON_REVIEW
 	userUpdateStr := fmt.Sprintf("%d", userID)
 
-	updates := map[string]interface{}{
-		"status_proposal": "ON_REVIEW",
-		"user_update":     userUpdateStr,
-	}
-
 	if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
+	if err := s.events.Record(tx, &PengajuanEvent{
+		Action:    workflow.ActionCancelReviewProposal,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// 6. Soft-delete review record in db_review_proposal
 	if err := tx.Model(&models.ReviewProposal{}).
@@ -1280,7 +1745,7 @@ func (s *PengajuanService) CancelReviewProposal(idPengajuan int, userID int, isA
 // ========================================
 
 // ReviewProposal submits review for PKM proposal
-func (s *PengajuanService) ReviewProposal(idPengajuan int, req *request.ReviewProposalRequest, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
+func (s *PengajuanService) ReviewProposal(idPengajuan int, req *request.ReviewProposalRequest, reviewer *ReviewerIdentity, userID int, isAdmin bool) (*response.PengajuanResponse, error) {
 	// 1. Get pengajuan
 	var pengajuan models.Pengajuan
 	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
@@ -1290,25 +1755,29 @@ func (s *PengajuanService) ReviewProposal(idPengajuan int, req *request.ReviewPr
 		return nil, err
 	}
 
-	// 2. Verify reviewer is assigned OR user is admin
-	idPegawai := userID // Assume userID maps to pegawai.id
-	isAssignedReviewer := pengajuan.IDReviewerProposal != nil && *pengajuan.IDReviewerProposal == idPegawai
-
-	if !isAssignedReviewer && !isAdmin {
-		return nil, errors.New("anda tidak memiliki akses untuk mereview pengajuan ini")
-	}
-
-	// 3. Check if status allows review (must be ON_REVIEW)
-	if pengajuan.StatusProposal != "ON_REVIEW" {
-		return nil, errors.New("proposal harus dalam status ON_REVIEW untuk dapat direview")
+	// 2. Verify reviewer is assigned OR user is admin, and status allows review (must be ON_REVIEW)
+	actor := s.reviewActor(reviewer, userID, isAdmin)
+	if err := s.workflow.Check(&pengajuan, workflow.ActionReviewProposal, actor); err != nil {
+		return nil, err
 	}
+	isAssignedReviewer := reviewer != nil && *pengajuan.IDReviewerProposal == reviewer.IDPegawai
 
-	// 4. Get status review info
+	// 3-4. Get status review info; kode_status (ACC, REVISI, or TOLAK) is the target status
 	var statusReview models.StatusReview
 	if err := database.DB.Where("id = ?", req.IDStatusReview).First(&statusReview).Error; err != nil {
 		return nil, errors.New("status review tidak valid")
 	}
 
+	before := pengajuan
+	input := workflow.Input{
+		Actor:  actor,
+		Target: statusReview.KodeStatus,
+	}
+	updates, err := s.workflow.Apply(&pengajuan, workflow.ActionReviewProposal, input)
+	if err != nil {
+		return nil, err
+	}
+
 	// 5. START TRANSACTION
 	tx := database.DB.Begin()
 	defer func() {
@@ -1317,12 +1786,15 @@ func (s *PengajuanService) ReviewProposal(idPengajuan int, req *request.ReviewPr
 		}
 	}()
 
-	// 6. Get reviewer's id_reviewer from db_reviewer
-	var reviewer models.Reviewer
+	// 6. Get id_reviewer of the assigned reviewer (admin may review on their behalf)
+	idPegawai := *pengajuan.IDReviewerProposal
 	var idReviewer int
-	if pengajuan.IDReviewerProposal != nil {
-		if err := database.DB.Where("id_pegawai = ? AND hapus = ?", *pengajuan.IDReviewerProposal, 0).First(&reviewer).Error; err == nil {
-			idReviewer = reviewer.ID
+	if isAssignedReviewer {
+		idReviewer = reviewer.IDReviewer
+	} else {
+		var assigned models.Reviewer
+		if err := database.DB.Where("id_pegawai = ? AND hapus = ?", idPegawai, 0).First(&assigned).Error; err == nil {
+			idReviewer = assigned.ID
 		}
 	}
 
@@ -1342,19 +1814,24 @@ func (s *PengajuanService) ReviewProposal(idPengajuan int, req *request.ReviewPr
 	}
 
 	// 7. Update pengajuan status
-	userUpdateStr := fmt.Sprintf("%d", userID)
-
-	updates := map[string]interface{}{
-		"status_proposal":         statusReview.KodeStatus, // ACC, REVISI, or TOLAK
-		"catatan_review_proposal": req.Catatan,
-		"tgl_review_proposal":     &now,
-		"user_update":             userUpdateStr,
-	}
+	updates["catatan_review_proposal"] = req.Catatan
+	updates["tgl_review_proposal"] = &now
 
 	if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
+	if err := s.events.Record(tx, &PengajuanEvent{
+		Action:    workflow.ActionReviewProposal,
+		Actor:     input.Actor,
+		Pengajuan: &pengajuan,
+		Before:    &before,
+		Updates:   updates,
+		Catatan:   req.Catatan,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// 8. Update plotting status to REVIEWED
 	tx.Model(&models.PlottingReviewer{}).
@@ -1381,11 +1858,12 @@ func (s *PengajuanService) GetAnnouncements(filters map[string]interface{}) ([]r
 	perPage := filters["per_page"].(int)
 	idKategori := filters["id_kategori"].(int)
 	tahun := filters["tahun"].(int)
+	idPeriode, _ := filters["id_periode"].(int)
 	statusProposal := filters["status_proposal"].(string)
 
 	// 2. Build query
 	query := database.DB.Where("hapus = ?", 0).
-		Where("status_proposal IN ?", []string{"ACC", "TOLAK"})
+		Where("status_proposal IN ?", []string{workflow.StatusACC, workflow.StatusTolak})
 
 	// Apply filters
 	if idKategori > 0 {
@@ -1394,6 +1872,9 @@ func (s *PengajuanService) GetAnnouncements(filters map[string]interface{}) ([]r
 	if tahun > 0 {
 		query = query.Where("tahun = ?", tahun)
 	}
+	if idPeriode > 0 {
+		query = s.periode.FilterPengajuan(query, idPeriode)
+	}
 	if statusProposal != "" {
 		query = query.Where("status_proposal = ?", statusProposal)
 	}
@@ -1478,3 +1959,85 @@ func (s *PengajuanService) convertToAnggotaModels(anggota []request.AnggotaReque
 	}
 	return result
 }
+
+// adminActor membuat aktor workflow untuk aksi admin
+func (s *PengajuanService) adminActor(userID int) workflow.Actor {
+	return workflow.Actor{IsAdmin: true, UserUpdate: fmt.Sprintf("%d", userID)}
+}
+
+// reviewActor membuat aktor workflow untuk reviewer (atau admin yang mereview atas nama reviewer)
+func (s *PengajuanService) reviewActor(reviewer *ReviewerIdentity, userID int, isAdmin bool) workflow.Actor {
+	actor := workflow.Actor{IsAdmin: isAdmin, UserUpdate: fmt.Sprintf("%d", userID)}
+	if reviewer != nil {
+		actor.IDPegawai = reviewer.IDPegawai
+	}
+	return actor
+}
+
+// validateSubmission menjalankan validasi penuh sebelum pengajuan masuk antrian (PENDING)
+func (s *PengajuanService) validateSubmission(anggota []models.PengajuanAnggota, isAdmin bool) error {
+	// 1. Check if registration period is open (skip for admin)
+	if !isAdmin {
+		if err := s.validator.CanSubmitPengajuan(); err != nil {
+			return err
+		}
+	}
+
+	// 2. Validate team size, structure (1 ketua) and no duplicate NIM
+	if err := s.validator.ValidateTeamSize(anggota); err != nil {
+		return err
+	}
+	if err := s.validator.ValidateTeamStructure(anggota); err != nil {
+		return err
+	}
+	if err := s.validator.ValidateNoDuplicateNIM(anggota); err != nil {
+		return err
+	}
+
+	// 3. Validate all NIMs exist in NEOMAA (skip for admin - for testing purposes)
+	if isAdmin {
+		return nil
+	}
+
+	nims := make([]string, len(anggota))
+	for i, a := range anggota {
+		nims[i] = a.NIMAnggota
+	}
+
+	mahasiswaList, err := s.externalService.GetMahasiswaByNIMs(nims)
+	if err != nil {
+		return fmt.Errorf("failed to fetch mahasiswa data: %w", err)
+	}
+	if len(mahasiswaList) != len(nims) {
+		return errors.New("beberapa NIM tidak ditemukan di database mahasiswa")
+	}
+
+	return nil
+}
+
+// anggotaNIMs mengambil daftar NIM anggota (urutan sesuai request) untuk timeline
+func anggotaNIMs(anggota []request.AnggotaRequest) []string {
+	nims := make([]string, 0, len(anggota))
+	for _, a := range anggota {
+		nims = append(nims, a.NIM)
+	}
+	return nims
+}
+
+// peranOf menentukan peran mahasiswa pada pengajuan (ketua/anggota)
+func peranOf(p *models.Pengajuan, nim string) string {
+	if p.NIMKetua == nim {
+		return PeranKetua
+	}
+	return PeranAnggota
+}
+
+// saveTransition menyimpan hasil transisi workflow dan mencatat event-nya dalam satu transaksi
+func (s *PengajuanService) saveTransition(event *PengajuanEvent) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(event.Pengajuan).Updates(event.Updates).Error; err != nil {
+			return err
+		}
+		return s.events.Record(tx, event)
+	})
+}
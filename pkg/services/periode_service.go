@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// ErrPeriodeNotFound dikembalikan jika tidak ada periode pendaftaran (tgl_setting) yang aktif
+var ErrPeriodeNotFound = errors.New("periode pendaftaran aktif tidak ditemukan")
+
+// PeriodeService menautkan pengajuan ke periode pendaftaran (db_tgl_setting)
+type PeriodeService struct{}
+
+// NewPeriodeService creates a new periode service
+func NewPeriodeService() *PeriodeService {
+	return &PeriodeService{}
+}
+
+// Active mengembalikan periode pendaftaran yang sedang berlaku
+func (s *PeriodeService) Active() (*models.TglSetting, error) {
+	var setting models.TglSetting
+	if err := database.DB.Where("is_active = ? AND status = ? AND hapus = ?", 1, 1, 0).
+		First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPeriodeNotFound
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// Assign mencatat periode pengajuan. Dipanggil di dalam transaksi pembuatan pengajuan.
+func (s *PeriodeService) Assign(tx *gorm.DB, idPengajuan int, idPeriode int, userUpdate string) error {
+	now := time.Now()
+	return tx.Create(&models.PengajuanPeriode{
+		IDPengajuan: idPengajuan,
+		IDPeriode:   idPeriode,
+		TglInsert:   &now,
+		UserUpdate:  userUpdate,
+	}).Error
+}
+
+// Get mengembalikan id_periode pengajuan, 0 jika belum tertaut
+func (s *PeriodeService) Get(idPengajuan int) (int, error) {
+	var row models.PengajuanPeriode
+	if err := database.DB.Where("id_pengajuan = ?", idPengajuan).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return row.IDPeriode, nil
+}
+
+// FilterPengajuan membatasi query db_pengajuan_pkm ke pengajuan milik satu periode
+func (s *PeriodeService) FilterPengajuan(query *gorm.DB, idPeriode int) *gorm.DB {
+	return query.Where("id IN (?)", database.DB.Model(&models.PengajuanPeriode{}).
+		Select("id_pengajuan").
+		Where("id_periode = ?", idPeriode))
+}
+
+// Backfill menautkan pengajuan lama (dibuat sebelum ada db_pengajuan_periode) ke periodenya.
+// Periode dicari dari tgl_pengajuan (atau tgl_insert) yang jatuh di rentang pendaftaran;
+// jika tidak ada, dipakai periode terakhir di tahun pengajuan yang dimulai sebelum tanggal itu.
+// Aman dijalankan berulang: hanya pengajuan yang belum tertaut yang diproses.
+func (s *PeriodeService) Backfill() (int, error) {
+	var periodeList []models.TglSetting
+	if err := database.DB.Where("hapus = ?", 0).
+		Order("tgl_daftar_awal ASC, id ASC").
+		Find(&periodeList).Error; err != nil {
+		return 0, err
+	}
+	if len(periodeList) == 0 {
+		return 0, nil
+	}
+
+	var pengajuanList []models.Pengajuan
+	if err := database.DB.Select("id, tahun, tgl_pengajuan, tgl_insert").
+		Where("id NOT IN (?)", database.DB.Model(&models.PengajuanPeriode{}).Select("id_pengajuan")).
+		Find(&pengajuanList).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	rows := make([]models.PengajuanPeriode, 0, len(pengajuanList))
+	for _, p := range pengajuanList {
+		tanggal := p.TglPengajuan
+		if tanggal == nil {
+			tanggal = p.TglInsert
+		}
+		periode := matchPeriode(periodeList, p.Tahun, tanggal)
+		if periode == nil {
+			continue
+		}
+		rows = append(rows, models.PengajuanPeriode{
+			IDPengajuan: p.ID,
+			IDPeriode:   periode.ID,
+			TglInsert:   &now,
+			UserUpdate:  "backfill",
+		})
+	}
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if err := database.DB.CreateInBatches(&rows, 500).Error; err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// matchPeriode memilih periode untuk pengajuan lama. periodeList terurut dari yang paling awal.
+func matchPeriode(periodeList []models.TglSetting, tahun int, tanggal *time.Time) *models.TglSetting {
+	if tanggal != nil {
+		for i := range periodeList {
+			awal := periodeList[i].TglDaftarAwal
+			akhir := periodeList[i].TglDaftarAkhir.AddDate(0, 0, 1) // tgl_daftar_akhir inklusif
+			if !tanggal.Before(awal) && tanggal.Before(akhir) {
+				return &periodeList[i]
+			}
+		}
+	}
+
+	var match *models.TglSetting
+	for i := range periodeList {
+		if periodeList[i].Tahun() != tahun {
+			continue
+		}
+		if match == nil || tanggal == nil || !periodeList[i].TglDaftarAwal.After(*tanggal) {
+			match = &periodeList[i]
+		}
+		if tanggal == nil {
+			break
+		}
+	}
+	return match
+}
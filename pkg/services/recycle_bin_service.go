@@ -0,0 +1,365 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+	"rires-be/pkg/workflow"
+
+	"gorm.io/gorm"
+)
+
+// Jenis data yang bisa dipulihkan dari recycle bin
+const (
+	RecycleTypePengajuan = "pengajuan"
+	RecycleTypeReviewer  = "reviewer"
+	RecycleTypeKategori  = "kategori"
+	RecycleTypeMenu      = "menu"
+)
+
+// Aksi timeline untuk pemulihan pengajuan
+const EventRestorePengajuan workflow.Action = "RESTORE"
+
+// ErrUnknownRecycleType dikembalikan jika jenis data tidak didukung recycle bin
+var ErrUnknownRecycleType = errors.New("jenis data tidak dikenal (pengajuan, reviewer, kategori, menu)")
+
+// ErrRecycleItemNotFound dikembalikan jika data tidak ada di recycle bin
+var ErrRecycleItemNotFound = errors.New("data tidak ditemukan di recycle bin")
+
+// RecycleBinService mengelola data yang di-soft delete (hapus = 1)
+type RecycleBinService struct {
+	fileService *FileUploadService
+	invitations *InvitationService
+	events      *PengajuanEventService
+	periode     *PeriodeService
+	validator   *utils.StatusValidator
+}
+
+// NewRecycleBinService creates a new recycle bin service
+func NewRecycleBinService() *RecycleBinService {
+	return &RecycleBinService{
+		fileService: NewFileUploadService(),
+		invitations: NewInvitationService(),
+		events:      NewPengajuanEventService(),
+		periode:     NewPeriodeService(),
+		validator:   utils.NewStatusValidator(),
+	}
+}
+
+// List returns soft-deleted data, newest deletion first. tipe kosong = semua jenis.
+func (s *RecycleBinService) List(tipe string) ([]response.RecycleBinItemResponse, error) {
+	types := []string{RecycleTypePengajuan, RecycleTypeReviewer, RecycleTypeKategori, RecycleTypeMenu}
+	if tipe != "" {
+		if !isRecycleType(tipe) {
+			return nil, ErrUnknownRecycleType
+		}
+		types = []string{tipe}
+	}
+
+	result := make([]response.RecycleBinItemResponse, 0)
+	for _, t := range types {
+		items, err := s.listType(t)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TglHapus.After(result[j].TglHapus)
+	})
+	return result, nil
+}
+
+// Restore mengembalikan data (hapus = 0)
+func (s *RecycleBinService) Restore(tipe string, id int, userUpdate string) error {
+	if !isRecycleType(tipe) {
+		return ErrUnknownRecycleType
+	}
+
+	if tipe == RecycleTypePengajuan {
+		return s.restorePengajuan(id, userUpdate)
+	}
+
+	result := database.DB.Table(recycleTable(tipe)).
+		Where("id = ? AND hapus = ?", id, 1).
+		Updates(map[string]interface{}{"hapus": 0, "user_update": userUpdate})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecycleItemNotFound
+	}
+	return nil
+}
+
+// Purge menghapus data secara permanen. Hanya data yang sudah ada di recycle bin.
+func (s *RecycleBinService) Purge(tipe string, id int) error {
+	switch tipe {
+	case RecycleTypePengajuan:
+		return s.purgePengajuan(id)
+	case RecycleTypeReviewer:
+		return s.purgeSimple(&models.Reviewer{}, id)
+	case RecycleTypeKategori:
+		return s.purgeKategori(id)
+	case RecycleTypeMenu:
+		return s.purgeMenu(id)
+	}
+	return ErrUnknownRecycleType
+}
+
+// listType membaca data terhapus untuk satu jenis
+func (s *RecycleBinService) listType(tipe string) ([]response.RecycleBinItemResponse, error) {
+	var items []response.RecycleBinItemResponse
+
+	switch tipe {
+	case RecycleTypePengajuan:
+		var rows []models.Pengajuan
+		if err := database.DB.Where("hapus = ?", 1).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			items = append(items, response.RecycleBinItemResponse{
+				Tipe:       tipe,
+				ID:         row.ID,
+				Nama:       row.Judul,
+				Keterangan: fmt.Sprintf("%s - ketua %s", row.KodePengajuan, row.NIMKetua),
+				TglHapus:   row.TglUpdate,
+				UserUpdate: row.UserUpdate,
+			})
+		}
+	case RecycleTypeReviewer:
+		var rows []models.Reviewer
+		if err := database.DB.Where("hapus = ?", 1).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			items = append(items, response.RecycleBinItemResponse{
+				Tipe:       tipe,
+				ID:         row.ID,
+				Nama:       row.NamaReviewer,
+				Keterangan: fmt.Sprintf("id_pegawai %d", row.IDPegawai),
+				TglHapus:   row.TglUpdate,
+				UserUpdate: row.UserUpdate,
+			})
+		}
+	case RecycleTypeKategori:
+		var rows []models.KategoriPKM
+		if err := database.DB.Where("hapus = ?", 1).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			items = append(items, response.RecycleBinItemResponse{
+				Tipe:       tipe,
+				ID:         row.ID,
+				Nama:       row.NamaKategori,
+				TglHapus:   row.TglUpdate,
+				UserUpdate: row.UserUpdate,
+			})
+		}
+	case RecycleTypeMenu:
+		var rows []models.Menu
+		if err := database.DB.Where("hapus = ?", 1).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			items = append(items, response.RecycleBinItemResponse{
+				Tipe:       tipe,
+				ID:         row.ID,
+				Nama:       row.NamaMenu,
+				Keterangan: row.URLMenu,
+				TglHapus:   row.TglUpdate,
+				UserUpdate: row.UserUpdate,
+			})
+		}
+	}
+
+	return items, nil
+}
+
+// restorePengajuan memulihkan pengajuan, mencatat timeline dan mengundang ulang anggota
+// yang undangannya dibatalkan saat pengajuan ditarik.
+func (s *RecycleBinService) restorePengajuan(id int, userUpdate string) error {
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", id, 1).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecycleItemNotFound
+		}
+		return err
+	}
+
+	idPeriode, err := s.periode.Get(pengajuan.ID)
+	if err != nil {
+		return err
+	}
+
+	before := pengajuan
+	updates := map[string]interface{}{"hapus": 0, "user_update": userUpdate}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// Selama di recycle bin ketua/anggota bisa sudah mengisi kuota di pengajuan lain
+		// pada periode yang sama; tolak pemulihan jika melewati batas
+		var anggota []models.PengajuanAnggota
+		if err := tx.Where("id_pengajuan = ? AND hapus = ?", pengajuan.ID, 0).Find(&anggota).Error; err != nil {
+			return err
+		}
+		if err := s.validator.ValidateParticipationLimitsTx(tx, anggota, idPeriode, pengajuan.ID); err != nil {
+			return fmt.Errorf("pengajuan tidak dapat dipulihkan: %w", err)
+		}
+
+		if err := tx.Model(&pengajuan).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if err := s.invitations.Sync(tx, &pengajuan, anggota, userUpdate); err != nil {
+			return err
+		}
+
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    EventRestorePengajuan,
+			Actor:     workflow.Actor{IsAdmin: true, UserUpdate: userUpdate},
+			Pengajuan: &pengajuan,
+			Before:    &before,
+			Updates:   updates,
+		})
+	})
+}
+
+// purgePengajuan menghapus pengajuan beserta seluruh data turunannya dan file proposal
+func (s *RecycleBinService) purgePengajuan(id int) error {
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", id, 1).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecycleItemNotFound
+		}
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		children := []interface{}{
+			&models.PengajuanAnggota{},
+			&models.ParameterPKM{},
+			&models.ReviewJudul{},
+			&models.ReviewProposal{},
+			&models.PlottingReviewer{},
+			&models.UndanganAnggota{},
+			&models.TransferKetua{},
+			&models.PembimbingPengajuan{},
+			&models.KemiripanJudul{},
+			&models.PengajuanPeriode{},
+			&models.PengajuanEvent{},
+		}
+		for _, child := range children {
+			if err := tx.Where("id_pengajuan = ?", pengajuan.ID).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+		// Judul ini juga tidak lagi menjadi pembanding pengajuan lain
+		if err := tx.Where("id_pengajuan_mirip = ?", pengajuan.ID).Delete(&models.KemiripanJudul{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&pengajuan).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// File dihapus setelah commit agar tidak hilang jika transaksi gagal
+	if err := s.fileService.DeleteFile(pengajuan.FileProposal); err != nil {
+		log.Printf("Failed to delete proposal file %s: %v", pengajuan.FileProposal, err)
+	}
+	return nil
+}
+
+// purgeKategori menolak penghapusan kategori yang masih dipakai pengajuan atau form parameter
+// dan ikut menghapus kode singkat serta nomor urut kode pengajuannya
+func (s *RecycleBinService) purgeKategori(id int) error {
+	var count int64
+	if err := database.DB.Model(&models.Pengajuan{}).Where("id_kategori = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("kategori masih dipakai oleh %d pengajuan", count)
+	}
+
+	if err := database.DB.Model(&models.ParameterForm{}).Where("id_kategori = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("kategori masih dipakai oleh %d parameter form", count)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND hapus = ?", id, 1).Delete(&models.KategoriPKM{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecycleItemNotFound
+		}
+		// Kode singkat & nomor urut kode pengajuan kategori ini
+		if err := tx.Where("id_kategori = ?", id).Delete(&models.KategoriKode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id_kategori = ?", id).Delete(&models.KodeSequence{}).Error
+	})
+}
+
+// purgeMenu menolak menu yang masih punya sub menu dan ikut menghapus hak aksesnya
+func (s *RecycleBinService) purgeMenu(id int) error {
+	var count int64
+	if err := database.DB.Model(&models.Menu{}).Where("id_parent = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("menu masih memiliki %d sub menu", count)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND hapus = ?", id, 1).Delete(&models.Menu{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecycleItemNotFound
+		}
+		return tx.Where("id_menu = ?", id).Delete(&models.UserAkses{}).Error
+	})
+}
+
+// purgeSimple menghapus permanen satu baris yang sudah di-soft delete
+func (s *RecycleBinService) purgeSimple(model interface{}, id int) error {
+	result := database.DB.Where("id = ? AND hapus = ?", id, 1).Delete(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecycleItemNotFound
+	}
+	return nil
+}
+
+// isRecycleType memeriksa jenis data recycle bin
+func isRecycleType(tipe string) bool {
+	return recycleTable(tipe) != ""
+}
+
+// recycleTable mengembalikan nama tabel untuk jenis data
+func recycleTable(tipe string) string {
+	switch tipe {
+	case RecycleTypePengajuan:
+		return models.Pengajuan{}.TableName()
+	case RecycleTypeReviewer:
+		return models.Reviewer{}.TableName()
+	case RecycleTypeKategori:
+		return models.KategoriPKM{}.TableName()
+	case RecycleTypeMenu:
+		return models.Menu{}.TableName()
+	}
+	return ""
+}
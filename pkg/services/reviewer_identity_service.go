@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotReviewer dikembalikan jika pegawai tidak terdaftar sebagai reviewer aktif
+var ErrNotReviewer = errors.New("anda bukan reviewer aktif. Pastikan email Anda sudah terdaftar sebagai reviewer")
+
+// ReviewerIdentity adalah reviewer yang sedang login, ter-resolve ke baris db_reviewer.
+// IDPegawai adalah kunci yang dipakai di id_reviewer_judul / id_reviewer_proposal / plotting.
+type ReviewerIdentity struct {
+	IDReviewer int // db_reviewer.id (subject token)
+	IDPegawai  int // pegawai.id di SIMPEG
+	Nama       string
+	Email      string
+}
+
+// ReviewerIdentityService memetakan hasil login / token claims ke db_reviewer
+type ReviewerIdentityService struct {
+	externalService *ExternalDataService
+}
+
+// NewReviewerIdentityService creates a new reviewer identity service
+func NewReviewerIdentityService() *ReviewerIdentityService {
+	return &ReviewerIdentityService{
+		externalService: NewExternalDataService(),
+	}
+}
+
+// ResolveFromLogin mencari reviewer untuk pegawai yang baru login.
+// Email SSO di-resolve ke id_pegawai lewat SIMPEG, lalu dicocokkan ke db_reviewer.id_pegawai.
+func (s *ReviewerIdentityService) ResolveFromLogin(identity *AuthIdentity, loginUsername string) (*ReviewerIdentity, error) {
+	email := strings.TrimSpace(identity.Email)
+	if email == "" && strings.Contains(loginUsername, "@") {
+		email = strings.TrimSpace(loginUsername)
+	}
+	if email == "" {
+		return nil, ErrNotReviewer
+	}
+
+	// 1. Email -> id_pegawai (SIMPEG)
+	pegawai, err := s.externalService.GetPegawaiByEmail(email)
+	if err == nil {
+		return s.ResolveByPegawai(pegawai.ID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[ReviewerIdentity] SIMPEG lookup failed for %s: %v", email, err)
+	}
+
+	// 2. SIMPEG tidak tersedia / email belum sinkron: pakai email yang disalin saat aktivasi reviewer
+	var reviewer models.Reviewer
+	if err := database.DB.
+		Where("LOWER(email_umm) = ? AND is_active = ? AND status = ? AND hapus = ?", strings.ToLower(email), 1, 1, 0).
+		First(&reviewer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotReviewer
+		}
+		return nil, err
+	}
+
+	return s.mapIdentity(&reviewer), nil
+}
+
+// ResolveByPegawai mencari reviewer aktif berdasarkan id_pegawai
+func (s *ReviewerIdentityService) ResolveByPegawai(idPegawai int) (*ReviewerIdentity, error) {
+	var reviewer models.Reviewer
+	if err := database.DB.
+		Where("id_pegawai = ? AND is_active = ? AND status = ? AND hapus = ?", idPegawai, 1, 1, 0).
+		First(&reviewer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotReviewer
+		}
+		return nil, err
+	}
+
+	return s.mapIdentity(&reviewer), nil
+}
+
+// ResolveFromClaims memetakan token reviewer ke db_reviewer.
+// Token pegawai memakai db_reviewer.id sebagai id_user, id_pegawai di user_data harus cocok dengan barisnya.
+func (s *ReviewerIdentityService) ResolveFromClaims(claims *utils.JWTClaims) (*ReviewerIdentity, error) {
+	if claims == nil || claims.UserType != UserTypePegawai || claims.UserID == 0 {
+		return nil, ErrNotReviewer
+	}
+
+	reviewer, err := s.ResolveByID(int(claims.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	if idPegawai, err := strconv.Atoi(claims.UserData["id_pegawai"]); err == nil && idPegawai != reviewer.IDPegawai {
+		return nil, errors.New("token reviewer tidak sesuai dengan data reviewer, silakan login ulang")
+	}
+
+	return reviewer, nil
+}
+
+// ResolveByID mencari reviewer aktif berdasarkan db_reviewer.id
+func (s *ReviewerIdentityService) ResolveByID(idReviewer int) (*ReviewerIdentity, error) {
+	var reviewer models.Reviewer
+	if err := database.DB.
+		Where("id = ? AND is_active = ? AND status = ? AND hapus = ?", idReviewer, 1, 1, 0).
+		First(&reviewer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotReviewer
+		}
+		return nil, err
+	}
+
+	return s.mapIdentity(&reviewer), nil
+}
+
+// mapIdentity memetakan model reviewer ke identity
+func (s *ReviewerIdentityService) mapIdentity(reviewer *models.Reviewer) *ReviewerIdentity {
+	return &ReviewerIdentity{
+		IDReviewer: reviewer.ID,
+		IDPegawai:  reviewer.IDPegawai,
+		Nama:       reviewer.NamaReviewer,
+		Email:      reviewer.EmailUmm,
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"rires-be/internal/dto/response"
 	"rires-be/internal/models"
 	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
 
 	"gorm.io/gorm"
 )
@@ -222,6 +223,22 @@ func (s *ReviewerService) DeleteReviewer(id int, userID int) error {
 	return database.DB.Model(&reviewer).Updates(updates).Error
 }
 
+// RevokeTokens revokes every access and refresh token issued to the reviewer
+func (s *ReviewerService) RevokeTokens(id int, userID int) error {
+	// 1. Get reviewer (soft-deleted reviewer tetap bisa dicabut tokennya)
+	var reviewer models.Reviewer
+	if err := database.DB.Where("id = ?", id).First(&reviewer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("reviewer tidak ditemukan")
+		}
+		return err
+	}
+
+	// 2. Revoke
+	subject := utils.TokenSubject("pegawai", uint(reviewer.ID), "")
+	return NewTokenService().RevokeSubject(subject, "dicabut oleh admin", fmt.Sprintf("%d", userID))
+}
+
 // IsActiveReviewer checks if pegawai is an active reviewer
 func (s *ReviewerService) IsActiveReviewer(idPegawai int) bool {
 	var reviewer models.Reviewer
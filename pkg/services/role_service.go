@@ -0,0 +1,433 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Tipe identitas SSO yang bisa dihubungkan ke akun lokal
+const (
+	RoleLinkPegawai   = "pegawai"
+	RoleLinkMahasiswa = "mahasiswa"
+)
+
+// roleIdentity adalah kumpulan kunci identitas milik satu orang
+type roleIdentity struct {
+	IDUser    int    // db_user.id (akun admin lokal)
+	IDPegawai int    // pegawai.id (reviewer)
+	NIM       string // mahasiswa
+}
+
+// RoleService handles multi-role accounts and active-role switching
+type RoleService struct {
+	externalService  *ExternalDataService
+	reviewerIdentity *ReviewerIdentityService
+}
+
+// NewRoleService creates a new role service
+func NewRoleService() *RoleService {
+	return &RoleService{
+		externalService:  NewExternalDataService(),
+		reviewerIdentity: NewReviewerIdentityService(),
+	}
+}
+
+// AvailableRoles mengembalikan semua role yang bisa dipakai pemilik token
+func (s *RoleService) AvailableRoles(claims *utils.JWTClaims) ([]response.RoleOption, error) {
+	identity, err := s.resolveIdentity(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	activeRole := claims.ActiveRole
+	if activeRole == "" {
+		activeRole = claims.UserType
+	}
+
+	roles := make([]response.RoleOption, 0, 3)
+
+	// Admin (akun lokal db_user)
+	if identity.IDUser > 0 {
+		var user models.User
+		if err := database.DB.Where("id = ? AND status = ? AND hapus = ?", identity.IDUser, 1, 0).First(&user).Error; err == nil {
+			roles = append(roles, response.RoleOption{
+				Role:        UserTypeAdmin,
+				IDUserLevel: user.LevelUser,
+				Label:       s.levelLabel(user.LevelUser, "Admin"),
+				IsActive:    activeRole == UserTypeAdmin,
+			})
+		}
+	}
+
+	// Reviewer (atau dosen pembimbing yang bukan reviewer)
+	if identity.IDPegawai > 0 {
+		if _, err := s.reviewerIdentity.ResolveByPegawai(identity.IDPegawai); err == nil {
+			roles = append(roles, response.RoleOption{
+				Role:        UserTypePegawai,
+				IDUserLevel: 4,
+				Label:       s.levelLabel(4, "Reviewer"),
+				IsActive:    activeRole == UserTypePegawai,
+			})
+		} else if active, _ := isActivePembimbing(identity.IDPegawai); active {
+			roles = append(roles, response.RoleOption{
+				Role:        UserTypePegawai,
+				IDUserLevel: 4,
+				Label:       "Dosen Pembimbing",
+				IsActive:    activeRole == UserTypePegawai,
+			})
+		}
+	}
+
+	// Mahasiswa
+	if identity.NIM != "" {
+		roles = append(roles, response.RoleOption{
+			Role:        UserTypeMahasiswa,
+			IDUserLevel: 3,
+			Label:       s.levelLabel(3, "Mahasiswa"),
+			IsActive:    activeRole == UserTypeMahasiswa,
+		})
+	}
+
+	return roles, nil
+}
+
+// SwitchRole menyusun claims baru untuk role yang dipilih.
+// Mengembalikan claims dan data user untuk response login.
+func (s *RoleService) SwitchRole(claims *utils.JWTClaims, role string) (*utils.JWTClaims, interface{}, error) {
+	if claims.Impersonator != nil {
+		return nil, nil, errors.New("tidak dapat berpindah role saat impersonation")
+	}
+
+	roles, err := s.AvailableRoles(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowed := false
+	for _, option := range roles {
+		if option.Role == role {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, nil, fmt.Errorf("role %s tidak tersedia untuk akun ini", role)
+	}
+
+	identity, err := s.resolveIdentity(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch role {
+	case UserTypeAdmin:
+		var user models.User
+		if err := database.DB.Where("id = ? AND status = ? AND hapus = ?", identity.IDUser, 1, 0).First(&user).Error; err != nil {
+			return nil, nil, errors.New("akun admin tidak aktif")
+		}
+		newClaims := utils.NewClaims(uint(user.ID), user.Username, "", UserTypeAdmin, user.LevelUser, map[string]string{
+			"nama_user":  user.NamaUser,
+			"level_user": strconv.Itoa(user.LevelUser),
+		})
+		return newClaims, response.AdminLoginResponse{
+			ID:        user.ID,
+			NamaUser:  user.NamaUser,
+			Username:  user.Username,
+			LevelUser: user.LevelUser,
+			Status:    user.Status,
+		}, nil
+
+	case UserTypePegawai:
+		// NIP, jabatan dan unit hanya diketahui dari login SSO pegawai
+		nip := ""
+		if claims.UserType == UserTypePegawai {
+			nip = claims.Username
+		}
+
+		reviewer, err := s.reviewerIdentity.ResolveByPegawai(identity.IDPegawai)
+		if errors.Is(err, ErrNotReviewer) {
+			return s.pembimbingClaims(identity.IDPegawai, nip, claims.UserData["jabatan"], claims.UserData["unit"])
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		newClaims, user := NewReviewerClaims(reviewer, nip, claims.UserData["jabatan"], claims.UserData["unit"])
+		return newClaims, user, nil
+
+	case UserTypeMahasiswa:
+		return s.mahasiswaClaims(identity.NIM)
+	}
+
+	return nil, nil, fmt.Errorf("role %s tidak dikenal", role)
+}
+
+// GetLinks mengembalikan identitas SSO yang terhubung ke akun lokal
+func (s *RoleService) GetLinks(idUser int) ([]response.UserRoleLinkResponse, error) {
+	var links []models.UserRoleLink
+	if err := database.DB.Where("id_user = ? AND hapus = ?", idUser, 0).Order("id ASC").Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.UserRoleLinkResponse, 0, len(links))
+	for _, link := range links {
+		result = append(result, s.mapLink(&link))
+	}
+	return result, nil
+}
+
+// CreateLink menghubungkan akun lokal dengan id_pegawai atau NIM
+func (s *RoleService) CreateLink(idUser int, req *request.CreateUserRoleLinkRequest, userID int) (*response.UserRoleLinkResponse, error) {
+	// 1. Akun lokal harus ada
+	var user models.User
+	if err := database.DB.Where("id = ? AND hapus = ?", idUser, 0).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user tidak ditemukan")
+		}
+		return nil, err
+	}
+
+	// 2. Validasi identitas di sumber data
+	nilai := strings.TrimSpace(req.Nilai)
+	switch req.Tipe {
+	case RoleLinkPegawai:
+		idPegawai, err := strconv.Atoi(nilai)
+		if err != nil || idPegawai <= 0 {
+			return nil, errors.New("nilai untuk tipe pegawai harus id_pegawai")
+		}
+		if !s.externalService.ValidatePegawaiExists(idPegawai) {
+			return nil, errors.New("pegawai tidak ditemukan di SIMPEG")
+		}
+	case RoleLinkMahasiswa:
+		if !s.externalService.ValidateNIMExists(nilai) {
+			return nil, errors.New("NIM tidak ditemukan")
+		}
+	}
+
+	// 3. Satu akun hanya punya satu identitas per tipe, satu identitas hanya ke satu akun
+	var count int64
+	database.DB.Model(&models.UserRoleLink{}).
+		Where("hapus = ? AND ((id_user = ? AND tipe = ?) OR (tipe = ? AND nilai = ?))", 0, idUser, req.Tipe, req.Tipe, nilai).
+		Count(&count)
+	if count > 0 {
+		return nil, errors.New("identitas sudah terhubung ke akun lain atau akun sudah memiliki identitas dengan tipe ini")
+	}
+
+	// 4. Create
+	now := time.Now()
+	link := &models.UserRoleLink{
+		IDUser:     idUser,
+		Tipe:       req.Tipe,
+		Nilai:      nilai,
+		TglInsert:  &now,
+		UserUpdate: strconv.Itoa(userID),
+	}
+	if err := database.DB.Create(link).Error; err != nil {
+		return nil, err
+	}
+
+	result := s.mapLink(link)
+	return &result, nil
+}
+
+// DeleteLink memutus hubungan identitas SSO dari akun lokal (soft delete)
+func (s *RoleService) DeleteLink(idUser int, idLink int, userID int) error {
+	result := database.DB.Model(&models.UserRoleLink{}).
+		Where("id = ? AND id_user = ? AND hapus = ?", idLink, idUser, 0).
+		Updates(map[string]interface{}{
+			"hapus":       1,
+			"user_update": strconv.Itoa(userID),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("link role tidak ditemukan")
+	}
+	return nil
+}
+
+// resolveIdentity mengumpulkan db_user, id_pegawai dan NIM milik pemilik token
+func (s *RoleService) resolveIdentity(claims *utils.JWTClaims) (*roleIdentity, error) {
+	identity := &roleIdentity{}
+
+	switch claims.UserType {
+	case UserTypeAdmin:
+		identity.IDUser = int(claims.UserID)
+	case UserTypePegawai:
+		identity.IDPegawai, _ = strconv.Atoi(claims.UserData["id_pegawai"])
+		identity.IDUser = s.linkedUser(RoleLinkPegawai, strconv.Itoa(identity.IDPegawai))
+	case UserTypeMahasiswa:
+		identity.NIM = claims.Username
+		identity.IDUser = s.linkedUser(RoleLinkMahasiswa, identity.NIM)
+	default:
+		return nil, errors.New("tipe user tidak dikenal")
+	}
+
+	if identity.IDUser == 0 {
+		return identity, nil
+	}
+
+	// Lengkapi dari link akun lokal
+	var links []models.UserRoleLink
+	if err := database.DB.Where("id_user = ? AND hapus = ?", identity.IDUser, 0).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		switch link.Tipe {
+		case RoleLinkPegawai:
+			if identity.IDPegawai == 0 {
+				identity.IDPegawai, _ = strconv.Atoi(link.Nilai)
+			}
+		case RoleLinkMahasiswa:
+			if identity.NIM == "" {
+				identity.NIM = link.Nilai
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+// linkedUser mencari akun lokal aktif yang terhubung ke identitas SSO
+func (s *RoleService) linkedUser(tipe, nilai string) int {
+	if nilai == "" || nilai == "0" {
+		return 0
+	}
+
+	var link models.UserRoleLink
+	if err := database.DB.
+		Joins("JOIN db_user ON db_user.id = db_user_role_link.id_user AND db_user.hapus = 0").
+		Where("db_user_role_link.tipe = ? AND db_user_role_link.nilai = ? AND db_user_role_link.hapus = ?", tipe, nilai, 0).
+		First(&link).Error; err != nil {
+		return 0
+	}
+	return link.IDUser
+}
+
+// mahasiswaClaims menyusun claims mahasiswa dari data NEOMAA (sama dengan hasil login SSO)
+func (s *RoleService) mahasiswaClaims(nim string) (*utils.JWTClaims, interface{}, error) {
+	mahasiswa, err := s.externalService.GetMahasiswaByNIM(nim)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mahasiswa dengan NIM %s tidak ditemukan", nim)
+	}
+
+	var namaProdi, namaFakultas string
+	if prodi, err := s.externalService.GetProdiByID(mahasiswa.RefProgramStudi); err == nil {
+		namaProdi = prodi.GetNamaProdi()
+		if fakultas, err := s.externalService.GetFakultasByID(prodi.KodeFakultas); err == nil {
+			namaFakultas = fakultas.NamaFakultas
+		}
+	}
+
+	// id_user mengikuti login mahasiswa (akun lokal opsional)
+	var userID uint
+	if idUser := s.linkedUser(RoleLinkMahasiswa, nim); idUser > 0 {
+		userID = uint(idUser)
+	}
+
+	claims := utils.NewClaims(userID, nim, "", UserTypeMahasiswa, 3, map[string]string{
+		"nama":     mahasiswa.NamaSiswa,
+		"prodi":    namaProdi,
+		"fakultas": namaFakultas,
+	})
+	return claims, &response.MahasiswaLoginResponse{
+		NIM:      nim,
+		Nama:     mahasiswa.NamaSiswa,
+		Prodi:    namaProdi,
+		Fakultas: namaFakultas,
+	}, nil
+}
+
+// pembimbingClaims menyusun claims pegawai yang hanya dosen pembimbing
+func (s *RoleService) pembimbingClaims(idPegawai int, nip, jabatan, unit string) (*utils.JWTClaims, interface{}, error) {
+	active, err := isActivePembimbing(idPegawai)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !active {
+		return nil, nil, ErrNotReviewerOrPembimbing
+	}
+
+	pegawai, err := s.externalService.GetPegawaiByID(idPegawai)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newClaims, user := NewPembimbingClaims(pegawai, nip, jabatan, unit)
+	return newClaims, user, nil
+}
+
+// NewReviewerClaims menyusun claims reviewer beserta data user untuk response login
+func NewReviewerClaims(reviewer *ReviewerIdentity, nip, jabatan, unit string) (*utils.JWTClaims, interface{}) {
+	claims := utils.NewClaims(
+		uint(reviewer.IDReviewer),
+		nip,
+		reviewer.Email,
+		UserTypePegawai,
+		4, // Reviewer level
+		map[string]string{
+			"nama":        reviewer.Nama,
+			"jabatan":     jabatan,
+			"unit":        unit,
+			"id_pegawai":  strconv.Itoa(reviewer.IDPegawai),
+			"id_reviewer": strconv.Itoa(reviewer.IDReviewer),
+		},
+	)
+
+	return claims, map[string]interface{}{
+		"id_reviewer": reviewer.IDReviewer,
+		"id_pegawai":  reviewer.IDPegawai,
+		"nip":         nip,
+		"nama":        reviewer.Nama,
+		"email":       reviewer.Email,
+		"jabatan":     jabatan,
+		"unit":        unit,
+	}
+}
+
+// levelLabel mengambil nama level dari db_user_level
+func (s *RoleService) levelLabel(idLevel int, fallback string) string {
+	var level models.UserLevel
+	if err := database.DB.Where("id = ? AND hapus = ?", idLevel, 0).First(&level).Error; err == nil && level.NamaLevel != "" {
+		return level.NamaLevel
+	}
+	return fallback
+}
+
+// mapLink memetakan model link ke response
+func (s *RoleService) mapLink(link *models.UserRoleLink) response.UserRoleLinkResponse {
+	resp := response.UserRoleLinkResponse{
+		ID:         link.ID,
+		IDUser:     link.IDUser,
+		Tipe:       link.Tipe,
+		Nilai:      link.Nilai,
+		TglInsert:  link.TglInsert,
+		UserUpdate: link.UserUpdate,
+	}
+
+	switch link.Tipe {
+	case RoleLinkPegawai:
+		if idPegawai, err := strconv.Atoi(link.Nilai); err == nil {
+			if pegawai, err := s.externalService.GetPegawaiByID(idPegawai); err == nil {
+				resp.Nama = pegawai.GetNamaLengkap()
+			}
+		}
+	case RoleLinkMahasiswa:
+		if mahasiswa, err := s.externalService.GetMahasiswaByNIM(link.Nilai); err == nil {
+			resp.Nama = mahasiswa.NamaSiswa
+		}
+	}
+	return resp
+}
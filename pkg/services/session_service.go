@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval membatasi update last_seen_at agar tidak menulis di setiap request
+const sessionTouchInterval = time.Minute
+
+// ErrSessionNotFound dikembalikan jika sesi tidak ada atau bukan milik user
+var ErrSessionNotFound = errors.New("sesi tidak ditemukan")
+
+// SessionService handles daftar dan pemutusan sesi login per perangkat
+type SessionService struct {
+	tokenService *TokenService
+}
+
+// NewSessionService creates a new session service
+func NewSessionService() *SessionService {
+	return &SessionService{
+		tokenService: NewTokenService(),
+	}
+}
+
+// IsActive memeriksa sesi milik access token dan mencatat last_seen_at.
+// Token tanpa baris sesi (diterbitkan sebelum fitur ini) dianggap aktif.
+func (s *SessionService) IsActive(sessionID string) (bool, error) {
+	var session models.UserSession
+	if err := database.DB.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	if session.RevokedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		database.DB.Model(&models.UserSession{}).
+			Where("id = ?", session.ID).
+			UpdateColumn("last_seen_at", now)
+	}
+	return true, nil
+}
+
+// GetMySessions mengembalikan sesi aktif milik subject token
+func (s *SessionService) GetMySessions(claims *utils.JWTClaims) ([]response.SessionResponse, error) {
+	return s.GetSessions(map[string]interface{}{
+		"subject":     claims.Subject,
+		"active_only": true,
+	}, claims.SessionID)
+}
+
+// RevokeMySession mengakhiri salah satu sesi milik subject token sendiri
+func (s *SessionService) RevokeMySession(claims *utils.JWTClaims, id int) error {
+	session, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	if session.Subject != claims.Subject {
+		return ErrSessionNotFound
+	}
+
+	return s.revoke(session, "diakhiri oleh pemilik akun", claims.Subject)
+}
+
+// GetSessions mengembalikan sesi dengan filter subject / user_type / id_user / identifier
+func (s *SessionService) GetSessions(filters map[string]interface{}, currentSessionID string) ([]response.SessionResponse, error) {
+	query := database.DB.Model(&models.UserSession{})
+
+	if subject, ok := filters["subject"].(string); ok && subject != "" {
+		query = query.Where("subject = ?", subject)
+	}
+	if userType, ok := filters["user_type"].(string); ok && userType != "" {
+		query = query.Where("user_type = ?", userType)
+	}
+	if idUser, ok := filters["id_user"].(int); ok && idUser > 0 {
+		query = query.Where("id_user = ?", idUser)
+	}
+	if identifier, ok := filters["identifier"].(string); ok && identifier != "" {
+		query = query.Where("identifier = ?", identifier)
+	}
+	if activeOnly, ok := filters["active_only"].(bool); ok && activeOnly {
+		query = query.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	var sessions []models.UserSession
+	if err := query.Order("last_seen_at DESC").Limit(500).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, response.SessionResponse{
+			ID:         session.ID,
+			UserType:   session.UserType,
+			IDUser:     session.IDUser,
+			Identifier: session.Identifier,
+			Nama:       session.Nama,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			IssuedAt:   session.IssuedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			RevokedAt:  session.RevokedAt,
+			Alasan:     session.Alasan,
+			IsActive:   session.IsActive(),
+			IsCurrent:  currentSessionID != "" && session.SessionID == currentSessionID,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession mengakhiri sesi mana pun (admin)
+func (s *SessionService) RevokeSession(id int, userUpdate string) error {
+	session, err := s.find(id)
+	if err != nil {
+		return err
+	}
+
+	return s.revoke(session, "diakhiri oleh admin", userUpdate)
+}
+
+// revoke mencabut refresh token sesi dan menandai sesi berakhir.
+// Access token sesi ini langsung ditolak JWTAuth karena sid-nya sudah dicabut.
+func (s *SessionService) revoke(session *models.UserSession, alasan, userUpdate string) error {
+	if session.RevokedAt != nil {
+		return errors.New("sesi sudah berakhir")
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return s.tokenService.revokeFamily(tx, session.SessionID, alasan, userUpdate)
+	})
+}
+
+// find mengambil sesi berdasarkan id
+func (s *SessionService) find(id int) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := database.DB.Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// StatisticsService handles rekap data pengajuan untuk dashboard
+type StatisticsService struct{}
+
+// NewStatisticsService creates a new statistics service
+func NewStatisticsService() *StatisticsService {
+	return &StatisticsService{}
+}
+
+// GetPengajuanStatistics menghitung jumlah pengajuan per status, kategori dan fakultas
+func (s *StatisticsService) GetPengajuanStatistics(tahun int) (*response.PengajuanStatisticsResponse, error) {
+	base := func() *gorm.DB {
+		query := database.DB.Model(&models.Pengajuan{}).Where("db_pengajuan_pkm.hapus = ?", 0)
+		if tahun > 0 {
+			query = query.Where("db_pengajuan_pkm.tahun = ?", tahun)
+		}
+		return query
+	}
+
+	result := &response.PengajuanStatisticsResponse{Tahun: tahun}
+
+	// 1. Total
+	if err := base().Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	// 2. Per status
+	var err error
+	if result.PerStatusJudul, err = s.countByStatus(base(), "status_judul"); err != nil {
+		return nil, err
+	}
+	if result.PerStatusProposal, err = s.countByStatus(base(), "status_proposal"); err != nil {
+		return nil, err
+	}
+	if result.PerStatusFinal, err = s.countByStatus(base(), "status_final"); err != nil {
+		return nil, err
+	}
+
+	// 3. Per kategori
+	result.PerKategori = []response.KategoriCount{}
+	if err := base().
+		Select("db_pengajuan_pkm.id_kategori, COALESCE(k.nama_kategori, '') AS nama_kategori, COUNT(*) AS jumlah").
+		Joins("LEFT JOIN db_kategori_pkm k ON k.id = db_pengajuan_pkm.id_kategori").
+		Group("db_pengajuan_pkm.id_kategori, k.nama_kategori").
+		Order("jumlah DESC").
+		Scan(&result.PerKategori).Error; err != nil {
+		return nil, err
+	}
+
+	// 4. Per fakultas
+	result.PerFakultas = []response.FakultasCount{}
+	if err := base().
+		Select("COALESCE(fakultas, '') AS fakultas, COUNT(*) AS jumlah").
+		Group("fakultas").
+		Order("jumlah DESC").
+		Scan(&result.PerFakultas).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// countByStatus menghitung jumlah pengajuan per nilai kolom status
+func (s *StatisticsService) countByStatus(query *gorm.DB, column string) ([]response.StatusCount, error) {
+	counts := []response.StatusCount{}
+	err := query.
+		Select("COALESCE(" + column + ", '') AS status, COUNT(*) AS jumlah").
+		Group(column).
+		Order("jumlah DESC").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenInvalid dikembalikan untuk refresh token yang tidak dikenal, kadaluarsa, atau dicabut
+var ErrRefreshTokenInvalid = errors.New("refresh token tidak valid atau sudah kadaluarsa")
+
+// TokenPair berisi access token dan refresh token hasil login/refresh
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// TokenService handles access token issuance, refresh token rotation and revocation
+type TokenService struct{}
+
+// NewTokenService creates a new token service
+func NewTokenService() *TokenService {
+	return &TokenService{}
+}
+
+// IssueTokenPair menerbitkan access token dan refresh token baru (family baru)
+// sekaligus mencatat sesi login. Family ID refresh token dipakai sebagai id sesi (claim sid).
+func (s *TokenService) IssueTokenPair(claims *utils.JWTClaims, ipAddress, userAgent string) (*TokenPair, error) {
+	familyID, err := utils.GenerateOpaqueToken(24)
+	if err != nil {
+		return nil, err
+	}
+	claims.SessionID = familyID
+
+	var pair *TokenPair
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var refresh *models.RefreshToken
+		pair, refresh, err = s.issue(tx, claims, familyID, ipAddress, userAgent)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		session := &models.UserSession{
+			SessionID:  familyID,
+			Subject:    claims.Subject,
+			UserType:   claims.UserType,
+			IDUser:     claims.UserID,
+			Identifier: truncate(claims.Username, 100),
+			Nama:       truncate(claimsDisplayName(claims), 150),
+			IPAddress:  truncate(ipAddress, 45),
+			UserAgent:  truncate(userAgent, 255),
+			IssuedAt:   now,
+			LastSeenAt: now,
+			ExpiresAt:  refresh.ExpiresAt,
+			UserUpdate: claims.Subject,
+		}
+		if err := tx.Create(session).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan sesi login: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// issue menandatangani access token dan menyimpan refresh token pada family yang diberikan
+func (s *TokenService) issue(db *gorm.DB, claims *utils.JWTClaims, familyID, ipAddress, userAgent string) (*TokenPair, *models.RefreshToken, error) {
+	accessToken, err := utils.SignAccessToken(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawRefresh, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Snapshot claims tanpa registered claims, dipakai ulang saat refresh
+	snapshot := *claims
+	snapshot.RegisteredClaims = jwt.RegisteredClaims{}
+	claimsJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	refresh := &models.RefreshToken{
+		TokenHash: utils.HashOpaqueToken(rawRefresh),
+		FamilyID:  familyID,
+		Subject:   claims.Subject,
+		Claims:    string(claimsJSON),
+		ExpiresAt: now.Add(utils.RefreshTokenTTL()),
+		IPAddress: ipAddress,
+		UserAgent: truncate(userAgent, 255),
+		TglInsert: &now,
+	}
+	if err := db.Create(refresh).Error; err != nil {
+		return nil, nil, fmt.Errorf("gagal menyimpan refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     rawRefresh,
+		AccessExpiresAt:  claims.ExpiresAt.Time,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, refresh, nil
+}
+
+// Refresh menukar refresh token dengan pasangan token baru (rotasi).
+// Pemakaian ulang refresh token yang sudah dirotasi mencabut seluruh family.
+func (s *TokenService) Refresh(rawRefresh, ipAddress, userAgent string) (*TokenPair, *utils.JWTClaims, error) {
+	// 1. Cari refresh token
+	var current models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", utils.HashOpaqueToken(rawRefresh)).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRefreshTokenInvalid
+		}
+		return nil, nil, err
+	}
+
+	// 2. Deteksi reuse: token sudah pernah ditukar
+	if current.ReplacedBy != nil {
+		_ = s.revokeFamily(database.DB, current.FamilyID, "refresh token dipakai ulang", current.Subject)
+		return nil, nil, errors.New("refresh token sudah pernah digunakan, semua sesi terkait dicabut")
+	}
+	if !current.IsUsable() {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+
+	// 3. Pulihkan claims dan pastikan akun masih boleh login
+	var claims utils.JWTClaims
+	if err := json.Unmarshal([]byte(current.Claims), &claims); err != nil {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+	if err := s.ensureSubjectActive(&claims); err != nil {
+		// Gagal cek database: tolak tanpa mencabut sesi; akun nonaktif: cabut family
+		var inactive subjectInactiveError
+		if errors.As(err, &inactive) {
+			_ = s.revokeFamily(database.DB, current.FamilyID, "akun tidak aktif", current.Subject)
+		}
+		return nil, nil, err
+	}
+
+	// Sesi yang sudah diakhiri (logout perangkat / admin) tidak bisa diperpanjang
+	var session models.UserSession
+	if err := database.DB.Where("session_id = ?", current.FamilyID).First(&session).Error; err == nil && session.RevokedAt != nil {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+
+	// 4. Rotasi dalam satu transaksi
+	tx := database.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	result := tx.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", current.ID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Sudah dirotasi oleh request lain secara bersamaan
+		tx.Rollback()
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+
+	pair, replacement, err := s.issue(tx, &claims, current.FamilyID, ipAddress, userAgent)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	if err := tx.Model(&models.RefreshToken{}).Where("id = ?", current.ID).Update("replaced_by", replacement.ID).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	// Perbarui jejak sesi (token sebelum fitur sesi tidak punya baris, update diabaikan)
+	if err := tx.Model(&models.UserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", current.FamilyID).
+		Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"expires_at":   replacement.ExpiresAt,
+			"ip_address":   truncate(ipAddress, 45),
+			"user_agent":   truncate(userAgent, 255),
+		}).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, err
+	}
+
+	return pair, &claims, nil
+}
+
+// Logout mencabut access token saat ini beserta refresh token-nya.
+// Jika allDevices bernilai true, semua token milik subject ikut dicabut.
+func (s *TokenService) Logout(claims *utils.JWTClaims, rawRefresh string, allDevices bool) error {
+	// 1. Cabut access token yang sedang dipakai
+	if err := s.RevokeAccessToken(claims, "logout"); err != nil {
+		return err
+	}
+
+	// 2. Akhiri sesi token ini beserta refresh token-nya
+	if claims.SessionID != "" {
+		if err := s.revokeFamily(database.DB, claims.SessionID, "logout", claims.Subject); err != nil {
+			return err
+		}
+	}
+
+	// 3. Cabut family refresh token yang dikirim (hanya milik subject yang sama)
+	if rawRefresh != "" {
+		var refresh models.RefreshToken
+		err := database.DB.Where("token_hash = ? AND subject = ?", utils.HashOpaqueToken(rawRefresh), claims.Subject).
+			First(&refresh).Error
+		if err == nil {
+			if refresh.FamilyID != claims.SessionID {
+				if err := s.revokeFamily(database.DB, refresh.FamilyID, "logout", claims.Subject); err != nil {
+					return err
+				}
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	// 4. Logout dari semua perangkat
+	if allDevices {
+		return s.RevokeSubject(claims.Subject, "logout semua perangkat", claims.Subject)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken memasukkan jti access token ke daftar cabut sampai token kadaluarsa
+func (s *TokenService) RevokeAccessToken(claims *utils.JWTClaims, alasan string) error {
+	if claims.ID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(utils.AccessTokenTTL())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	now := time.Now()
+	return database.DB.Create(&models.RevokedToken{
+		JTI:        claims.ID,
+		Subject:    claims.Subject,
+		ExpiresAt:  expiresAt,
+		Alasan:     alasan,
+		TglInsert:  &now,
+		UserUpdate: claims.Subject,
+	}).Error
+}
+
+// RevokeSubject mencabut semua access token dan refresh token milik subject
+// yang diterbitkan sampai saat ini. Dipakai untuk memutus sesi yang bocor.
+func (s *TokenService) RevokeSubject(subject, alasan, userUpdate string) error {
+	now := time.Now()
+
+	tx := database.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("subject = ? AND revoked_at IS NULL", subject).
+		Update("revoked_at", now).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(&models.UserSession{}).
+		Where("subject = ? AND revoked_at IS NULL", subject).
+		Updates(map[string]interface{}{
+			"revoked_at":  now,
+			"alasan":      truncate(alasan, 255),
+			"user_update": userUpdate,
+		}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Access token paling lama hidup selama AccessTokenTTL
+	if err := tx.Create(&models.RevokedToken{
+		Subject:       subject,
+		RevokedBefore: &now,
+		ExpiresAt:     now.Add(utils.AccessTokenTTL()),
+		Alasan:        alasan,
+		TglInsert:     &now,
+		UserUpdate:    userUpdate,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// IsRevoked memeriksa apakah access token sudah dicabut.
+// Token bersesi (sid) dicabut lewat sesinya: RevokeSubject mengakhiri semua sesi subject dan
+// JWTAuth menolak sesi yang sudah diakhiri, sehingga login ulang di detik yang sama tetap berlaku.
+// Pencabutan per subject (revoked_before) hanya untuk token tanpa sesi (impersonation);
+// iat hanya sampai detik, jadi token yang terbit di detik pencabutan ikut ditolak.
+func (s *TokenService) IsRevoked(claims *utils.JWTClaims) (bool, error) {
+	query := database.DB.Model(&models.RevokedToken{}).Where("jti <> '' AND jti = ?", claims.ID)
+	if claims.SessionID == "" && claims.Subject != "" && claims.IssuedAt != nil {
+		query = query.Or("subject = ? AND jti = '' AND revoked_before > ?", claims.Subject, claims.IssuedAt.Time)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// PurgeExpired menghapus baris token yang sudah kadaluarsa
+func (s *TokenService) PurgeExpired() error {
+	now := time.Now()
+	if err := database.DB.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Where("expires_at < ?", now).Delete(&models.UserSession{}).Error; err != nil {
+		return err
+	}
+	return database.DB.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
+
+// revokeFamily mencabut semua refresh token dalam satu family dan mengakhiri sesinya
+func (s *TokenService) revokeFamily(db *gorm.DB, familyID, alasan, userUpdate string) error {
+	now := time.Now()
+	if err := db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	return db.Model(&models.UserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":  now,
+			"alasan":      truncate(alasan, 255),
+			"user_update": userUpdate,
+		}).Error
+}
+
+// subjectInactiveError menandai akun yang memang tidak aktif (bukan kegagalan query)
+type subjectInactiveError string
+
+func (e subjectInactiveError) Error() string {
+	return string(e)
+}
+
+// ensureSubjectActive memastikan akun lokal masih aktif sebelum token diperpanjang.
+// Error query dikembalikan apa adanya agar refresh gagal (fail closed).
+func (s *TokenService) ensureSubjectActive(claims *utils.JWTClaims) error {
+	switch claims.UserType {
+	case "admin":
+		var count int64
+		if err := database.DB.Model(&models.User{}).
+			Where("id = ? AND status = ? AND hapus = ?", claims.UserID, 1, 0).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check account status: %w", err)
+		}
+		if count == 0 {
+			return subjectInactiveError("akun tidak aktif")
+		}
+	case "pegawai":
+		// Token dosen pembimbing (bukan reviewer) berlaku selama masih membimbing
+		if claims.UserID == 0 {
+			idPegawai, _ := strconv.Atoi(claims.UserData["id_pegawai"])
+			active, err := isActivePembimbing(idPegawai)
+			if err != nil {
+				return fmt.Errorf("failed to check pembimbing status: %w", err)
+			}
+			if !active {
+				return subjectInactiveError("dosen pembimbing tidak aktif")
+			}
+			return nil
+		}
+		var count int64
+		if err := database.DB.Model(&models.Reviewer{}).
+			Where("id = ? AND is_active = ? AND status = ? AND hapus = ?", claims.UserID, 1, 1, 0).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check reviewer status: %w", err)
+		}
+		if count == 0 {
+			return subjectInactiveError("reviewer tidak aktif")
+		}
+	}
+	return nil
+}
+
+// claimsDisplayName mengambil nama pemilik token dari user_data
+func claimsDisplayName(claims *utils.JWTClaims) string {
+	if nama := claims.UserData["nama"]; nama != "" {
+		return nama
+	}
+	return claims.UserData["nama_user"]
+}
+
+// truncate memotong string agar muat di kolom varchar
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"rires-be/internal/dto/request"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+	"rires-be/pkg/workflow"
+
+	"gorm.io/gorm"
+)
+
+// Status pengalihan ketua
+const (
+	TransferPending   = "PENDING"
+	TransferAccepted  = "ACCEPTED"
+	TransferDeclined  = "DECLINED"
+	TransferCancelled = "CANCELLED"
+)
+
+// Aksi timeline untuk pengalihan ketua
+const (
+	EventNominateKetua workflow.Action = "NOMINATE_KETUA"
+	EventTransferKetua workflow.Action = "TRANSFER_KETUA"
+	EventDeclineKetua  workflow.Action = "DECLINE_KETUA"
+	EventCancelKetua   workflow.Action = "CANCEL_TRANSFER_KETUA"
+)
+
+// ErrTransferNotFound dikembalikan jika pengalihan tidak ada atau bukan untuk user ini
+var ErrTransferNotFound = errors.New("pengalihan ketua tidak ditemukan")
+
+// ErrTransferForbidden dikembalikan jika user bukan ketua saat ini atau admin
+var ErrTransferForbidden = errors.New("hanya ketua atau admin yang dapat mengalihkan ketua")
+
+// ErrTransferNotPending dikembalikan jika pengalihan sudah dijawab/dibatalkan oleh request lain
+var ErrTransferNotPending = errors.New("pengalihan ketua sudah tidak menunggu jawaban")
+
+// ErrKetuaChanged dikembalikan jika ketua pengajuan sudah bukan pengusul pengalihan
+var ErrKetuaChanged = errors.New("ketua pengajuan sudah berubah, pengalihan dibatalkan")
+
+// TransferKetuaService mengelola pengalihan ketua antar anggota tim
+type TransferKetuaService struct {
+	externalService *ExternalDataService
+	validator       *utils.StatusValidator
+	invitations     *InvitationService
+	events          *PengajuanEventService
+	periode         *PeriodeService
+}
+
+// NewTransferKetuaService creates a new transfer ketua service
+func NewTransferKetuaService() *TransferKetuaService {
+	return &TransferKetuaService{
+		externalService: NewExternalDataService(),
+		validator:       utils.NewStatusValidator(),
+		invitations:     NewInvitationService(),
+		events:          NewPengajuanEventService(),
+		periode:         NewPeriodeService(),
+	}
+}
+
+// Nominate mengajukan anggota tim sebagai ketua baru. Ketua belum berubah sampai anggota menerima.
+func (s *TransferKetuaService) Nominate(idPengajuan int, req *request.NominateKetuaRequest, actor workflow.Actor) (*response.TransferKetuaResponse, error) {
+	// 1. Get pengajuan
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", idPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPengajuanNotFound
+		}
+		return nil, err
+	}
+
+	// 2. Ketua saat ini atau admin
+	if !actor.IsAdmin && actor.NIM != pengajuan.NIMKetua {
+		return nil, ErrTransferForbidden
+	}
+	if req.NIMBaru == pengajuan.NIMKetua {
+		return nil, errors.New("NIM tersebut sudah menjadi ketua")
+	}
+
+	// 3. Target must be a confirmed member of the team
+	if _, err := s.findMember(database.DB, pengajuan.ID, req.NIMBaru); err != nil {
+		return nil, err
+	}
+	pendingMembers, err := s.invitations.PendingMembers(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, nim := range pendingMembers {
+		if nim == req.NIMBaru {
+			return nil, errors.New("anggota belum menerima undangan tim")
+		}
+	}
+
+	// 4. Only one open nomination per pengajuan
+	var count int64
+	if err := database.DB.Model(&models.TransferKetua{}).
+		Where("id_pengajuan = ? AND status_transfer = ?", pengajuan.ID, TransferPending).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("masih ada pengalihan ketua yang menunggu jawaban")
+	}
+
+	// 5. Save nomination
+	now := time.Now()
+	transfer := &models.TransferKetua{
+		IDPengajuan:    pengajuan.ID,
+		NIMLama:        pengajuan.NIMKetua,
+		NIMBaru:        req.NIMBaru,
+		Alasan:         req.Alasan,
+		StatusTransfer: TransferPending,
+		DiajukanOleh:   actor.UserUpdate,
+		TglInsert:      &now,
+		UserUpdate:     actor.UserUpdate,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    EventNominateKetua,
+			Actor:     actor,
+			Pengajuan: &pengajuan,
+			Catatan:   req.Alasan,
+			DataBaru:  map[string]interface{}{"nim_ketua": req.NIMBaru},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.toResponse(transfer, &pengajuan)
+	return &result, nil
+}
+
+// Respond dijalankan anggota yang dinominasikan. Jika diterima, nim_ketua, nama_ketua,
+// biodata ketua, flag is_ketua dan urutan diperbarui dalam satu transaksi.
+func (s *TransferKetuaService) Respond(id int, nim string, accept bool, req *request.AcceptKetuaRequest) (*response.TransferKetuaResponse, error) {
+	// 1. Get transfer addressed to this mahasiswa
+	var transfer models.TransferKetua
+	if err := database.DB.Where("id = ? AND nim_baru = ?", id, nim).First(&transfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+	if transfer.StatusTransfer != TransferPending {
+		return nil, errors.New("pengalihan ketua sudah tidak menunggu jawaban (status: " + transfer.StatusTransfer + ")")
+	}
+
+	// 2. Get pengajuan; nominasi gugur jika ketua sudah berganti
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ? AND hapus = ?", transfer.IDPengajuan, 0).First(&pengajuan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPengajuanNotFound
+		}
+		return nil, err
+	}
+	if pengajuan.NIMKetua != transfer.NIMLama {
+		s.closeTransfer(database.DB, transfer.ID, map[string]interface{}{
+			"status_transfer": TransferCancelled,
+			"user_update":     "system",
+		})
+		return nil, ErrKetuaChanged
+	}
+
+	actor := workflow.Actor{NIM: nim, UserUpdate: nim}
+	now := time.Now()
+
+	// 3. Decline: ketua tetap
+	if !accept {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := s.closeTransfer(tx, transfer.ID, map[string]interface{}{
+				"status_transfer": TransferDeclined,
+				"responded_at":    now,
+				"user_update":     nim,
+			}); err != nil {
+				return err
+			}
+			return s.events.Record(tx, &PengajuanEvent{
+				Action:    EventDeclineKetua,
+				Actor:     actor,
+				Pengajuan: &pengajuan,
+				DataBaru:  map[string]interface{}{"nim_ketua": nim},
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		transfer.StatusTransfer = TransferDeclined
+		transfer.RespondedAt = &now
+		result := s.toResponse(&transfer, &pengajuan)
+		return &result, nil
+	}
+
+	// 4. Accept
+	idPeriode, err := s.periode.Get(pengajuan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := pengajuan
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// 4a. Tutup nominasi lebih dulu: hanya satu request yang bisa mengubah status PENDING
+		if err := s.closeTransfer(tx, transfer.ID, map[string]interface{}{
+			"status_transfer": TransferAccepted,
+			"responded_at":    now,
+			"user_update":     nim,
+		}); err != nil {
+			return err
+		}
+
+		// Ketua baru harus masih dalam batas ketua, dan ketua lama (yang kini menjadi anggota)
+		// dalam batas anggota per periode
+		if err := s.validator.ValidateParticipationLimitsTx(tx, []models.PengajuanAnggota{
+			{NIMAnggota: nim, IsKetua: 1},
+			{NIMAnggota: transfer.NIMLama, IsKetua: 0},
+		}, idPeriode, pengajuan.ID); err != nil {
+			return err
+		}
+
+		newKetua, err := s.findMember(tx, pengajuan.ID, nim)
+		if err != nil {
+			return err
+		}
+
+		nama := newKetua.NamaAnggota
+		if nama == "" {
+			if mahasiswa, err := s.externalService.GetMahasiswaByNIM(nim); err == nil {
+				nama = mahasiswa.NamaSiswa
+			}
+		}
+
+		// 4b. Biodata ketua di pengajuan, hanya jika ketua belum berganti sejak dibaca
+		updates := map[string]interface{}{
+			"nim_ketua":     nim,
+			"nama_ketua":    nama,
+			"email_ketua":   req.EmailKetua,
+			"no_hp_ketua":   req.NoHPKetua,
+			"program_studi": req.ProgramStudi,
+			"fakultas":      req.Fakultas,
+			"user_update":   nim,
+		}
+		result := tx.Model(&pengajuan).Where("nim_ketua = ? AND hapus = ?", transfer.NIMLama, 0).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrKetuaChanged
+		}
+
+		// 4c. Tukar flag ketua & urutan: ketua lama menempati urutan ketua baru
+		if err := tx.Model(&models.PengajuanAnggota{}).
+			Where("id_pengajuan = ? AND nim_anggota = ? AND hapus = ?", pengajuan.ID, transfer.NIMLama, 0).
+			Updates(map[string]interface{}{"is_ketua": 0, "urutan": newKetua.Urutan}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PengajuanAnggota{}).
+			Where("id = ?", newKetua.ID).
+			Updates(map[string]interface{}{"is_ketua": 1, "urutan": 1}).Error; err != nil {
+			return err
+		}
+
+		// 4d. Ketua lama menjadi anggota yang sudah menyetujui tim
+		if err := s.invitations.MarkAccepted(tx, &pengajuan, transfer.NIMLama, before.NamaKetua, nim); err != nil {
+			return err
+		}
+
+		// 4e. Record
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    EventTransferKetua,
+			Actor:     actor,
+			Pengajuan: &pengajuan,
+			Before:    &before,
+			Updates:   updates,
+			Catatan:   transfer.Alasan,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.StatusTransfer = TransferAccepted
+	transfer.RespondedAt = &now
+	result := s.toResponse(&transfer, &pengajuan)
+	return &result, nil
+}
+
+// Cancel membatalkan nominasi yang belum dijawab (ketua yang mengajukan atau admin)
+func (s *TransferKetuaService) Cancel(id int, actor workflow.Actor) error {
+	var transfer models.TransferKetua
+	if err := database.DB.Where("id = ?", id).First(&transfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTransferNotFound
+		}
+		return err
+	}
+	if !actor.IsAdmin && actor.NIM != transfer.NIMLama {
+		return ErrTransferForbidden
+	}
+	if transfer.StatusTransfer != TransferPending {
+		return errors.New("hanya pengalihan yang masih menunggu jawaban yang dapat dibatalkan")
+	}
+
+	var pengajuan models.Pengajuan
+	if err := database.DB.Where("id = ?", transfer.IDPengajuan).First(&pengajuan).Error; err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.closeTransfer(tx, transfer.ID, map[string]interface{}{
+			"status_transfer": TransferCancelled,
+			"user_update":     actor.UserUpdate,
+		}); err != nil {
+			return err
+		}
+		return s.events.Record(tx, &PengajuanEvent{
+			Action:    EventCancelKetua,
+			Actor:     actor,
+			Pengajuan: &pengajuan,
+			DataLama:  map[string]interface{}{"nim_ketua": transfer.NIMBaru},
+		})
+	})
+}
+
+// closeTransfer mengubah status pengalihan yang masih PENDING. Update bersyarat agar
+// jawaban/pembatalan yang bersamaan tidak saling menimpa.
+func (s *TransferKetuaService) closeTransfer(db *gorm.DB, id int, updates map[string]interface{}) error {
+	result := db.Model(&models.TransferKetua{}).
+		Where("id = ? AND status_transfer = ?", id, TransferPending).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTransferNotPending
+	}
+	return nil
+}
+
+// GetMyTransfers returns nominations sent or received by the mahasiswa, newest first
+func (s *TransferKetuaService) GetMyTransfers(nim string) ([]response.TransferKetuaResponse, error) {
+	var transfers []models.TransferKetua
+	if err := database.DB.Where("nim_baru = ? OR nim_lama = ?", nim, nim).
+		Order("tgl_insert DESC, id DESC").
+		Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.TransferKetuaResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		var pengajuan models.Pengajuan
+		if err := database.DB.Where("id = ? AND hapus = ?", transfer.IDPengajuan, 0).First(&pengajuan).Error; err != nil {
+			continue
+		}
+		result = append(result, s.toResponse(&transfer, &pengajuan))
+	}
+	return result, nil
+}
+
+// findMember mengambil baris anggota non-ketua pada pengajuan
+func (s *TransferKetuaService) findMember(db *gorm.DB, idPengajuan int, nim string) (*models.PengajuanAnggota, error) {
+	var anggota models.PengajuanAnggota
+	if err := db.Where("id_pengajuan = ? AND nim_anggota = ? AND is_ketua = ? AND hapus = ?", idPengajuan, nim, 0, 0).
+		First(&anggota).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("NIM " + nim + " bukan anggota tim pengajuan ini")
+		}
+		return nil, err
+	}
+	return &anggota, nil
+}
+
+// toResponse memetakan pengalihan ke DTO
+func (s *TransferKetuaService) toResponse(t *models.TransferKetua, p *models.Pengajuan) response.TransferKetuaResponse {
+	return response.TransferKetuaResponse{
+		ID:             t.ID,
+		IDPengajuan:    t.IDPengajuan,
+		KodePengajuan:  p.KodePengajuan,
+		Judul:          p.Judul,
+		NIMLama:        t.NIMLama,
+		NIMBaru:        t.NIMBaru,
+		Alasan:         t.Alasan,
+		StatusTransfer: t.StatusTransfer,
+		DiajukanOleh:   t.DiajukanOleh,
+		RespondedAt:    t.RespondedAt,
+		TglInsert:      t.TglInsert,
+	}
+}
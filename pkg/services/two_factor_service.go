@@ -0,0 +1,504 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"rires-be/config"
+	"rires-be/internal/dto/response"
+	"rires-be/internal/models"
+	"rires-be/pkg/database"
+	"rires-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// twoFactorMaxAttempts batas kode salah per challenge token
+const twoFactorMaxAttempts = 5
+
+// twoFactorRecoveryCodeCount jumlah kode pemulihan yang diterbitkan
+const twoFactorRecoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorChallengeInvalid challenge token tidak dikenal, sudah dipakai, kedaluwarsa atau terlalu banyak percobaan
+	ErrTwoFactorChallengeInvalid = errors.New("challenge token tidak valid atau sudah kedaluwarsa, silakan login ulang")
+	// ErrTwoFactorInvalidCode kode TOTP / kode pemulihan salah
+	ErrTwoFactorInvalidCode = errors.New("kode verifikasi salah")
+	// ErrTwoFactorNotEnabled akun belum mengaktifkan 2FA
+	ErrTwoFactorNotEnabled = errors.New("2FA belum aktif untuk akun ini")
+)
+
+// TwoFactorService handles TOTP two-factor authentication untuk akun admin db_user
+type TwoFactorService struct{}
+
+// NewTwoFactorService creates a new two-factor service
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{}
+}
+
+// IsRequired memeriksa apakah level user wajib memakai 2FA (TWO_FACTOR_REQUIRED_LEVELS)
+func (s *TwoFactorService) IsRequired(idUserLevel int) bool {
+	for _, level := range strings.Split(config.AppConfig.TwoFactorRequiredLevels, ",") {
+		if value, err := strconv.Atoi(strings.TrimSpace(level)); err == nil && value == idUserLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEnabled memeriksa apakah user sudah mengaktifkan 2FA
+func (s *TwoFactorService) IsEnabled(idUser int) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.UserTwoFactor{}).
+		Where("id_user = ? AND is_enabled = ?", idUser, 1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// NeedsChallenge menentukan apakah login admin harus melewati faktor kedua.
+// setupRequired bernilai true jika level wajib 2FA tetapi user belum enrollment.
+func (s *TwoFactorService) NeedsChallenge(identity *AuthIdentity) (needed bool, setupRequired bool, err error) {
+	if identity.UserType != UserTypeAdmin || identity.UserID == 0 {
+		return false, false, nil
+	}
+
+	enabled, err := s.IsEnabled(int(identity.UserID))
+	if err != nil {
+		return false, false, err
+	}
+	if enabled {
+		return true, false, nil
+	}
+	if s.IsRequired(identity.IDUserLevel) {
+		return true, true, nil
+	}
+	return false, false, nil
+}
+
+// CreateChallenge menerbitkan challenge token setelah password admin benar
+func (s *TwoFactorService) CreateChallenge(identity *AuthIdentity, setupRequired bool, ipAddress string) (*response.TwoFactorChallengeResponse, error) {
+	rawToken, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.challengeTTL()
+	now := time.Now()
+	challenge := &models.TwoFactorChallenge{
+		TokenHash: utils.HashOpaqueToken(rawToken),
+		IDUser:    int(identity.UserID),
+		Username:  identity.Username,
+		ExpiresAt: now.Add(ttl),
+		IPAddress: truncate(ipAddress, 45),
+		TglInsert: &now,
+	}
+	if err := database.DB.Create(challenge).Error; err != nil {
+		return nil, err
+	}
+
+	return &response.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    rawToken,
+		ExpiresIn:         int(ttl.Seconds()),
+		SetupRequired:     setupRequired,
+	}, nil
+}
+
+// EnrollFromChallenge memulai enrollment untuk admin yang wajib 2FA tetapi belum punya
+func (s *TwoFactorService) EnrollFromChallenge(rawChallenge string) (*response.TwoFactorSetupResponse, error) {
+	challenge, err := s.findChallenge(rawChallenge)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Setup(challenge.IDUser, challenge.Username)
+}
+
+// ChallengeUsername mengembalikan username pemilik challenge yang masih bisa diverifikasi
+// (dipakai untuk throttle login sebelum kode diperiksa)
+func (s *TwoFactorService) ChallengeUsername(rawChallenge string) (string, error) {
+	challenge, err := s.findChallenge(rawChallenge)
+	if err != nil {
+		return "", err
+	}
+	return challenge.Username, nil
+}
+
+// VerifyChallenge memverifikasi faktor kedua lalu mengembalikan identitas admin untuk diterbitkan token.
+// Jika enrollment dilakukan lewat challenge, 2FA diaktifkan dan kode pemulihan ikut dikembalikan.
+func (s *TwoFactorService) VerifyChallenge(rawChallenge, code, recoveryCode string) (*AuthIdentity, []string, error) {
+	challenge, err := s.findChallenge(rawChallenge)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var recoveryCodes []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Klaim challenge lebih dulu: verifikasi paralel dengan challenge yang sama hanya lolos sekali.
+		// Jika kode salah, transaksi di-rollback dan challenge bisa dicoba lagi.
+		result := tx.Model(&models.TwoFactorChallenge{}).
+			Where("id = ? AND dipakai_at IS NULL", challenge.ID).
+			Update("dipakai_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTwoFactorChallengeInvalid
+		}
+
+		twoFactor, err := s.find(tx, challenge.IDUser)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case twoFactor.IsEnabled == 1 && recoveryCode != "":
+			err = s.consumeRecoveryCode(tx, twoFactor, recoveryCode)
+		case twoFactor.IsEnabled == 1:
+			err = s.consumeCode(tx, twoFactor, code)
+		default:
+			// Enrollment saat login: kode pertama mengaktifkan 2FA
+			recoveryCodes, err = s.activate(tx, twoFactor, code, strconv.Itoa(challenge.IDUser))
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorInvalidCode) || errors.Is(err, ErrTwoFactorNotEnabled) {
+			database.DB.Model(challenge).UpdateColumn("percobaan", gorm.Expr("percobaan + 1"))
+		}
+		return nil, nil, err
+	}
+
+	identity, err := s.adminIdentity(challenge.IDUser)
+	if err != nil {
+		return nil, nil, err
+	}
+	return identity, recoveryCodes, nil
+}
+
+// GetStatus mengembalikan status 2FA akun admin
+func (s *TwoFactorService) GetStatus(idUser, idUserLevel int) (*response.TwoFactorStatusResponse, error) {
+	result := &response.TwoFactorStatusResponse{
+		Required: s.IsRequired(idUserLevel),
+	}
+
+	twoFactor, err := s.find(database.DB, idUser)
+	if errors.Is(err, ErrTwoFactorNotEnabled) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Enabled = twoFactor.IsEnabled == 1
+	result.EnabledAt = twoFactor.EnabledAt
+	if result.Enabled {
+		result.RecoveryCodesLeft = len(twoFactor.RecoveryCodeHashes())
+	}
+	return result, nil
+}
+
+// Setup membuat (atau mengganti) secret yang belum dikonfirmasi
+func (s *TwoFactorService) Setup(idUser int, account string) (*response.TwoFactorSetupResponse, error) {
+	twoFactor, err := s.find(database.DB, idUser)
+	if err != nil && !errors.Is(err, ErrTwoFactorNotEnabled) {
+		return nil, err
+	}
+	if twoFactor != nil && twoFactor.IsEnabled == 1 {
+		return nil, errors.New("2FA sudah aktif, nonaktifkan terlebih dahulu untuk mengganti perangkat")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if twoFactor == nil {
+		twoFactor = &models.UserTwoFactor{
+			IDUser:     idUser,
+			Secret:     secret,
+			TglInsert:  &now,
+			UserUpdate: strconv.Itoa(idUser),
+		}
+		if err := database.DB.Create(twoFactor).Error; err != nil {
+			return nil, err
+		}
+	} else if err := database.DB.Model(twoFactor).Updates(map[string]interface{}{
+		"secret":         secret,
+		"last_used_step": 0,
+		"user_update":    strconv.Itoa(idUser),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	issuer := config.AppConfig.TwoFactorIssuer
+	return &response.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(issuer, account, secret),
+		Issuer:          issuer,
+		Account:         account,
+	}, nil
+}
+
+// Enable mengonfirmasi enrollment dengan kode pertama dan menerbitkan kode pemulihan
+func (s *TwoFactorService) Enable(idUser int, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		twoFactor, err := s.find(tx, idUser)
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			return errors.New("jalankan setup 2FA terlebih dahulu")
+		}
+		if err != nil {
+			return err
+		}
+		if twoFactor.IsEnabled == 1 {
+			return errors.New("2FA sudah aktif")
+		}
+
+		recoveryCodes, err = s.activate(tx, twoFactor, code, strconv.Itoa(idUser))
+		return err
+	})
+	return recoveryCodes, err
+}
+
+// Disable menonaktifkan 2FA setelah password dan kode TOTP diverifikasi
+func (s *TwoFactorService) Disable(idUser, idUserLevel int, password, code string) error {
+	if s.IsRequired(idUserLevel) {
+		return errors.New("2FA wajib untuk level akun ini dan tidak dapat dinonaktifkan")
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND hapus = ?", idUser, 0).First(&user).Error; err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if ok, _ := utils.CheckStoredPassword(user.Password, password); !ok {
+		return errors.New("password salah")
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		twoFactor, err := s.findEnabled(tx, idUser)
+		if err != nil {
+			return err
+		}
+		if err := s.consumeCode(tx, twoFactor, code); err != nil {
+			return err
+		}
+		return tx.Delete(twoFactor).Error
+	})
+}
+
+// RegenerateRecoveryCodes mengganti seluruh kode pemulihan (kode lama tidak berlaku lagi)
+func (s *TwoFactorService) RegenerateRecoveryCodes(idUser int, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		twoFactor, err := s.findEnabled(tx, idUser)
+		if err != nil {
+			return err
+		}
+		if err := s.consumeCode(tx, twoFactor, code); err != nil {
+			return err
+		}
+
+		var hashes []string
+		recoveryCodes, hashes, err = s.generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		return tx.Model(twoFactor).Updates(map[string]interface{}{
+			"recovery_codes": strings.Join(hashes, ","),
+			"user_update":    strconv.Itoa(idUser),
+		}).Error
+	})
+	return recoveryCodes, err
+}
+
+// VerifyCode memverifikasi kode TOTP user yang sudah mengaktifkan 2FA (mis. saat switch-role ke admin)
+func (s *TwoFactorService) VerifyCode(idUser int, code string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		twoFactor, err := s.findEnabled(tx, idUser)
+		if err != nil {
+			return err
+		}
+		return s.consumeCode(tx, twoFactor, code)
+	})
+}
+
+// Reset menghapus 2FA user (perangkat hilang dan kode pemulihan habis), dilakukan admin lain
+func (s *TwoFactorService) Reset(idUser int) error {
+	return database.DB.Where("id_user = ?", idUser).Delete(&models.UserTwoFactor{}).Error
+}
+
+// activate mengonfirmasi secret dengan kode pertama lalu menyimpan kode pemulihan
+func (s *TwoFactorService) activate(tx *gorm.DB, twoFactor *models.UserTwoFactor, code, userUpdate string) ([]string, error) {
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrTwoFactorInvalidCode
+	}
+
+	recoveryCodes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := tx.Model(twoFactor).Updates(map[string]interface{}{
+		"is_enabled":     1,
+		"enabled_at":     now,
+		"last_used_step": step,
+		"recovery_codes": strings.Join(hashes, ","),
+		"user_update":    userUpdate,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// consumeCode memverifikasi kode TOTP dan menolak kode yang sudah pernah dipakai
+func (s *TwoFactorService) consumeCode(tx *gorm.DB, twoFactor *models.UserTwoFactor, code string) error {
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok || step <= twoFactor.LastUsedStep {
+		return ErrTwoFactorInvalidCode
+	}
+
+	// Update bersyarat agar dua request paralel dengan kode yang sama tidak sama-sama lolos
+	result := tx.Model(&models.UserTwoFactor{}).
+		Where("id = ? AND last_used_step < ?", twoFactor.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorInvalidCode
+	}
+	return nil
+}
+
+// consumeRecoveryCode memakai satu kode pemulihan (sekali pakai)
+func (s *TwoFactorService) consumeRecoveryCode(tx *gorm.DB, twoFactor *models.UserTwoFactor, recoveryCode string) error {
+	hash := utils.HashOpaqueToken(normalizeRecoveryCode(recoveryCode))
+
+	remaining := []string{}
+	found := false
+	for _, stored := range twoFactor.RecoveryCodeHashes() {
+		if !found && stored == hash {
+			found = true
+			continue
+		}
+		remaining = append(remaining, stored)
+	}
+	if !found {
+		return ErrTwoFactorInvalidCode
+	}
+
+	// Update bersyarat: dua request paralel dengan kode pemulihan yang sama tidak sama-sama lolos
+	result := tx.Model(&models.UserTwoFactor{}).
+		Where("id = ? AND recovery_codes = ?", twoFactor.ID, twoFactor.RecoveryCodes).
+		Update("recovery_codes", strings.Join(remaining, ","))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorInvalidCode
+	}
+	return nil
+}
+
+// generateRecoveryCodes membuat kode pemulihan format xxxxx-xxxxx beserta hash-nya
+func (s *TwoFactorService) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, twoFactorRecoveryCodeCount)
+	hashes := make([]string, 0, twoFactorRecoveryCodeCount)
+	for i := 0; i < twoFactorRecoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(buf)
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, utils.HashOpaqueToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// findChallenge mengambil challenge yang masih bisa diverifikasi
+func (s *TwoFactorService) findChallenge(rawChallenge string) (*models.TwoFactorChallenge, error) {
+	var challenge models.TwoFactorChallenge
+	if err := database.DB.Where("token_hash = ?", utils.HashOpaqueToken(strings.TrimSpace(rawChallenge))).
+		First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTwoFactorChallengeInvalid
+		}
+		return nil, err
+	}
+	if !challenge.IsUsable(twoFactorMaxAttempts) {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+	return &challenge, nil
+}
+
+// find mengambil baris 2FA user (aktif maupun belum dikonfirmasi)
+func (s *TwoFactorService) find(db *gorm.DB, idUser int) (*models.UserTwoFactor, error) {
+	var twoFactor models.UserTwoFactor
+	if err := db.Where("id_user = ?", idUser).First(&twoFactor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+// findEnabled mengambil baris 2FA yang sudah aktif
+func (s *TwoFactorService) findEnabled(db *gorm.DB, idUser int) (*models.UserTwoFactor, error) {
+	twoFactor, err := s.find(db, idUser)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled != 1 {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return twoFactor, nil
+}
+
+// adminIdentity membaca ulang akun admin setelah faktor kedua lolos
+func (s *TwoFactorService) adminIdentity(idUser int) (*AuthIdentity, error) {
+	var user models.User
+	if err := database.DB.Where("id = ? AND hapus = ?", idUser, 0).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user tidak ditemukan")
+		}
+		return nil, err
+	}
+	if user.Status != 1 {
+		return nil, ErrAccountInactive
+	}
+
+	return &AuthIdentity{
+		Provider:    "local",
+		UserType:    UserTypeAdmin,
+		UserID:      uint(user.ID),
+		Username:    user.Username,
+		Nama:        user.NamaUser,
+		IDUserLevel: user.LevelUser,
+	}, nil
+}
+
+// challengeTTL membaca umur challenge token dari config
+func (s *TwoFactorService) challengeTTL() time.Duration {
+	minutes, err := strconv.Atoi(config.AppConfig.TwoFactorChallengeMinutes)
+	if err != nil || minutes < 1 {
+		minutes = 5
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// normalizeRecoveryCode menyamakan format kode pemulihan (tanpa strip/spasi, huruf kecil)
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
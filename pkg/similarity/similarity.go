@@ -0,0 +1,233 @@
+package similarity
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopwords adalah kata umum judul PKM (Indonesia & Inggris) yang tidak membedakan judul
+var stopwords = map[string]bool{
+	"dan": true, "di": true, "ke": true, "dari": true, "yang": true, "untuk": true,
+	"pada": true, "dengan": true, "dalam": true, "sebagai": true, "melalui": true,
+	"terhadap": true, "atau": true, "oleh": true, "ini": true, "itu": true,
+	"bagi": true, "serta": true, "guna": true, "akan": true, "secara": true,
+	"the": true, "of": true, "and": true, "for": true, "in": true, "to": true,
+	"a": true, "an": true, "on": true, "with": true, "by": true,
+}
+
+// Document adalah satu judul di korpus
+type Document struct {
+	ID   int
+	Text string
+}
+
+// Match adalah dokumen korpus beserta skor kemiripannya (0-1)
+type Match struct {
+	ID    int
+	Score float64
+}
+
+// Vector adalah bobot TF-IDF per n-gram, sudah dinormalisasi (panjang 1)
+type Vector map[string]float64
+
+// Index menyimpan IDF dan vektor seluruh dokumen korpus
+type Index struct {
+	idf     map[string]float64
+	unknown float64 // IDF untuk n-gram yang tidak ada di korpus
+	ids     []int
+	vectors map[int]Vector
+}
+
+// NewIndex membangun index TF-IDF dari korpus judul
+func NewIndex(docs []Document) *Index {
+	df := make(map[string]int)
+	grams := make(map[int]map[string]int, len(docs))
+	ids := make([]int, 0, len(docs))
+	for _, doc := range docs {
+		counts := countGrams(doc.Text)
+		grams[doc.ID] = counts
+		ids = append(ids, doc.ID)
+		for gram := range counts {
+			df[gram]++
+		}
+	}
+
+	// IDF dengan smoothing agar korpus kecil tetap stabil
+	n := float64(len(docs))
+	ix := &Index{
+		idf:     make(map[string]float64, len(df)),
+		unknown: math.Log(n+1) + 1,
+		ids:     ids,
+		vectors: make(map[int]Vector, len(docs)),
+	}
+	for gram, count := range df {
+		ix.idf[gram] = math.Log((n+1)/(float64(count)+1)) + 1
+	}
+	for id, counts := range grams {
+		ix.vectors[id] = ix.weigh(counts)
+	}
+	return ix
+}
+
+// Vector menghitung vektor TF-IDF untuk teks di luar korpus
+func (ix *Index) Vector(text string) Vector {
+	return ix.weigh(countGrams(text))
+}
+
+// TopMatches mencari dokumen korpus paling mirip dengan teks, skor tertinggi dulu.
+// Dokumen dengan ID exclude dilewati (judul itu sendiri).
+func (ix *Index) TopMatches(text string, exclude int, limit int, minScore float64) []Match {
+	query := ix.Vector(text)
+	matches := make([]Match, 0)
+	for _, id := range ix.ids {
+		if id == exclude {
+			continue
+		}
+		if score := Cosine(query, ix.vectors[id]); score >= minScore && score > 0 {
+			matches = append(matches, Match{ID: id, Score: score})
+		}
+	}
+
+	sortMatches(matches)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Clusters mengelompokkan dokumen korpus yang saling mirip (skor >= minScore), transitif.
+// Hanya kelompok dengan dua dokumen atau lebih yang dikembalikan; ids kosong = seluruh korpus.
+func (ix *Index) Clusters(ids []int, minScore float64) [][]int {
+	if len(ids) == 0 {
+		ids = ix.ids
+	}
+
+	parent := make(map[int]int, len(ids))
+	var find func(int) int
+	find = func(id int) int {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	for _, id := range ids {
+		parent[id] = id
+	}
+
+	for i := 0; i < len(ids); i++ {
+		for j := i + 1; j < len(ids); j++ {
+			if Cosine(ix.vectors[ids[i]], ix.vectors[ids[j]]) >= minScore {
+				parent[find(ids[i])] = find(ids[j])
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for _, id := range ids {
+		root := find(id)
+		groups[root] = append(groups[root], id)
+	}
+
+	clusters := make([][]int, 0)
+	for _, group := range groups {
+		if len(group) > 1 {
+			sort.Ints(group)
+			clusters = append(clusters, group)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
+
+// Score menghitung kemiripan dua dokumen korpus
+func (ix *Index) Score(a, b int) float64 {
+	return Cosine(ix.vectors[a], ix.vectors[b])
+}
+
+// Cosine menghitung cosine similarity dua vektor yang sudah dinormalisasi
+func Cosine(a, b Vector) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot float64
+	for gram, weight := range a {
+		dot += weight * b[gram]
+	}
+	if dot > 1 {
+		return 1
+	}
+	return dot
+}
+
+// Normalize menyederhanakan judul: huruf kecil, tanpa tanda baca dan stopword
+func Normalize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !stopwords[field] {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// weigh mengubah frekuensi n-gram menjadi vektor TF-IDF ternormalisasi
+func (ix *Index) weigh(counts map[string]int) Vector {
+	vector := make(Vector, len(counts))
+	var norm float64
+	for gram, count := range counts {
+		idf, ok := ix.idf[gram]
+		if !ok {
+			idf = ix.unknown
+		}
+		weight := (1 + math.Log(float64(count))) * idf
+		vector[gram] = weight
+		norm += weight * weight
+	}
+
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for gram := range vector {
+		vector[gram] /= norm
+	}
+	return vector
+}
+
+// countGrams menghitung n-gram judul: trigram karakter per kata (tahan imbuhan & salah ketik)
+// ditambah bigram kata (urutan kata)
+func countGrams(text string) map[string]int {
+	tokens := Normalize(text)
+	counts := make(map[string]int)
+	for _, token := range tokens {
+		padded := []rune(" " + token + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts["c:"+string(padded[i:i+3])]++
+		}
+	}
+	for i := 0; i+1 < len(tokens); i++ {
+		counts["w:"+tokens[i]+" "+tokens[i+1]]++
+	}
+	return counts
+}
+
+// sortMatches mengurutkan skor tertinggi dulu, ID kecil dulu jika sama
+func sortMatches(matches []Match) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+}
@@ -0,0 +1,146 @@
+package similarity
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testCorpus: 1 & 3 sama setelah normalisasi, 2 hampir sama dengan 1,
+// 4 & 5 parafrase, 6 & 7 tidak berkaitan
+var testCorpus = []Document{
+	{ID: 1, Text: "Pemanfaatan Limbah Kulit Pisang sebagai Bioplastik Ramah Lingkungan"},
+	{ID: 2, Text: "Pemanfaatan limbah kulit pisang menjadi bioplastik yang ramah lingkungan"},
+	{ID: 3, Text: "PEMANFAATAN LIMBAH KULIT PISANG SEBAGAI BIOPLASTIK RAMAH LINGKUNGAN!"},
+	{ID: 4, Text: "Aplikasi Mobile Pemantau Kualitas Air Tambak Udang Berbasis IoT"},
+	{ID: 5, Text: "Aplikasi Pemantau Kualitas Air Tambak Udang Berbasis Internet of Things"},
+	{ID: 6, Text: "Pelatihan Kewirausahaan Batik Tulis bagi Ibu Rumah Tangga"},
+	{ID: 7, Text: "Deteksi Dini Penyakit Daun Padi Menggunakan Citra Digital"},
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Pemanfaatan Limbah sebagai Bioplastik", []string{"pemanfaatan", "limbah", "bioplastik"}},
+		{"IoT-Based Monitoring of Water", []string{"iot", "based", "monitoring", "water"}},
+		{"Batik (Tulis), 2026!", []string{"batik", "tulis", "2026"}},
+		{"dan yang untuk", []string{}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Normalize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	ix := NewIndex(testCorpus)
+
+	tests := []struct {
+		name     string
+		a, b     int
+		min, max float64
+	}{
+		{"dokumen yang sama", 1, 1, 0.999, 1},
+		{"beda huruf besar & tanda baca", 1, 3, 0.999, 1},
+		{"beda stopword & satu kata", 1, 2, 0.8, 0.95},
+		{"parafrase", 4, 5, 0.5, 0.8},
+		{"topik berbeda", 1, 4, 0, 0.2},
+		{"topik berbeda lain", 6, 7, 0, 0.2},
+		{"id tidak ada", 1, 99, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := ix.Score(tt.a, tt.b)
+			if score < tt.min || score > tt.max {
+				t.Errorf("Score(%d, %d) = %.4f, want between %.2f and %.2f", tt.a, tt.b, score, tt.min, tt.max)
+			}
+			if reverse := ix.Score(tt.b, tt.a); !almostEqual(score, reverse) {
+				t.Errorf("Score(%d, %d) = %.6f, Score(%d, %d) = %.6f; want symmetric", tt.a, tt.b, score, tt.b, tt.a, reverse)
+			}
+		})
+	}
+}
+
+func TestTopMatches(t *testing.T) {
+	ix := NewIndex(testCorpus)
+
+	tests := []struct {
+		name     string
+		text     string
+		exclude  int
+		limit    int
+		minScore float64
+		want     []int
+	}{
+		{"judul sendiri dikecualikan", testCorpus[0].Text, 1, 2, 0.5, []int{3, 2}},
+		{"batas jumlah", testCorpus[0].Text, 1, 1, 0.5, []int{3}},
+		{"skor minimal", testCorpus[3].Text, 0, 0, 0.5, []int{4, 5}},
+		{"tanpa batas jumlah", testCorpus[3].Text, 4, 0, 0.5, []int{5}},
+		{"teks di luar korpus", "Pemantauan Air Tambak Udang berbasis Internet of Things", 0, 0, 0.3, []int{5, 4}},
+		{"tidak ada yang mirip", "Sistem Informasi Perpustakaan Sekolah", 0, 0, 0.2, []int{}},
+		{"teks kosong", "", 0, 0, 0, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := ix.TopMatches(tt.text, tt.exclude, tt.limit, tt.minScore)
+			got := make([]int, 0, len(matches))
+			for i, match := range matches {
+				got = append(got, match.ID)
+				if i > 0 && match.Score > matches[i-1].Score {
+					t.Errorf("TopMatches() not sorted by score: %+v", matches)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TopMatches() = %v, want %v (%+v)", got, tt.want, matches)
+			}
+		})
+	}
+}
+
+func TestClusters(t *testing.T) {
+	ix := NewIndex(testCorpus)
+
+	tests := []struct {
+		name     string
+		ids      []int
+		minScore float64
+		want     [][]int
+	}{
+		{"seluruh korpus", nil, 0.5, [][]int{{1, 2, 3}, {4, 5}}},
+		{"skor minimal tinggi", nil, 0.75, [][]int{{1, 2, 3}}},
+		{"hanya judul identik", nil, 0.95, [][]int{{1, 3}}},
+		{"sebagian korpus", []int{2, 4, 5, 6}, 0.5, [][]int{{4, 5}}},
+		{"tidak ada kelompok", []int{1, 4, 6, 7}, 0.5, [][]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ix.Clusters(tt.ids, tt.minScore); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Clusters(%v, %.2f) = %v, want %v", tt.ids, tt.minScore, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCosineEmpty(t *testing.T) {
+	ix := NewIndex(nil)
+	if score := Cosine(ix.Vector(""), ix.Vector("bioplastik")); score != 0 {
+		t.Errorf("Cosine(empty, vector) = %v, want 0", score)
+	}
+	if matches := ix.TopMatches("bioplastik", 0, 0, 0); len(matches) != 0 {
+		t.Errorf("TopMatches() on empty index = %v, want none", matches)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	diff := a - b
+	return diff < 1e-9 && diff > -1e-9
+}
@@ -0,0 +1,229 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format file yang didukung
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat dikembalikan untuk file selain .csv / .xlsx
+var ErrUnsupportedFormat = errors.New("format file harus .csv atau .xlsx")
+
+// utf8BOM ditambahkan Excel di awal file CSV
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// FormatOf menentukan format dari ekstensi nama file
+func FormatOf(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ReadRows membaca seluruh baris file .csv atau sheet pertama file .xlsx.
+// Sel di-trim; baris yang seluruh selnya kosong tetap dikembalikan agar nomor baris sesuai file.
+func ReadRows(filename string, r io.Reader) ([][]string, error) {
+	format, err := FormatOf(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	if format == FormatXLSX {
+		rows, err = readXLSX(r)
+	} else {
+		rows, err = readCSV(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		for j := range rows[i] {
+			rows[i][j] = strings.TrimSpace(rows[i][j])
+		}
+	}
+	return rows, nil
+}
+
+// IsEmptyRow true jika semua sel kosong
+func IsEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if cell != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// readCSV membaca CSV dengan pemisah koma atau titik koma (Excel berbahasa Indonesia)
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := bufio.NewReader(r)
+	if head, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(head, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+
+	firstLine, _ := reader.Peek(4096)
+	if i := bytes.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		csvReader.Comma = ';'
+	}
+	return csvReader.ReadAll()
+}
+
+// readXLSX membaca sheet pertama
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("file xlsx tidak memiliki sheet")
+	}
+	return file.GetRows(sheets[0])
+}
+
+// ContentType mengembalikan MIME type untuk format file
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer menulis baris ke file .csv atau sheet .xlsx. Close wajib dipanggil untuk menyelesaikan file.
+type Writer interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewWriter membuat Writer untuk format csv atau xlsx
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		// BOM agar Excel membaca UTF-8 dengan benar
+		if _, err := w.Write(utf8BOM); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		file := excelize.NewFile()
+		stream, err := file.NewStreamWriter(file.GetSheetName(0))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &xlsxWriter{out: w, file: file, stream: stream}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// csvWriter menulis baris langsung ke output
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (cw *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatCell(value)
+		if _, isText := value.(string); isText {
+			record[i] = escapeFormula(record[i])
+		}
+	}
+	if err := cw.writer.Write(record); err != nil {
+		return err
+	}
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// xlsxWriter memakai stream writer excelize (baris tidak ditahan di memori sebagai sel),
+// file zip baru ditulis ke output saat Close
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (xw *xlsxWriter) WriteRow(values []interface{}) error {
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		if t, ok := value.(*time.Time); ok {
+			values[i] = formatCell(t)
+		}
+	}
+	return xw.stream.SetRow(cell, values)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := xw.file.WriteTo(xw.out)
+	return err
+}
+
+// formatCell mengubah nilai sel menjadi teks
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(value)
+}
+
+// escapeFormula mencegah teks isian pengguna (mis. judul "=HYPERLINK(...)") dieksekusi
+// sebagai formula saat CSV dibuka di Excel
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
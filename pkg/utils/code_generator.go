@@ -1,73 +1,185 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"rires-be/internal/models"
 	"rires-be/pkg/database"
-	"time"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
-// GenerateKodePengajuan generates unique code for pengajuan
-// Format: PKM-{KODE_KATEGORI}-{TAHUN}-{SEQUENCE}
-// Example: PKM-K-2026-001, PKM-RE-2026-002
-func GenerateKodePengajuan(kategori *models.KategoriPKM, tahun int) (string, error) {
-	if kategori == nil {
-		return "", fmt.Errorf("kategori is required")
+// DefaultKodeTemplate is the kode pengajuan format used when no valid template is configured
+// Example: PKM-K-2026-001, PKM-RE-2026-002, PKM-K-2026-2-001 (periode kedua di 2026)
+const DefaultKodeTemplate = "PKM-{KODE}-{TAHUN}-{SEQ:3}"
+
+// MaxKodePengajuanLength follows kolom db_pengajuan_pkm.kode_pengajuan varchar(50)
+const MaxKodePengajuanLength = 50
+
+// kodePlaceholder matches {KODE}, {TAHUN}, {SEQ} and {SEQ:n}
+var kodePlaceholder = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// kodeKategoriPattern: kode singkat kategori, huruf besar/angka
+var kodeKategoriPattern = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+
+// ValidateKodeTemplate checks a kode pengajuan template.
+// {KODE} dan {TAHUN} wajib ada (kode unik antar kategori & periode), {SEQ} atau {SEQ:n} tepat satu kali.
+func ValidateKodeTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("template kode tidak boleh kosong")
 	}
 
-	if tahun == 0 {
-		tahun = time.Now().Year()
+	counts := make(map[string]int)
+	for _, match := range kodePlaceholder.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "KODE", "TAHUN":
+			if match[2] != "" {
+				return fmt.Errorf("placeholder {%s} tidak memakai jumlah digit", match[1])
+			}
+		case "SEQ":
+			if match[2] != "" {
+				if width, _ := strconv.Atoi(match[2]); width < 1 || width > 6 {
+					return errors.New("jumlah digit {SEQ:n} harus 1-6")
+				}
+			}
+		default:
+			return fmt.Errorf("placeholder {%s} tidak dikenal (gunakan {KODE}, {TAHUN}, {SEQ:n})", match[1])
+		}
+		counts[match[1]]++
 	}
 
-	// Extract kategori code (e.g., "PKM-K" -> "K", "PKM-RE" -> "RE")
-	kodeKategori := extractKodeKategori(kategori.NamaKategori)
+	if counts["KODE"] == 0 || counts["TAHUN"] == 0 {
+		return errors.New("template kode wajib memuat {KODE} dan {TAHUN}")
+	}
+	if counts["SEQ"] != 1 {
+		return errors.New("template kode wajib memuat tepat satu {SEQ} atau {SEQ:n}")
+	}
+	if strings.ContainsAny(kodePlaceholder.ReplaceAllString(template, ""), "{}") {
+		return errors.New("template kode berisi kurung kurawal yang tidak valid")
+	}
 
-	// Get last sequence number for this kategori and tahun
-	sequence, err := getLastSequence(kategori.ID, tahun)
-	if err != nil {
-		return "", err
+	// Kode terpanjang yang mungkin: kode kategori 10 karakter, tahun periode ke-n, nomor urut 6 digit
+	if sample := FormatKodePengajuan(template, "XXXXXXXXXX", "2026-10", 999999); len(sample) > MaxKodePengajuanLength {
+		return fmt.Errorf("template kode terlalu panjang (maksimal %d karakter)", MaxKodePengajuanLength)
 	}
+	return nil
+}
 
-	// Increment sequence
-	sequence++
+// FormatKodePengajuan fills a template with kode kategori, tahun periode and sequence
+func FormatKodePengajuan(template, kodeKategori, tahun string, sequence int) string {
+	return kodePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		match := kodePlaceholder.FindStringSubmatch(placeholder)
+		switch match[1] {
+		case "KODE":
+			return kodeKategori
+		case "TAHUN":
+			return tahun
+		case "SEQ":
+			width, _ := strconv.Atoi(match[2])
+			return fmt.Sprintf("%0*d", width, sequence)
+		}
+		return placeholder
+	})
+}
 
-	// Generate code: PKM-K-2026-001
-	code := fmt.Sprintf("PKM-%s-%d-%03d", kodeKategori, tahun, sequence)
+// ParseKodeSequence extracts the sequence from a kode pengajuan made with the same
+// template, kode kategori and tahun. ok = false jika kode tidak sesuai format.
+func ParseKodeSequence(template, kodeKategori, tahun, kodePengajuan string) (int, bool) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range kodePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		switch template[loc[2]:loc[3]] {
+		case "KODE":
+			pattern.WriteString(regexp.QuoteMeta(kodeKategori))
+		case "TAHUN":
+			pattern.WriteString(regexp.QuoteMeta(tahun))
+		case "SEQ":
+			if loc[4] >= 0 {
+				pattern.WriteString(`(\d{` + template[loc[4]:loc[5]] + `,})`)
+			} else {
+				pattern.WriteString(`(\d+)`)
+			}
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
 
-	return code, nil
+	match := regexp.MustCompile(pattern.String()).FindStringSubmatch(kodePengajuan)
+	if match == nil {
+		return 0, false
+	}
+	sequence, err := strconv.Atoi(match[1])
+	if err != nil || sequence < 1 {
+		return 0, false
+	}
+	return sequence, true
+}
+
+// NormalizeKodeKategori uppercases a kode kategori and checks its format
+func NormalizeKodeKategori(kode string) (string, error) {
+	kode = strings.ToUpper(strings.TrimSpace(kode))
+	if !kodeKategoriPattern.MatchString(kode) {
+		return "", errors.New("kode kategori harus 1-10 huruf besar/angka tanpa spasi (mis. RE, KC)")
+	}
+	return kode, nil
 }
 
-// extractKodeKategori extracts the kategori code from kategori name
+// DeriveKodeKategori derives a short kode from nama kategori, dipakai selama admin belum mengisi kode
 // PKM-K -> K
-// PKM-RE -> RE
 // PKM-RSH -> RSH
-func extractKodeKategori(namaKategori string) string {
-	// Remove "PKM-" prefix
-	if len(namaKategori) > 4 && namaKategori[:4] == "PKM-" {
-		return namaKategori[4:]
+// PKM RE (Riset Eksakta) -> RE
+func DeriveKodeKategori(namaKategori string) string {
+	nama := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(namaKategori)), "PKM")
+	fields := strings.FieldsFunc(nama, func(r rune) bool {
+		return r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r))
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	kode := fields[0]
+	if len(kode) > 10 {
+		kode = kode[:10]
 	}
-	return namaKategori
+	return kode
 }
 
-// getLastSequence gets the last sequence number for given kategori and tahun
-func getLastSequence(kategoriID, tahun int) (int, error) {
-	var lastPengajuan models.Pengajuan
-
-	err := database.DB.Where("id_kategori = ? AND tahun = ? AND hapus = ?", kategoriID, tahun, 0).
-		Order("id DESC").
-		First(&lastPengajuan).Error
-
+// KodeTahunPeriode proposes the tahun part of kode pengajuan for a periode that has none stored yet.
+// Periode pertama di suatu tahun memakai tahun saja (kode lama tetap sama),
+// periode berikutnya di tahun yang sama diberi urutan: 2026-2, 2026-3, ...
+// Hasilnya bergantung pada periode yang ada saat ini, jadi hanya dipakai sekali lalu disimpan
+// (db_periode_kode, lihat KodePengajuanService).
+func KodeTahunPeriode(periode *models.TglSetting) (string, error) {
+	tahun := periode.Tahun()
+
+	var sebelumnya int64
+	err := database.DB.Model(&models.TglSetting{}).
+		Where("hapus = ? AND YEAR(tgl_daftar_awal) = ?", 0, tahun).
+		Where("tgl_daftar_awal < ? OR (tgl_daftar_awal = ? AND id < ?)", periode.TglDaftarAwal, periode.TglDaftarAwal, periode.ID).
+		Count(&sebelumnya).Error
 	if err != nil {
-		// No records found, start from 0
-		return 0, nil
+		return "", err
+	}
+
+	if sebelumnya == 0 {
+		return strconv.Itoa(tahun), nil
 	}
+	return fmt.Sprintf("%d-%d", tahun, sebelumnya+1), nil
+}
 
-	// Extract sequence from kode_pengajuan (last 3 digits)
-	// PKM-K-2026-001 -> 001
-	var sequence int
-	fmt.Sscanf(lastPengajuan.KodePengajuan[len(lastPengajuan.KodePengajuan)-3:], "%d", &sequence)
+// legacyKodePrefix: generator lama menempelkan nama kategori utuh setelah "PKM-",
+// mis. "PKM-PKM RE (Riset Eksakata)-2026-004" atau "PKM-PKM-K-2026-001"
+var legacyKodePrefix = regexp.MustCompile(`^PKM-PKM[- ]`)
 
-	return sequence, nil
+// IsLegacyKodePengajuan mengenali kode buatan generator lama: awalan PKM- ganda,
+// atau spasi/kurung pada bagian kategori. Kode dari template lain yang valid tidak termasuk.
+func IsLegacyKodePengajuan(kodePengajuan string) bool {
+	return legacyKodePrefix.MatchString(strings.ToUpper(kodePengajuan)) ||
+		strings.ContainsAny(kodePengajuan, " ()")
 }
 
 // ValidateKodePengajuan checks if kode_pengajuan is unique
@@ -78,4 +190,4 @@ func ValidateKodePengajuan(kodePengajuan string) bool {
 		Count(&count)
 
 	return count == 0 // True if unique (count = 0)
-}
\ No newline at end of file
+}
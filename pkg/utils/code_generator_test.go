@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKodeTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{"default", DefaultKodeTemplate, false},
+		{"seq tanpa digit", "{KODE}/{TAHUN}/{SEQ}", false},
+		{"urutan bebas", "{SEQ:4}.{TAHUN}.PKM-{KODE}", false},
+		{"kosong", "   ", true},
+		{"tanpa kode", "PKM-{TAHUN}-{SEQ:3}", true},
+		{"tanpa tahun", "PKM-{KODE}-{SEQ:3}", true},
+		{"tanpa seq", "PKM-{KODE}-{TAHUN}", true},
+		{"seq dua kali", "PKM-{KODE}-{TAHUN}-{SEQ}-{SEQ:3}", true},
+		{"digit seq 0", "PKM-{KODE}-{TAHUN}-{SEQ:0}", true},
+		{"digit seq 7", "PKM-{KODE}-{TAHUN}-{SEQ:7}", true},
+		{"digit pada kode", "PKM-{KODE:2}-{TAHUN}-{SEQ:3}", true},
+		{"placeholder tidak dikenal", "PKM-{KODE}-{TAHUN}-{BULAN}-{SEQ:3}", true},
+		{"kurung kurawal liar", "PKM-{KODE}-{TAHUN}-{SEQ:3}}", true},
+		{"huruf kecil", "PKM-{kode}-{TAHUN}-{SEQ:3}", true},
+		{"terlalu panjang", "PKM-{KODE}-{TAHUN}-{SEQ}-" + strings.Repeat("X", 30), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKodeTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateKodeTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatKodePengajuan(t *testing.T) {
+	tests := []struct {
+		template string
+		kode     string
+		tahun    string
+		sequence int
+		want     string
+	}{
+		{DefaultKodeTemplate, "K", "2026", 1, "PKM-K-2026-001"},
+		{DefaultKodeTemplate, "RE", "2026-2", 12, "PKM-RE-2026-2-012"},
+		{DefaultKodeTemplate, "KC", "2026", 1234, "PKM-KC-2026-1234"},
+		{"{KODE}/{TAHUN}/{SEQ}", "RSH", "2027", 7, "RSH/2027/7"},
+		{"{SEQ:5}.{TAHUN}.{KODE}", "PI", "2026", 42, "00042.2026.PI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatKodePengajuan(tt.template, tt.kode, tt.tahun, tt.sequence); got != tt.want {
+				t.Errorf("FormatKodePengajuan() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKodeSequenceRoundTrip(t *testing.T) {
+	templates := []string{
+		DefaultKodeTemplate,
+		"{KODE}/{TAHUN}/{SEQ}",
+		"{SEQ:5}.{TAHUN}.{KODE}",
+		"UNIV-{TAHUN}-{KODE}-{SEQ:2}",
+	}
+	sequences := []int{1, 9, 10, 99, 100, 999, 1000, 123456}
+
+	for _, template := range templates {
+		for _, tahun := range []string{"2026", "2026-2"} {
+			for _, sequence := range sequences {
+				kode := FormatKodePengajuan(template, "RE", tahun, sequence)
+				got, ok := ParseKodeSequence(template, "RE", tahun, kode)
+				if !ok || got != sequence {
+					t.Errorf("ParseKodeSequence(%q, %q) = %d, %v; want %d, true", template, kode, got, ok, sequence)
+				}
+			}
+		}
+	}
+}
+
+func TestParseKodeSequenceMismatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		kode          string
+		tahun         string
+		kodePengajuan string
+	}{
+		{"kategori lain", DefaultKodeTemplate, "K", "2026", "PKM-KC-2026-001"},
+		{"tahun lain", DefaultKodeTemplate, "K", "2026", "PKM-K-2025-001"},
+		{"periode kedua di tahun yang sama", DefaultKodeTemplate, "K", "2026", "PKM-K-2026-2-001"},
+		{"digit kurang", DefaultKodeTemplate, "K", "2026", "PKM-K-2026-01"},
+		{"nomor urut nol", DefaultKodeTemplate, "K", "2026", "PKM-K-2026-000"},
+		{"template lain", "{KODE}/{TAHUN}/{SEQ}", "K", "2026", "PKM-K-2026-001"},
+		{"format lama", DefaultKodeTemplate, "RE", "2026", "PKM-PKM RE (Riset Eksakta)-2026-004"},
+		{"karakter meta regex pada kode", DefaultKodeTemplate, "K", "2026", "PKM-K-2026X001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sequence, ok := ParseKodeSequence(tt.template, tt.kode, tt.tahun, tt.kodePengajuan); ok {
+				t.Errorf("ParseKodeSequence(%q) = %d, true; want false", tt.kodePengajuan, sequence)
+			}
+		})
+	}
+}
+
+func TestNormalizeKodeKategori(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{" re ", "RE", false},
+		{"kc", "KC", false},
+		{"GFT2", "GFT2", false},
+		{"", "", true},
+		{"R E", "", true},
+		{"PKM-K", "", true},
+		{"ABCDEFGHIJK", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeKodeKategori(tt.input)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("NormalizeKodeKategori(%q) = %q, %v; want %q, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeriveKodeKategori(t *testing.T) {
+	tests := []struct {
+		nama string
+		want string
+	}{
+		{"PKM-K", "K"},
+		{"PKM-RSH", "RSH"},
+		{"PKM RE (Riset Eksakta)", "RE"},
+		{"pkm-kc", "KC"},
+		{"Karsa Cipta", "KARSA"},
+		{"PKM-ABCDEFGHIJKL", "ABCDEFGHIJ"},
+		{"PKM", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := DeriveKodeKategori(tt.nama); got != tt.want {
+				t.Errorf("DeriveKodeKategori(%q) = %q, want %q", tt.nama, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLegacyKodePengajuan(t *testing.T) {
+	tests := []struct {
+		kode string
+		want bool
+	}{
+		{"PKM-PKM RE (Riset Eksakata)-2026-004", true},
+		{"PKM-PKM-K-2026-001", true},
+		{"pkm-pkm-k-2026-001", true},
+		{"PKM-K 2026-001", true},
+		{"PKM-K-2026-001", false},
+		{"PKM-KC-2026-2-001", false},
+		{"RSH/2027/7", false},
+		{"00042.2026.PI", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kode, func(t *testing.T) {
+			if got := IsLegacyKodePengajuan(tt.kode); got != tt.want {
+				t.Errorf("IsLegacyKodePengajuan(%q) = %v, want %v", tt.kode, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"rires-be/internal/models"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -60,19 +62,41 @@ func GetCurrentUserData(c *fiber.Ctx) map[string]string {
 	return userData.(map[string]string)
 }
 
-// IsAdmin memeriksa apakah user adalah admin
+// GetActiveRole mengambil role aktif dari token (admin, mahasiswa, pegawai).
+// Token lama tanpa active_role memakai user_type.
+func GetActiveRole(c *fiber.Ctx) string {
+	if claims := GetCurrentClaims(c); claims != nil && claims.ActiveRole != "" {
+		return claims.ActiveRole
+	}
+	return GetCurrentUserType(c)
+}
+
+// IsAdmin memeriksa apakah role aktif user adalah admin
 func IsAdmin(c *fiber.Ctx) bool {
-	return GetCurrentUserType(c) == "admin"
+	return GetActiveRole(c) == "admin"
 }
 
-// IsMahasiswa memeriksa apakah user adalah mahasiswa
+// IsMahasiswa memeriksa apakah role aktif user adalah mahasiswa
 func IsMahasiswa(c *fiber.Ctx) bool {
-	return GetCurrentUserType(c) == "mahasiswa"
+	return GetActiveRole(c) == "mahasiswa"
 }
 
-// IsReviewer memeriksa apakah user adalah reviewer (pegawai)
+// IsReviewer memeriksa apakah role aktif user adalah reviewer (pegawai)
 func IsReviewer(c *fiber.Ctx) bool {
-	return GetCurrentUserType(c) == "pegawai"
+	return GetActiveRole(c) == "pegawai"
+}
+
+// IsPembimbingOnly memeriksa apakah token pegawai hanya dosen pembimbing (bukan reviewer).
+// Token dari NewPembimbingClaims memakai id_user 0 dan user_data pembimbing=1.
+func IsPembimbingOnly(c *fiber.Ctx) bool {
+	if GetActiveRole(c) != "pegawai" {
+		return false
+	}
+	claims := GetCurrentClaims(c)
+	if claims == nil {
+		return GetCurrentUserID(c) == 0
+	}
+	return claims.UserID == 0 || claims.UserData["pembimbing"] == "1"
 }
 
 // GetCurrentUserLevel mengambil id_user_level dari context
@@ -92,3 +116,40 @@ func GetCurrentUserLevel(c *fiber.Ctx) int {
 		return 0
 	}
 }
+
+// GetCurrentClaims mengambil JWT claims lengkap dari context
+func GetCurrentClaims(c *fiber.Ctx) *JWTClaims {
+	claims, ok := c.Locals("claims").(*JWTClaims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
+
+// GetImpersonator mengambil admin yang sedang impersonate (nil jika bukan token impersonation)
+func GetImpersonator(c *fiber.Ctx) *Impersonator {
+	impersonator, ok := c.Locals("impersonator").(*Impersonator)
+	if !ok {
+		return nil
+	}
+	return impersonator
+}
+
+// IsImpersonating memeriksa apakah request memakai token impersonation
+func IsImpersonating(c *fiber.Ctx) bool {
+	return GetImpersonator(c) != nil
+}
+
+// GetAPIKey mengambil API key pemanggil (nil jika request memakai JWT)
+func GetAPIKey(c *fiber.Ctx) *models.APIKey {
+	key, ok := c.Locals("api_key").(*models.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+// IsAPIKey memeriksa apakah request diautentikasi dengan X-API-Key
+func IsAPIKey(c *fiber.Ctx) bool {
+	return GetAPIKey(c) != nil
+}
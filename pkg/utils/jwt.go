@@ -2,100 +2,138 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"rires-be/config"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // JWTClaims adalah struktur claims untuk JWT
 type JWTClaims struct {
-	UserID      uint              `json:"id_user"`
-	Email       string            `json:"email"`
-	Username    string            `json:"username"`
-	UserType    string            `json:"user_type"`     // admin, mahasiswa, pegawai
-	IDUserLevel int               `json:"id_user_level"` // 1=superadmin, 2=admin, 3=mahasiswa, 4=reviewer
-	UserData    map[string]string `json:"user_data"`     // Additional user data
+	UserID       uint              `json:"id_user"`
+	Email        string            `json:"email"`
+	Username     string            `json:"username"`
+	UserType     string            `json:"user_type"`              // admin, mahasiswa, pegawai
+	ActiveRole   string            `json:"active_role,omitempty"`  // role aktif (sama dengan user_type), dipilih lewat switch-role
+	IDUserLevel  int               `json:"id_user_level"`          // 1=superadmin, 2=admin, 3=mahasiswa, 4=reviewer
+	UserData     map[string]string `json:"user_data"`              // Additional user data
+	Impersonator *Impersonator     `json:"impersonator,omitempty"` // Terisi jika token hasil impersonation admin
+	SessionID    string            `json:"sid,omitempty"`          // id db_user_session, kosong untuk token impersonation
 	jwt.RegisteredClaims
 }
 
-// GenerateToken membuat JWT token baru
-func GenerateToken(userID uint, email string) (string, error) {
-	// Parse JWT expired hours dari config
-	expiredHours, err := strconv.Atoi(config.AppConfig.JWTExpiredHours)
-	if err != nil {
-		expiredHours = 24 // Default 24 jam
-	}
+// Impersonator adalah admin yang sedang "view as user"
+type Impersonator struct {
+	UserID   uint   `json:"id_user"`
+	Username string `json:"username"`
+	IDLog    int    `json:"id_log"` // id db_impersonation_log
+}
 
-	// Buat claims
-	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiredHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+// AccessTokenTTL mengembalikan umur access token dari config
+func AccessTokenTTL() time.Duration {
+	minutes, err := strconv.Atoi(config.AppConfig.JWTAccessExpiredMinutes)
+	if err != nil || minutes <= 0 {
+		minutes = 15 // Default 15 menit
 	}
+	return time.Duration(minutes) * time.Minute
+}
 
-	// Buat token dengan claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign token dengan secret key
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
-	if err != nil {
-		return "", err
+// RefreshTokenTTL mengembalikan umur refresh token dari config
+func RefreshTokenTTL() time.Duration {
+	hours, err := strconv.Atoi(config.AppConfig.JWTRefreshExpiredHours)
+	if err != nil || hours <= 0 {
+		hours = 168 // Default 7 hari
 	}
-
-	return tokenString, nil
+	return time.Duration(hours) * time.Hour
 }
 
-// GenerateTokenWithClaims membuat JWT token dengan custom claims
-func GenerateTokenWithClaims(userID uint, username, email, userType string, idUserLevel int, userData map[string]string) (string, error) {
-	// Parse JWT expired hours dari config
-	expiredHours, err := strconv.Atoi(config.AppConfig.JWTExpiredHours)
-	if err != nil {
-		expiredHours = 24 // Default 24 jam
+// TokenSubject membentuk subject (sub) yang stabil untuk sebuah akun.
+// Admin dan pegawai memakai ID lokal, mahasiswa memakai NIM.
+// Pegawai tanpa ID lokal (dosen pembimbing yang bukan reviewer) memakai NIP.
+func TokenSubject(userType string, userID uint, username string) string {
+	if userType == "mahasiswa" || userID == 0 {
+		return fmt.Sprintf("%s:%s", userType, username)
 	}
+	return fmt.Sprintf("%s:%d", userType, userID)
+}
 
-	// Buat claims
-	claims := JWTClaims{
+// NewClaims menyusun claims access token tanpa registered claims (diisi saat sign)
+func NewClaims(userID uint, username, email, userType string, idUserLevel int, userData map[string]string) *JWTClaims {
+	return &JWTClaims{
 		UserID:      userID,
 		Email:       email,
 		Username:    username,
 		UserType:    userType,
+		ActiveRole:  userType,
 		IDUserLevel: idUserLevel,
 		UserData:    userData,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiredHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
 	}
+}
 
-	// Buat token dengan claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// SignAccessToken mengisi jti, sub, iat dan exp lalu menandatangani access token
+func SignAccessToken(claims *JWTClaims) (string, error) {
+	return SignAccessTokenWithTTL(claims, AccessTokenTTL())
+}
 
-	// Sign token dengan secret key
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
-	if err != nil {
-		return "", err
+// SignAccessTokenWithTTL sama dengan SignAccessToken dengan umur token custom
+func SignAccessTokenWithTTL(claims *JWTClaims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   TokenSubject(claims.UserType, claims.UserID, claims.Username),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
 	}
 
-	return tokenString, nil
+	keySet := currentJWTKeys()
+	if keySet == nil {
+		return "", errors.New("JWT signing key is not loaded")
+	}
+
+	// Buat token dengan claims, kid dipakai verifier untuk memilih public key
+	token := jwt.NewWithClaims(keySet.signingMethod, claims)
+	token.Header["kid"] = keySet.signingKID
+
+	// Sign token dengan private key aktif
+	return token.SignedString(keySet.private)
+}
+
+// GenerateToken membuat JWT token baru
+func GenerateToken(userID uint, email string) (string, error) {
+	return SignAccessToken(&JWTClaims{UserID: userID, Email: email})
+}
+
+// GenerateTokenWithClaims membuat JWT token dengan custom claims
+func GenerateTokenWithClaims(userID uint, username, email, userType string, idUserLevel int, userData map[string]string) (string, error) {
+	return SignAccessToken(NewClaims(userID, username, email, userType, idUserLevel, userData))
 }
 
 // ValidateToken memvalidasi JWT token dan mengembalikan claims
 func ValidateToken(tokenString string) (*JWTClaims, error) {
+	keySet := currentJWTKeys()
+	if keySet == nil {
+		return nil, errors.New("JWT verification keys are not loaded")
+	}
+
 	// Parse token
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validasi signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// Pilih public key berdasarkan kid
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keySet.verify[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+
+		// Validasi signing method sesuai key (mencegah algorithm confusion)
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("invalid signing method")
 		}
-		return []byte(config.AppConfig.JWTSecret), nil
-	})
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"rires-be/config"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtVerifyKey adalah public key yang diterima untuk verifikasi token
+type jwtVerifyKey struct {
+	kid    string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// jwtKeySet menyimpan key signing aktif dan semua key verifikasi (untuk rotasi)
+type jwtKeySet struct {
+	signingKID    string
+	signingMethod jwt.SigningMethod
+	private       crypto.Signer
+	verify        map[string]jwtVerifyKey
+	order         []string // urutan kid untuk JWKS
+}
+
+// JWK adalah satu public key dalam format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // Ed25519
+	X   string `json:"x,omitempty"`   // Ed25519 public key
+}
+
+// JWKSet adalah isi endpoint /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	jwtKeys   *jwtKeySet
+)
+
+// LoadJWTKeys membaca signing key dan verification key dari config.
+// Dipanggil saat startup, aplikasi tidak boleh jalan jika gagal.
+func LoadJWTKeys() error {
+	private, err := readPrivateKey(config.AppConfig.JWTPrivateKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load JWT signing key: %w", err)
+	}
+
+	method, err := signingMethodFor(private.Public())
+	if err != nil {
+		return err
+	}
+
+	keySet := &jwtKeySet{
+		signingKID:    config.AppConfig.JWTKeyID,
+		signingMethod: method,
+		private:       private,
+		verify:        map[string]jwtVerifyKey{},
+	}
+	keySet.add(config.AppConfig.JWTKeyID, method, private.Public())
+
+	// Public key lama yang masih berlaku selama masa rotasi
+	for _, entry := range strings.Split(config.AppConfig.JWTVerifyKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, path, ok := strings.Cut(entry, ":")
+		if !ok || kid == "" || path == "" {
+			return fmt.Errorf("invalid JWT_VERIFY_KEYS entry %q, expected kid:path", entry)
+		}
+		if _, exists := keySet.verify[kid]; exists {
+			return fmt.Errorf("duplicate JWT key id %q", kid)
+		}
+
+		public, err := readPublicKey(path)
+		if err != nil {
+			return fmt.Errorf("failed to load JWT verify key %q: %w", kid, err)
+		}
+		verifyMethod, err := signingMethodFor(public)
+		if err != nil {
+			return err
+		}
+		keySet.add(kid, verifyMethod, public)
+	}
+
+	jwtKeysMu.Lock()
+	jwtKeys = keySet
+	jwtKeysMu.Unlock()
+
+	return nil
+}
+
+// JWKS mengembalikan semua public key aktif untuk diverifikasi service lain
+func JWKS() JWKSet {
+	keySet := currentJWTKeys()
+	result := JWKSet{Keys: []JWK{}}
+	if keySet == nil {
+		return result
+	}
+
+	for _, kid := range keySet.order {
+		key := keySet.verify[kid]
+		jwk := JWK{Use: "sig", Alg: key.method.Alg(), Kid: kid}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		result.Keys = append(result.Keys, jwk)
+	}
+	return result
+}
+
+// add mendaftarkan public key untuk verifikasi
+func (k *jwtKeySet) add(kid string, method jwt.SigningMethod, public crypto.PublicKey) {
+	k.verify[kid] = jwtVerifyKey{kid: kid, method: method, public: public}
+	k.order = append(k.order, kid)
+}
+
+// currentJWTKeys mengembalikan key set yang sedang aktif
+func currentJWTKeys() *jwtKeySet {
+	jwtKeysMu.RLock()
+	defer jwtKeysMu.RUnlock()
+	return jwtKeys
+}
+
+// signingMethodFor menentukan algoritma dari tipe key: RSA -> RS256, Ed25519 -> EdDSA
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("RSA JWT key must be at least 2048 bits")
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT key type %T, use RSA or Ed25519", public)
+	}
+}
+
+// readPrivateKey membaca private key PEM (PKCS#8 atau PKCS#1 untuk RSA)
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("private key must be PKCS#8 or PKCS#1 PEM")
+}
+
+// readPublicKey membaca public key PEM (PKIX), atau mengambil public dari private key
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if signer, err := readPrivateKey(path); err == nil {
+		return signer.Public(), nil
+	}
+	return nil, errors.New("public key must be PKIX PEM")
+}
+
+// readPEM membaca blok PEM pertama dari file
+func readPEM(path string) (*pem.Block, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+	return block, nil
+}
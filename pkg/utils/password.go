@@ -1,6 +1,24 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"rires-be/config"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Jenis hash password yang mungkin tersimpan di db_user.password
+const (
+	PasswordHashBcrypt = "bcrypt"     // $2a$ / $2b$ / $2y$
+	PasswordHashMySQL  = "mysql_sha1" // MySQL PASSWORD(): *HEX(SHA1(SHA1(password)))
+	PasswordHashPlain  = "plain"      // tersimpan apa adanya (legacy)
+)
 
 // HashPassword meng-hash password menggunakan bcrypt
 func HashPassword(password string) (string, error) {
@@ -15,4 +33,84 @@ func HashPassword(password string) (string, error) {
 // VerifyPassword memverifikasi password dengan hash
 func VerifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-}
\ No newline at end of file
+}
+
+// HashMySQLPassword creates MySQL PASSWORD() compatible hash
+// MySQL PASSWORD() uses double SHA1: *UPPERCASE_HEX(SHA1(SHA1(password)))
+// Hanya dipakai untuk memverifikasi password lama, jangan dipakai untuk menyimpan password baru.
+func HashMySQLPassword(password string) string {
+	// First SHA1
+	firstHash := sha1.Sum([]byte(password))
+
+	// Second SHA1
+	secondHash := sha1.Sum(firstHash[:])
+
+	// Convert to uppercase hex with * prefix
+	return "*" + strings.ToUpper(hex.EncodeToString(secondHash[:]))
+}
+
+// DetectPasswordHash menentukan jenis hash dari password yang tersimpan
+func DetectPasswordHash(stored string) string {
+	if len(stored) >= 4 && (stored[0:4] == "$2a$" || stored[0:4] == "$2b$" || stored[0:4] == "$2y$") {
+		return PasswordHashBcrypt
+	}
+	if len(stored) > 0 && stored[0] == '*' {
+		return PasswordHashMySQL
+	}
+	return PasswordHashPlain
+}
+
+// CheckStoredPassword memverifikasi password terhadap hash apapun yang tersimpan.
+// needsRehash bernilai true jika password benar tetapi hash-nya belum bcrypt.
+func CheckStoredPassword(stored, password string) (ok bool, needsRehash bool) {
+	switch DetectPasswordHash(stored) {
+	case PasswordHashBcrypt:
+		return VerifyPassword(stored, password) == nil, false
+	case PasswordHashMySQL:
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(HashMySQLPassword(password))) == 1
+	default:
+		ok = stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+	return ok, ok
+}
+
+// ValidatePasswordPolicy memeriksa password baru terhadap kebijakan password di config
+func ValidatePasswordPolicy(password string) error {
+	minLength, err := strconv.Atoi(config.AppConfig.PasswordMinLength)
+	if err != nil || minLength < 1 {
+		minLength = 8
+	}
+
+	if len(password) < minLength {
+		return fmt.Errorf("password must be at least %d characters", minLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if config.AppConfig.PasswordRequireUpper == "true" && !hasUpper {
+		return fmt.Errorf("password must contain an uppercase letter")
+	}
+	if config.AppConfig.PasswordRequireLower == "true" && !hasLower {
+		return fmt.Errorf("password must contain a lowercase letter")
+	}
+	if config.AppConfig.PasswordRequireDigit == "true" && !hasDigit {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if config.AppConfig.PasswordRequireSymbol == "true" && !hasSymbol {
+		return fmt.Errorf("password must contain a symbol")
+	}
+
+	return nil
+}
@@ -2,9 +2,18 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"rires-be/config"
 	"rires-be/internal/models"
 	"rires-be/pkg/database"
+	"rires-be/pkg/workflow"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // StatusValidator handles status flow validation
@@ -20,48 +29,21 @@ func NewStatusValidator() *StatusValidator {
 // ========================================
 
 // CanSubmitProposal checks if mahasiswa can submit proposal
-// Requirements: status_judul = ACC
+// Rules: workflow.ActionUploadProposal (status_judul = ACC)
 func (v *StatusValidator) CanSubmitProposal(pengajuan *models.Pengajuan) error {
-	if pengajuan == nil {
-		return errors.New("pengajuan is nil")
-	}
-
-	if pengajuan.StatusJudul != "ACC" {
-		return errors.New("judul belum di-ACC. Status saat ini: " + pengajuan.StatusJudul)
-	}
-
-	return nil
+	return workflow.Default().Allows(pengajuan, workflow.ActionUploadProposal)
 }
 
 // CanReviseJudul checks if mahasiswa can revise judul
-// Requirements: status_judul = REVISI
+// Rules: workflow.ActionReviseJudul (status_judul = REVISI)
 func (v *StatusValidator) CanReviseJudul(pengajuan *models.Pengajuan) error {
-	if pengajuan == nil {
-		return errors.New("pengajuan is nil")
-	}
-
-	if pengajuan.StatusJudul != "REVISI" {
-		return errors.New("judul tidak dalam status REVISI. Status saat ini: " + pengajuan.StatusJudul)
-	}
-
-	return nil
+	return workflow.Default().Allows(pengajuan, workflow.ActionReviseJudul)
 }
 
 // CanReviewJudul checks if reviewer can review judul
-// Requirements: status_judul = PENDING or ON_REVIEW
+// Rules: workflow.ActionReviewJudul (status_judul = ON_REVIEW, reviewer assigned)
 func (v *StatusValidator) CanReviewJudul(pengajuan *models.Pengajuan) error {
-	if pengajuan == nil {
-		return errors.New("pengajuan is nil")
-	}
-
-	validStatuses := []string{"PENDING", "ON_REVIEW", "REVISI"}
-	for _, status := range validStatuses {
-		if pengajuan.StatusJudul == status {
-			return nil
-		}
-	}
-
-	return errors.New("judul tidak bisa di-review. Status saat ini: " + pengajuan.StatusJudul)
+	return workflow.Default().Allows(pengajuan, workflow.ActionReviewJudul)
 }
 
 // ========================================
@@ -69,38 +51,15 @@ func (v *StatusValidator) CanReviewJudul(pengajuan *models.Pengajuan) error {
 // ========================================
 
 // CanReviseProposal checks if mahasiswa can revise proposal
-// Requirements: status_proposal = REVISI
+// Rules: workflow.ActionReviseProposal (status_proposal = REVISI)
 func (v *StatusValidator) CanReviseProposal(pengajuan *models.Pengajuan) error {
-	if pengajuan == nil {
-		return errors.New("pengajuan is nil")
-	}
-
-	if pengajuan.StatusProposal != "REVISI" {
-		return errors.New("proposal tidak dalam status REVISI. Status saat ini: " + pengajuan.StatusProposal)
-	}
-
-	return nil
+	return workflow.Default().Allows(pengajuan, workflow.ActionReviseProposal)
 }
 
 // CanReviewProposal checks if reviewer can review proposal
-// Requirements: status_proposal = PENDING or ON_REVIEW, file_proposal exists
+// Rules: workflow.ActionReviewProposal (status_proposal = ON_REVIEW, file_proposal exists)
 func (v *StatusValidator) CanReviewProposal(pengajuan *models.Pengajuan) error {
-	if pengajuan == nil {
-		return errors.New("pengajuan is nil")
-	}
-
-	if pengajuan.FileProposal == "" {
-		return errors.New("proposal belum di-upload")
-	}
-
-	validStatuses := []string{"PENDING", "ON_REVIEW", "REVISI"}
-	for _, status := range validStatuses {
-		if pengajuan.StatusProposal == status {
-			return nil
-		}
-	}
-
-	return errors.New("proposal tidak bisa di-review. Status saat ini: " + pengajuan.StatusProposal)
+	return workflow.Default().Allows(pengajuan, workflow.ActionReviewProposal)
 }
 
 // ========================================
@@ -154,6 +113,163 @@ func (v *StatusValidator) ValidateNoDuplicateNIM(anggota []models.PengajuanAnggo
 	return nil
 }
 
+// PendingParticipation mencatat keikutsertaan yang belum tersimpan di database,
+// mis. baris sebelumnya pada file import. Key = NIM, value = label pengajuan (mis. "baris 3").
+type PendingParticipation struct {
+	Ketua   map[string][]string
+	Anggota map[string][]string
+}
+
+// NewPendingParticipation creates an empty PendingParticipation
+func NewPendingParticipation() *PendingParticipation {
+	return &PendingParticipation{
+		Ketua:   make(map[string][]string),
+		Anggota: make(map[string][]string),
+	}
+}
+
+// Add mencatat satu tim dengan label pengajuannya
+func (p *PendingParticipation) Add(anggota []models.PengajuanAnggota, label string) {
+	for _, member := range anggota {
+		if member.IsKetua == 1 {
+			p.Ketua[member.NIMAnggota] = append(p.Ketua[member.NIMAnggota], label)
+		} else {
+			p.Anggota[member.NIMAnggota] = append(p.Anggota[member.NIMAnggota], label)
+		}
+	}
+}
+
+// ValidateParticipationLimits checks that no member exceeds the ketua/anggota limit
+// across other pengajuan in the same periode (db_pengajuan_periode). excludeID = pengajuan yang sedang
+// diedit (0 untuk baru). Pengajuan yang judulnya ditolak tidak dihitung.
+// idPeriode 0 (pengajuan lama yang tidak tertaut ke periode mana pun) tidak dibatasi.
+func (v *StatusValidator) ValidateParticipationLimits(anggota []models.PengajuanAnggota, idPeriode int, excludeID int) error {
+	return v.ValidateParticipationLimitsWithPending(anggota, idPeriode, excludeID, nil)
+}
+
+// ValidateParticipationLimitsWithPending sama dengan ValidateParticipationLimits,
+// ditambah keikutsertaan yang belum tersimpan (pending boleh nil)
+func (v *StatusValidator) ValidateParticipationLimitsWithPending(anggota []models.PengajuanAnggota, idPeriode int, excludeID int, pending *PendingParticipation) error {
+	return v.checkParticipationLimits(database.DB, anggota, idPeriode, excludeID, pending)
+}
+
+// ValidateParticipationLimitsTx menjalankan ValidateParticipationLimits di dalam transaksi penyimpanan.
+// Baris db_partisipasi_lock tiap NIM di periode ini dikunci sampai commit dan keikutsertaan dibaca
+// dengan locking read, sehingga create/edit/accept/restore yang bersamaan untuk mahasiswa yang sama
+// berjalan bergantian dan tidak bisa sama-sama lolos batas.
+func (v *StatusValidator) ValidateParticipationLimitsTx(tx *gorm.DB, anggota []models.PengajuanAnggota, idPeriode int, excludeID int) error {
+	if !participationLimited(anggota, idPeriode) {
+		return nil
+	}
+
+	// Urutan NIM tetap agar dua transaksi tidak saling menunggu (deadlock)
+	nims := participationNIMs(anggota)
+	sort.Strings(nims)
+
+	now := time.Now()
+	locks := make([]models.PartisipasiLock, len(nims))
+	for i, nim := range nims {
+		locks[i] = models.PartisipasiLock{NIM: nim, IDPeriode: idPeriode, TglInsert: &now}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&locks).Error; err != nil {
+		return fmt.Errorf("failed to lock participation: %w", err)
+	}
+	var locked []models.PartisipasiLock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id_periode = ? AND nim IN ?", idPeriode, nims).
+		Order("nim ASC").
+		Find(&locked).Error; err != nil {
+		return fmt.Errorf("failed to lock participation: %w", err)
+	}
+
+	return v.checkParticipationLimits(tx.Clauses(clause.Locking{Strength: "UPDATE"}), anggota, idPeriode, excludeID, nil)
+}
+
+// checkParticipationLimits menghitung keikutsertaan lewat db (koneksi biasa atau transaksi)
+func (v *StatusValidator) checkParticipationLimits(db *gorm.DB, anggota []models.PengajuanAnggota, idPeriode int, excludeID int, pending *PendingParticipation) error {
+	if !participationLimited(anggota, idPeriode) {
+		return nil
+	}
+	maxKetua := participationLimit(config.AppConfig.MaxKetuaPerPeriode, 1)
+	maxAnggota := participationLimit(config.AppConfig.MaxAnggotaPerPeriode, 2)
+
+	nims := participationNIMs(anggota)
+
+	type participation struct {
+		NIMAnggota    string
+		IsKetua       int
+		KodePengajuan string
+	}
+	var existing []participation
+	if err := db.Table("db_pengajuan_anggota AS a").
+		Select("a.nim_anggota, a.is_ketua, p.kode_pengajuan").
+		Joins("JOIN db_pengajuan_pkm AS p ON p.id = a.id_pengajuan").
+		Joins("JOIN db_pengajuan_periode AS pp ON pp.id_pengajuan = p.id").
+		Where("a.nim_anggota IN ? AND a.hapus = ? AND p.hapus = ?", nims, 0, 0).
+		Where("pp.id_periode = ? AND p.id <> ? AND (p.status_judul IS NULL OR p.status_judul <> ?)", idPeriode, excludeID, workflow.StatusTolak).
+		Order("p.id ASC").
+		Scan(&existing).Error; err != nil {
+		return fmt.Errorf("failed to check participation limits: %w", err)
+	}
+
+	asKetua := make(map[string][]string)
+	asAnggota := make(map[string][]string)
+	for _, row := range existing {
+		if row.IsKetua == 1 {
+			asKetua[row.NIMAnggota] = append(asKetua[row.NIMAnggota], row.KodePengajuan)
+		} else {
+			asAnggota[row.NIMAnggota] = append(asAnggota[row.NIMAnggota], row.KodePengajuan)
+		}
+	}
+	if pending != nil {
+		for _, nim := range nims {
+			asKetua[nim] = append(asKetua[nim], pending.Ketua[nim]...)
+			asAnggota[nim] = append(asAnggota[nim], pending.Anggota[nim]...)
+		}
+	}
+
+	for _, member := range anggota {
+		if member.IsKetua == 1 {
+			if kode := asKetua[member.NIMAnggota]; maxKetua > 0 && len(kode) >= maxKetua {
+				return fmt.Errorf("NIM %s sudah menjadi ketua pada pengajuan %s (maksimal %d pengajuan sebagai ketua per periode)",
+					member.NIMAnggota, strings.Join(kode, ", "), maxKetua)
+			}
+			continue
+		}
+		if kode := asAnggota[member.NIMAnggota]; maxAnggota > 0 && len(kode) >= maxAnggota {
+			return fmt.Errorf("NIM %s sudah menjadi anggota pada pengajuan %s (maksimal %d pengajuan sebagai anggota per periode)",
+				member.NIMAnggota, strings.Join(kode, ", "), maxAnggota)
+		}
+	}
+
+	return nil
+}
+
+// participationLimited false jika batas tidak berlaku (batas 0, tim kosong atau tanpa periode)
+func participationLimited(anggota []models.PengajuanAnggota, idPeriode int) bool {
+	maxKetua := participationLimit(config.AppConfig.MaxKetuaPerPeriode, 1)
+	maxAnggota := participationLimit(config.AppConfig.MaxAnggotaPerPeriode, 2)
+	return (maxKetua > 0 || maxAnggota > 0) && len(anggota) > 0 && idPeriode != 0
+}
+
+// participationNIMs mengembalikan NIM seluruh anggota tim
+func participationNIMs(anggota []models.PengajuanAnggota) []string {
+	nims := make([]string, len(anggota))
+	for i, member := range anggota {
+		nims[i] = member.NIMAnggota
+	}
+	return nims
+}
+
+// participationLimit membaca batas dari config (0 = tanpa batas)
+func participationLimit(value string, fallback int) int {
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return fallback
+	}
+	return limit
+}
+
 // ========================================
 // REGISTRATION PERIOD VALIDATION
 // ========================================
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret adalah secret SHA1 Appendix B RFC 6238 ("12345678901234567890") dalam base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// Vektor RFC 6238 Appendix B (SHA1), 6 digit terakhir dari kode 8 digit
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("TOTPCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TOTPCode() at %d = %q, want %q", tt.unix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOTPCodeSecretFormat(t *testing.T) {
+	want, _ := TOTPCode(rfc6238Secret, 1)
+
+	// Secret yang diketik ulang dari aplikasi: huruf kecil dan spasi di ujung
+	if got, err := TOTPCode(" "+strings.ToLower(rfc6238Secret)+" ", 1); err != nil || got != want {
+		t.Errorf("TOTPCode(lowercase) = %q, %v; want %q", got, err, want)
+	}
+	if _, err := TOTPCode("NOT-BASE32!", 1); err == nil {
+		t.Error("TOTPCode(invalid secret) error = nil, want error")
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+	code, _ := TOTPCode(rfc6238Secret, step)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		wantStep int64
+		wantOK   bool
+	}{
+		{"step yang sama", now, step, true},
+		{"satu step kemudian", now.Add(TOTPPeriod * time.Second), step, true},
+		{"satu step sebelumnya", now.Add(-TOTPPeriod * time.Second), step, true},
+		{"dua step kemudian", now.Add(2 * TOTPPeriod * time.Second), 0, false},
+		{"dua step sebelumnya", now.Add(-2 * TOTPPeriod * time.Second), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(rfc6238Secret, code, tt.at)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() = %d, %v; want %d, %v", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPInput(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		wantOK bool
+	}{
+		{"kode benar", rfc6238Secret, "005924", true},
+		{"spasi di tengah", rfc6238Secret, "005 924", true},
+		{"spasi di ujung", rfc6238Secret, " 005924 ", true},
+		{"kode salah", rfc6238Secret, "005925", false},
+		{"terlalu pendek", rfc6238Secret, "05924", false},
+		{"kode 8 digit", rfc6238Secret, "89005924", false},
+		{"kosong", rfc6238Secret, "", false},
+		{"secret tidak valid", "NOT-BASE32!", "005924", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok != tt.wantOK {
+				t.Errorf("ValidateTOTP(%q) ok = %v, want %v", tt.code, ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("GenerateTOTPSecret() = %q, decoded %d bytes (%v); want 20 bytes", secret, len(key), err)
+	}
+
+	other, _ := GenerateTOTPSecret()
+	if other == secret {
+		t.Error("GenerateTOTPSecret() returned the same secret twice")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("RIRES UMS", "admin@ums.ac.id", rfc6238Secret)
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error = %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("URI = %q, want otpauth://totp/...", uri)
+	}
+	if parsed.Path != "/RIRES UMS:admin@ums.ac.id" {
+		t.Errorf("label = %q, want %q", parsed.Path, "/RIRES UMS:admin@ums.ac.id")
+	}
+
+	query := parsed.Query()
+	want := map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "RIRES UMS",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("query %s = %q, want %q", key, got, value)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"rires-be/internal/models"
+)
+
+// reviewedStatuses adalah hasil review yang bisa dipilih reviewer
+var reviewedStatuses = []string{StatusACC, StatusRevisi, StatusTolak}
+
+// DefaultTransitions mengembalikan alur standar judul -> proposal -> pengumuman final
+func DefaultTransitions() []Transition {
+	return []Transition{
+		// ---------- JUDUL ----------
+		{
+			// Draft tidak divalidasi penuh dan belum terlihat di antrian admin
+			Action:      ActionSaveDraft,
+			Stage:       StageJudul,
+			From:        []string{""},
+			To:          []string{StatusDraft},
+			Roles:       []Role{RoleKetua, RoleAdmin},
+			Effects:     []Effect{setFinal(FinalDraft)},
+			RoleError:   "hanya ketua yang dapat menyimpan draft",
+			StatusError: "pengajuan sudah pernah disimpan",
+		},
+		{
+			Action:      ActionEditDraft,
+			Stage:       StageJudul,
+			From:        []string{StatusDraft},
+			Roles:       []Role{RoleKetua, RoleAdmin},
+			RoleError:   "hanya ketua yang dapat merevisi judul",
+			StatusError: "pengajuan hanya dapat diupdate jika status = DRAFT, PENDING atau REVISI",
+		},
+		{
+			// Pengajuan baru langsung diajukan; anggota masih harus menerima undangan
+			Action:      ActionSubmitJudul,
+			Stage:       StageJudul,
+			From:        []string{""},
+			To:          []string{StatusPending},
+			Roles:       []Role{RoleKetua, RoleAdmin},
+			Effects:     []Effect{setFinal(FinalDraft)},
+			RoleError:   "hanya ketua yang dapat mengajukan judul",
+			StatusError: "pengajuan sudah pernah diajukan",
+		},
+		{
+			// Draft di-submit setelah seluruh anggota menerima undangan
+			Action:      ActionSubmitDraft,
+			Stage:       StageJudul,
+			From:        []string{StatusDraft},
+			To:          []string{StatusPending},
+			Roles:       []Role{RoleKetua, RoleAdmin},
+			Guards:      []Guard{requireTeamConfirmed},
+			Effects:     []Effect{setFinal(FinalDraft)},
+			RoleError:   "hanya ketua yang dapat mengajukan judul",
+			StatusError: "hanya pengajuan berstatus DRAFT yang dapat di-submit",
+		},
+		{
+			// Status PENDING: hanya anggota tim yang boleh diubah, status tidak berubah
+			Action:      ActionEditAnggota,
+			Stage:       StageJudul,
+			From:        []string{StatusPending},
+			Roles:       []Role{RoleKetua, RoleAdmin},
+			RoleError:   "hanya ketua yang dapat merevisi judul",
+			StatusError: "pengajuan hanya dapat diupdate jika status = DRAFT, PENDING atau REVISI",
+		},
+		{
+			// Status REVISI: judul & parameter diperbaiki lalu kembali ke reviewer yang sama
+			Action:      ActionReviseJudul,
+			Stage:       StageJudul,
+			From:        []string{StatusRevisi},
+			To:          []string{StatusOnReview},
+			Roles:       []Role{RoleKetua, RoleAdmin},
+			RoleError:   "hanya ketua yang dapat merevisi judul",
+			StatusError: "pengajuan hanya dapat diupdate jika status = DRAFT, PENDING atau REVISI",
+		},
+		{
+			// Ditarik ketua sebelum judul ACC = soft delete, bisa dipulihkan admin dari recycle bin
+			Action:      ActionWithdraw,
+			Stage:       StageJudul,
+			From:        []string{StatusDraft, StatusPending, StatusOnReview, StatusRevisi},
+			Roles:       []Role{RoleKetua},
+			Effects:     []Effect{softDelete},
+			RoleError:   "hanya ketua yang dapat menarik pengajuan",
+			StatusError: "pengajuan hanya dapat ditarik sebelum judul ACC",
+		},
+		{
+			Action:      ActionAssignReviewerJudul,
+			Stage:       StageJudul,
+			From:        []string{StatusPending, StatusOnReview},
+			To:          []string{StatusOnReview},
+			Roles:       []Role{RoleAdmin},
+			Guards:      []Guard{requireTeamConfirmed, requireEndorsed},
+			Effects:     []Effect{assignReviewer(StageJudul)},
+			StatusError: "reviewer hanya dapat di-assign untuk pengajuan dengan status PENDING atau ON_REVIEW",
+		},
+		{
+			Action:      ActionCancelPlottingJudul,
+			Stage:       StageJudul,
+			From:        []string{StatusOnReview},
+			To:          []string{StatusPending},
+			Roles:       []Role{RoleAdmin},
+			Guards:      []Guard{requireReviewer(StageJudul, "tidak ada reviewer yang di-assign untuk judul ini")},
+			Effects:     []Effect{clearReviewer(StageJudul)},
+			StatusError: "plotting hanya dapat dibatalkan untuk pengajuan dengan status ON_REVIEW",
+		},
+		{
+			Action:      ActionReviewJudul,
+			Stage:       StageJudul,
+			From:        []string{StatusOnReview},
+			To:          reviewedStatuses,
+			Roles:       []Role{RoleReviewer, RoleAdmin},
+			Guards:      []Guard{requireReviewer(StageJudul, "belum ada reviewer yang di-assign untuk judul ini")},
+			RoleError:   "anda tidak memiliki akses untuk mereview pengajuan ini",
+			StatusError: "pengajuan harus dalam status ON_REVIEW untuk dapat direview",
+		},
+		{
+			Action:      ActionCancelReviewJudul,
+			Stage:       StageJudul,
+			From:        reviewedStatuses,
+			To:          []string{StatusOnReview},
+			Roles:       []Role{RoleReviewer, RoleAdmin},
+			RoleError:   "anda tidak memiliki akses untuk membatalkan review pengajuan ini",
+			StatusError: "hanya pengajuan yang sudah direview yang dapat dibatalkan",
+		},
+
+		// ---------- PROPOSAL ----------
+		{
+			// Upload ulang setelah plotting tetap di reviewer yang sama
+			Action:    ActionUploadProposal,
+			Stage:     StageProposal,
+			To:        []string{StatusPending, StatusOnReview},
+			Roles:     []Role{RoleKetua, RoleAdmin},
+			Guards:    []Guard{requireStatus(StageJudul, StatusACC, "proposal hanya dapat diupload jika judul sudah ACC")},
+			Resolve:   keepReviewer(StageProposal),
+			RoleError: "hanya ketua yang dapat upload proposal",
+		},
+		{
+			Action:      ActionReviseProposal,
+			Stage:       StageProposal,
+			From:        []string{StatusRevisi},
+			To:          []string{StatusOnReview},
+			Roles:       []Role{RoleKetua},
+			RoleError:   "hanya ketua yang dapat merevisi proposal",
+			StatusError: "proposal hanya dapat direvisi jika status = REVISI",
+		},
+		{
+			Action:      ActionAssignReviewerProposal,
+			Stage:       StageProposal,
+			From:        []string{StatusPending, StatusOnReview},
+			To:          []string{StatusOnReview},
+			Roles:       []Role{RoleAdmin},
+			Guards:      []Guard{requireProposalFile},
+			Effects:     []Effect{assignReviewer(StageProposal)},
+			StatusError: "reviewer hanya dapat di-assign untuk proposal dengan status PENDING atau ON_REVIEW",
+		},
+		{
+			Action:      ActionCancelPlottingProposal,
+			Stage:       StageProposal,
+			From:        []string{StatusOnReview},
+			To:          []string{StatusPending},
+			Roles:       []Role{RoleAdmin},
+			Guards:      []Guard{requireReviewer(StageProposal, "tidak ada reviewer yang di-assign untuk proposal ini")},
+			Effects:     []Effect{clearReviewer(StageProposal)},
+			StatusError: "plotting hanya dapat dibatalkan untuk proposal dengan status ON_REVIEW",
+		},
+		{
+			Action:      ActionReviewProposal,
+			Stage:       StageProposal,
+			From:        []string{StatusOnReview},
+			To:          reviewedStatuses,
+			Roles:       []Role{RoleReviewer, RoleAdmin},
+			Guards:      []Guard{requireProposalFile, requireReviewer(StageProposal, "belum ada reviewer yang di-assign untuk proposal ini")},
+			RoleError:   "anda tidak memiliki akses untuk mereview pengajuan ini",
+			StatusError: "proposal harus dalam status ON_REVIEW untuk dapat direview",
+		},
+		{
+			Action:      ActionCancelReviewProposal,
+			Stage:       StageProposal,
+			From:        reviewedStatuses,
+			To:          []string{StatusOnReview},
+			Roles:       []Role{RoleReviewer, RoleAdmin},
+			RoleError:   "anda tidak memiliki akses untuk membatalkan review proposal ini",
+			StatusError: "hanya proposal yang sudah direview yang dapat dibatalkan",
+		},
+
+		// ---------- FINAL ----------
+		{
+			Action: ActionAnnounceFinal,
+			Stage:  StageFinal,
+			To:     []string{FinalLolos, FinalTidakLolos},
+			Roles:  []Role{RoleAdmin},
+			Guards: []Guard{
+				requireStatus(StageJudul, StatusACC, "judul harus ACC sebelum pengumuman final"),
+				requireStatus(StageProposal, StatusACC, "proposal harus ACC sebelum pengumuman final"),
+			},
+		},
+	}
+}
+
+// requireStatus memastikan tahap lain sudah mencapai status tertentu
+func requireStatus(stage Stage, status, message string) Guard {
+	return func(p *models.Pengajuan, in Input) error {
+		if stage.Status(p) != status {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+// requireReviewer memastikan tahap sudah punya reviewer
+func requireReviewer(stage Stage, message string) Guard {
+	return func(p *models.Pengajuan, in Input) error {
+		if stage.Reviewer(p) == nil {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+// requireProposalFile memastikan file proposal sudah diupload
+func requireProposalFile(p *models.Pengajuan, in Input) error {
+	if p.FileProposal == "" {
+		return errors.New("proposal belum diupload")
+	}
+	return nil
+}
+
+// requireTeamConfirmed memastikan semua anggota sudah menerima undangan
+func requireTeamConfirmed(p *models.Pengajuan, in Input) error {
+	if len(in.PendingMembers) > 0 {
+		return fmt.Errorf("menunggu konfirmasi anggota tim: %s", strings.Join(in.PendingMembers, ", "))
+	}
+	return nil
+}
+
+// requireEndorsed memastikan judul sudah disahkan dosen pembimbing
+func requireEndorsed(p *models.Pengajuan, in Input) error {
+	if in.EndorsementPending {
+		return errors.New("judul belum disahkan oleh dosen pembimbing")
+	}
+	return nil
+}
+
+// assignReviewer menulis reviewer dari Input ke kolom reviewer tahap
+func assignReviewer(stage Stage) Effect {
+	return func(p *models.Pengajuan, in Input, updates map[string]interface{}) {
+		updates[stage.ReviewerColumn()] = in.Reviewer
+	}
+}
+
+// clearReviewer melepas reviewer tahap
+func clearReviewer(stage Stage) Effect {
+	return func(p *models.Pengajuan, in Input, updates map[string]interface{}) {
+		updates[stage.ReviewerColumn()] = nil
+	}
+}
+
+// softDelete menandai pengajuan terhapus (hapus = 1)
+func softDelete(p *models.Pengajuan, in Input, updates map[string]interface{}) {
+	updates["hapus"] = 1
+}
+
+// setFinal mengisi status_final
+func setFinal(status string) Effect {
+	return func(p *models.Pengajuan, in Input, updates map[string]interface{}) {
+		StageFinal.setStatus(p, status, updates)
+	}
+}
+
+// keepReviewer memilih ON_REVIEW jika reviewer sudah di-plot, selain itu PENDING
+func keepReviewer(stage Stage) func(p *models.Pengajuan, in Input) string {
+	return func(p *models.Pengajuan, in Input) string {
+		if stage.Reviewer(p) != nil {
+			return StatusOnReview
+		}
+		return StatusPending
+	}
+}
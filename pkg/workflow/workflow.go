@@ -0,0 +1,416 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"rires-be/internal/models"
+)
+
+// Status yang dipakai di status_judul / status_proposal / status_final
+const (
+	StatusDraft    = "DRAFT" // judul disimpan mahasiswa, belum masuk antrian plotting
+	StatusPending  = "PENDING"
+	StatusOnReview = "ON_REVIEW"
+	StatusACC      = "ACC"
+	StatusRevisi   = "REVISI"
+	StatusTolak    = "TOLAK"
+
+	FinalDraft      = "DRAFT"
+	FinalSubmitted  = "SUBMITTED"
+	FinalLolos      = "LOLOS"
+	FinalTidakLolos = "TIDAK_LOLOS"
+)
+
+// Stage adalah tahap pengajuan yang punya kolom status sendiri
+type Stage string
+
+const (
+	StageJudul    Stage = "JUDUL"
+	StageProposal Stage = "PROPOSAL"
+	StageFinal    Stage = "FINAL"
+)
+
+// StatusColumn mengembalikan nama kolom status untuk tahap ini
+func (s Stage) StatusColumn() string {
+	switch s {
+	case StageJudul:
+		return "status_judul"
+	case StageProposal:
+		return "status_proposal"
+	case StageFinal:
+		return "status_final"
+	}
+	return ""
+}
+
+// ReviewerColumn mengembalikan nama kolom reviewer untuk tahap ini (kosong untuk FINAL)
+func (s Stage) ReviewerColumn() string {
+	switch s {
+	case StageJudul:
+		return "id_reviewer_judul"
+	case StageProposal:
+		return "id_reviewer_proposal"
+	}
+	return ""
+}
+
+// Status membaca status pengajuan pada tahap ini
+func (s Stage) Status(p *models.Pengajuan) string {
+	switch s {
+	case StageJudul:
+		return p.StatusJudul
+	case StageProposal:
+		return p.StatusProposal
+	case StageFinal:
+		return p.StatusFinal
+	}
+	return ""
+}
+
+// Reviewer membaca id_pegawai reviewer yang di-plot pada tahap ini
+func (s Stage) Reviewer(p *models.Pengajuan) *int {
+	switch s {
+	case StageJudul:
+		return p.IDReviewerJudul
+	case StageProposal:
+		return p.IDReviewerProposal
+	}
+	return nil
+}
+
+// setStatus menulis status ke struct dan ke map updates
+func (s Stage) setStatus(p *models.Pengajuan, status string, updates map[string]interface{}) {
+	switch s {
+	case StageJudul:
+		p.StatusJudul = status
+	case StageProposal:
+		p.StatusProposal = status
+	case StageFinal:
+		p.StatusFinal = status
+	}
+	updates[s.StatusColumn()] = status
+}
+
+// Role adalah peran aktor terhadap satu pengajuan
+type Role string
+
+const (
+	RoleKetua    Role = "ketua"
+	RoleReviewer Role = "reviewer" // reviewer yang di-plot pada tahap transisi
+	RoleAdmin    Role = "admin"
+)
+
+// Action adalah aksi yang mengubah status pengajuan
+type Action string
+
+const (
+	ActionSaveDraft              Action = "SAVE_DRAFT"
+	ActionEditDraft              Action = "EDIT_DRAFT"
+	ActionSubmitJudul            Action = "SUBMIT_JUDUL"
+	ActionSubmitDraft            Action = "SUBMIT_DRAFT"
+	ActionEditAnggota            Action = "EDIT_ANGGOTA"
+	ActionWithdraw               Action = "WITHDRAW"
+	ActionReviseJudul            Action = "REVISE_JUDUL"
+	ActionAssignReviewerJudul    Action = "ASSIGN_REVIEWER_JUDUL"
+	ActionCancelPlottingJudul    Action = "CANCEL_PLOTTING_JUDUL"
+	ActionReviewJudul            Action = "REVIEW_JUDUL"
+	ActionCancelReviewJudul      Action = "CANCEL_REVIEW_JUDUL"
+	ActionUploadProposal         Action = "UPLOAD_PROPOSAL"
+	ActionReviseProposal         Action = "REVISE_PROPOSAL"
+	ActionAssignReviewerProposal Action = "ASSIGN_REVIEWER_PROPOSAL"
+	ActionCancelPlottingProposal Action = "CANCEL_PLOTTING_PROPOSAL"
+	ActionReviewProposal         Action = "REVIEW_PROPOSAL"
+	ActionCancelReviewProposal   Action = "CANCEL_REVIEW_PROPOSAL"
+	ActionAnnounceFinal          Action = "ANNOUNCE_FINAL"
+)
+
+// ErrUnknownAction dikembalikan jika aksi tidak terdaftar di engine
+var ErrUnknownAction = errors.New("aksi workflow tidak dikenal")
+
+// Actor adalah pihak yang menjalankan aksi
+type Actor struct {
+	NIM        string // mahasiswa yang login
+	IDPegawai  int    // reviewer yang login
+	IsAdmin    bool
+	UserUpdate string // diisi ke kolom user_update
+}
+
+// Input adalah parameter tambahan sebuah transisi
+type Input struct {
+	Actor    Actor
+	Target   string // status tujuan untuk transisi dengan lebih dari satu To (hasil review, pengumuman final)
+	Reviewer int    // id_pegawai untuk aksi plotting
+
+	// PendingMembers adalah NIM anggota yang undangannya belum diterima
+	PendingMembers []string
+
+	// EndorsementPending true jika dosen pembimbing belum menyetujui judul
+	EndorsementPending bool
+}
+
+// Guard adalah precondition transisi, mengembalikan error jika tidak terpenuhi
+type Guard func(p *models.Pengajuan, in Input) error
+
+// Effect menambahkan perubahan kolom selain status
+type Effect func(p *models.Pengajuan, in Input, updates map[string]interface{})
+
+// Transition mendefinisikan satu aksi: tahap, status asal, status tujuan, peran dan aturan tambahan
+type Transition struct {
+	Action Action
+	Stage  Stage
+	From   []string // nil = status apa pun, "" = belum ada status
+	To     []string // lebih dari satu: dipilih lewat Input.Target atau Resolve
+	Roles  []Role
+
+	// Resolve memilih status tujuan jika To lebih dari satu dan Input.Target kosong
+	Resolve func(p *models.Pengajuan, in Input) string
+
+	Guards  []Guard
+	Effects []Effect
+
+	RoleError   string
+	StatusError string
+}
+
+// Engine menyimpan definisi transisi default dan override per kategori PKM
+type Engine struct {
+	mu       sync.RWMutex
+	defaults map[Action]Transition
+	kategori map[int]map[Action]Transition
+}
+
+// NewEngine membuat engine dengan daftar transisi default
+func NewEngine(transitions ...Transition) *Engine {
+	e := &Engine{
+		defaults: make(map[Action]Transition),
+		kategori: make(map[int]map[Action]Transition),
+	}
+	for _, t := range transitions {
+		e.defaults[t.Action] = t
+	}
+	return e
+}
+
+var (
+	defaultEngine     *Engine
+	defaultEngineOnce sync.Once
+)
+
+// Default mengembalikan engine bersama berisi alur PKM standar
+func Default() *Engine {
+	defaultEngineOnce.Do(func() {
+		defaultEngine = NewEngine(DefaultTransitions()...)
+	})
+	return defaultEngine
+}
+
+// RegisterKategori mengganti transisi tertentu untuk satu kategori PKM.
+// Aksi yang tidak di-override tetap memakai definisi default.
+func (e *Engine) RegisterKategori(idKategori int, transitions ...Transition) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	overrides, ok := e.kategori[idKategori]
+	if !ok {
+		overrides = make(map[Action]Transition)
+		e.kategori[idKategori] = overrides
+	}
+	for _, t := range transitions {
+		overrides[t.Action] = t
+	}
+}
+
+// Transition mengembalikan definisi aksi yang berlaku untuk pengajuan (override kategori dulu)
+func (e *Engine) Transition(p *models.Pengajuan, action Action) (Transition, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if p != nil {
+		if t, ok := e.kategori[p.IDKategori][action]; ok {
+			return t, nil
+		}
+	}
+	if t, ok := e.defaults[action]; ok {
+		return t, nil
+	}
+	return Transition{}, fmt.Errorf("%w: %s", ErrUnknownAction, action)
+}
+
+// Allows memeriksa precondition dan status asal tanpa memeriksa peran aktor
+func (e *Engine) Allows(p *models.Pengajuan, action Action) error {
+	if p == nil {
+		return errors.New("pengajuan is nil")
+	}
+
+	t, err := e.Transition(p, action)
+	if err != nil {
+		return err
+	}
+	return t.allows(p, Input{})
+}
+
+// Check memeriksa peran aktor, precondition dan status asal
+func (e *Engine) Check(p *models.Pengajuan, action Action, actor Actor) error {
+	if p == nil {
+		return errors.New("pengajuan is nil")
+	}
+
+	t, err := e.Transition(p, action)
+	if err != nil {
+		return err
+	}
+
+	in := Input{Actor: actor}
+	if !t.permits(p, actor) {
+		return t.roleError()
+	}
+	return t.allows(p, in)
+}
+
+// Can adalah versi boolean dari Check
+func (e *Engine) Can(p *models.Pengajuan, action Action, actor Actor) bool {
+	return e.Check(p, action, actor) == nil
+}
+
+// Apply menjalankan transisi: memvalidasi aturan, menulis status baru ke struct
+// dan mengembalikan kolom yang harus di-update (termasuk efek samping dan user_update).
+func (e *Engine) Apply(p *models.Pengajuan, action Action, in Input) (map[string]interface{}, error) {
+	if p == nil {
+		return nil, errors.New("pengajuan is nil")
+	}
+
+	t, err := e.Transition(p, action)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.permits(p, in.Actor) {
+		return nil, t.roleError()
+	}
+	if err := t.allows(p, in); err != nil {
+		return nil, err
+	}
+
+	target, err := t.target(p, in)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	for _, effect := range t.Effects {
+		effect(p, in, updates)
+	}
+	if target != "" {
+		t.Stage.setStatus(p, target, updates)
+	}
+	if in.Actor.UserUpdate != "" {
+		updates["user_update"] = in.Actor.UserUpdate
+	}
+
+	return updates, nil
+}
+
+// ApplyFirst menjalankan aksi pertama yang diizinkan dari daftar.
+// Jika tidak ada yang lolos, error dari aksi pertama yang dikembalikan.
+func (e *Engine) ApplyFirst(p *models.Pengajuan, in Input, actions ...Action) (Action, map[string]interface{}, error) {
+	var firstErr error
+	for _, action := range actions {
+		if err := e.Check(p, action, in.Actor); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		updates, err := e.Apply(p, action, in)
+		return action, updates, err
+	}
+
+	if firstErr == nil {
+		firstErr = ErrUnknownAction
+	}
+	return "", nil, firstErr
+}
+
+// permits memeriksa apakah aktor punya salah satu peran transisi
+func (t Transition) permits(p *models.Pengajuan, actor Actor) bool {
+	if len(t.Roles) == 0 {
+		return true
+	}
+
+	for _, role := range t.Roles {
+		switch role {
+		case RoleAdmin:
+			if actor.IsAdmin {
+				return true
+			}
+		case RoleKetua:
+			if actor.NIM != "" && p.NIMKetua == actor.NIM {
+				return true
+			}
+		case RoleReviewer:
+			reviewer := t.Stage.Reviewer(p)
+			if actor.IDPegawai > 0 && reviewer != nil && *reviewer == actor.IDPegawai {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allows menjalankan guard lalu memeriksa status asal
+func (t Transition) allows(p *models.Pengajuan, in Input) error {
+	for _, guard := range t.Guards {
+		if err := guard(p, in); err != nil {
+			return err
+		}
+	}
+
+	if t.From == nil {
+		return nil
+	}
+
+	current := t.Stage.Status(p)
+	for _, from := range t.From {
+		if current == from {
+			return nil
+		}
+	}
+
+	if t.StatusError != "" {
+		return errors.New(t.StatusError)
+	}
+	return fmt.Errorf("aksi %s tidak dapat dilakukan pada status %s", t.Action, current)
+}
+
+// target menentukan status tujuan transisi
+func (t Transition) target(p *models.Pengajuan, in Input) (string, error) {
+	switch {
+	case len(t.To) == 0:
+		return "", nil
+	case len(t.To) == 1 && in.Target == "":
+		return t.To[0], nil
+	}
+
+	target := in.Target
+	if target == "" && t.Resolve != nil {
+		target = t.Resolve(p, in)
+	}
+
+	for _, to := range t.To {
+		if target == to {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("status %s tidak berlaku untuk aksi %s", target, t.Action)
+}
+
+// roleError mengembalikan pesan penolakan peran
+func (t Transition) roleError() error {
+	if t.RoleError != "" {
+		return errors.New(t.RoleError)
+	}
+	return fmt.Errorf("anda tidak memiliki akses untuk aksi %s", t.Action)
+}